@@ -3,11 +3,11 @@ package flarc
 
 import (
 	"context"
-	"fmt"
 	"io"
+	"strings"
 	"text/template"
 
-	"github.com/youta-t/flarc/flarcerror"
+	"github.com/youta-t/flarc/completion"
 	"github.com/youta-t/flarc/help"
 	"github.com/youta-t/flarc/params"
 	"github.com/youta-t/flarc/parser"
@@ -50,7 +50,12 @@ func NewCommand[T any](
 	aa := make([]params.ArgDef, len(args))
 	copy(aa, args)
 
-	parser, err := parser.New(&flagdef, args)
+	parser, err := parser.New(&flagdef, args, opt.parserOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := completableFlags(parser.Flags(), opt.flagCompleters)
 	if err != nil {
 		return nil, err
 	}
@@ -59,42 +64,188 @@ func NewCommand[T any](
 		shortDescription: shortDescription,
 		task:             task,
 		parser:           parser,
+		flags:            flags,
+		rawDescription:   opt.rawDescription,
 		description:      opt.description,
+		passthrough:      opt.passthrough,
+		bindArgs:         opt.bindArgs,
 	}, nil
 }
 
+// TaskWithArgs is a task of commands declared with NewCommandWithArgs.
+type TaskWithArgs[T, A any] func(context.Context, CommandlineWithArgs[T, A], []any) error
+
+// NewCommandWithArgs creates new command, declaring positional arguments with a struct.
+//
+// # Args
+//
+// - shortDescription: short (one line or less) description for this command.
+//
+// - flagdef: a struct defining flags for this command
+//
+// - argdef: a struct defining positional arguments for this command.
+// Fields are converted in the same way as flags, and slice fields are repeatable.
+// Tags are `arg:"NAME"` (by default, the field name in upper snake case),
+// `required:"true"`, `help:"..."` and `choices:"..."`.
+// Values of argdef are used when the arg is not given.
+//
+// - task: task of this command.
+// CommandlineWithArgs.TypedArgs() is reflected argdef.
+//
+// - option: options
+func NewCommandWithArgs[T, A any](
+	shortDescription string,
+	flagdef T, argdef A, task TaskWithArgs[T, A],
+	option ...CommandOption,
+) (Command, error) {
+	typed, err := parser.NewTypedArgs(&argdef)
+	if err != nil {
+		return nil, err
+	}
+
+	// args are converted in Parse, to report invalid ones with other usage errors.
+	bindArgs := func() (parser.ParseOption, func() any) {
+		args := new(*A)
+		return parser.BindArgs(typed, args), func() any { return *args }
+	}
+
+	return NewCommand(
+		shortDescription, flagdef, Args(typed.Defs()),
+		func(ctx context.Context, cl Commandline[T], params []any) error {
+			args := cl.(commandline[T]).typedArgs.(*A)
+			return task(ctx, commandlineWithArgs[T, A]{Commandline: cl, typedArgs: *args}, params)
+		},
+		append(option[:len(option):len(option)], withArgsBinder(bindArgs))...,
+	)
+}
+
+// withArgsBinder sets bindArgs, which returns a ParseOption converting positional args for each Parse,
+// and a function returning the converted value after Parse.
+func withArgsBinder(bindArgs func() (parser.ParseOption, func() any)) CommandOption {
+	return func(p *commandOption) (*commandOption, error) {
+		p.bindArgs = bindArgs
+		return p, nil
+	}
+}
+
 type CommandOption func(*commandOption) (*commandOption, error)
 
 type commandOption struct {
-	description *template.Template
+	rawDescription string
+	description    *template.Template
+	parserOptions  []parser.Option
+	flagCompleters []flagCompleter
+	passthrough    bool
+	bindArgs       func() (parser.ParseOption, func() any)
 }
 
 func WithDescription(d string) CommandOption {
 	return func(p *commandOption) (*commandOption, error) {
 		var err error
+		p.rawDescription = d
 		p.description, err = template.New("").Parse(d)
 		return p, err
 	}
 }
 
+// WithEnvPrefix binds environment variables to flags without env tag.
+//
+// See parser.WithEnvPrefix.
+func WithEnvPrefix(prefix string) CommandOption {
+	return func(p *commandOption) (*commandOption, error) {
+		p.parserOptions = append(p.parserOptions, parser.WithEnvPrefix(prefix))
+		return p, nil
+	}
+}
+
+// WithFlagConstraints adds constraints among flags.
+//
+// Violations are reported as ErrUsage.
+func WithFlagConstraints(cs ...Constraint) CommandOption {
+	return func(p *commandOption) (*commandOption, error) {
+		p.parserOptions = append(p.parserOptions, parser.WithConstraints(cs...))
+		return p, nil
+	}
+}
+
+// WithFlagCompleter sets a completer for values of the flag, used in shell completion.
+//
+// flag is a flag name or an alias, with or without leading "-".
+// directive tells shells how to complete in addition to candidates.
+func WithFlagCompleter(flag string, complete completion.Completer, directive completion.Directive) CommandOption {
+	return func(p *commandOption) (*commandOption, error) {
+		p.flagCompleters = append(p.flagCompleters, flagCompleter{
+			flag: flag, complete: complete, directive: directive,
+		})
+		return p, nil
+	}
+}
+
+// WithUnknownFlagsPassthrough makes the command to accept unknown flags as positional args.
+//
+// This is for commands forwarding their args to other tools.
+// By default, unknown flags are ErrUnknownFlag.
+func WithUnknownFlagsPassthrough() CommandOption {
+	return func(p *commandOption) (*commandOption, error) {
+		p.passthrough = true
+		return p, nil
+	}
+}
+
 type command[T any] struct {
 	shortDescription string
+	rawDescription   string
 	description      *template.Template
 
 	parser parser.Parser[T]
 
+	// flags are flags of parser, with completers.
+	flags []completableFlag
+
 	task Task[T]
+
+	// passthrough is true if unknown flags are positional args.
+	passthrough bool
+
+	// bindArgs returns a ParseOption converting positional args, and a function returning them after Parse.
+	// It is set for commands declared with NewCommandWithArgs.
+	bindArgs func() (parser.ParseOption, func() any)
 }
 
 func (cmd command[T]) ShortDescription() string {
 	return cmd.shortDescription
 }
 
+func (cmd command[T]) Description() string {
+	return cmd.rawDescription
+}
+
+func (cmd command[T]) Flags() []params.Flag {
+	return cmd.parser.Flags()
+}
+
+func (cmd command[T]) Args() []params.Arg {
+	return cmd.parser.Args()
+}
+
+func (cmd command[T]) Subcommands() map[string]Command {
+	return nil
+}
+
+func (cmd command[T]) listConstraints() []Constraint {
+	return cmd.parser.Constraints()
+}
+
+func (cmd command[T]) descriptionTemplate() *template.Template {
+	return cmd.description
+}
+
 func (cmd command[T]) newHelp(fullname string) help.Help {
 	h := help.New(
 		fullname, cmd.ShortDescription(),
 		help.WithDescription(cmd.description),
 		help.WitArgs(cmd.parser.Args()),
+		help.WithConstraints(constraintDescriptions(cmd.parser.Constraints())),
 	)
 	h.AppendFlags(cmd.parser.Flags()...)
 	return h
@@ -105,22 +256,45 @@ func (cmd command[T]) prepare(
 	stdin io.Reader,
 	stdout io.Writer,
 	stderr io.Writer,
+	lookupEnv func(string) (string, bool),
+	config parser.Config,
 	args []string,
+	argIndices []int,
+	inherited []params.Flag,
+	groups []flagSources,
 	params ...any,
 ) runner {
-	flags, argv, rem, err := cmd.parser.Parse(args)
-	if err != nil {
-		return runner{
-			Run:  func(context.Context) error { return err },
-			Help: func() help.Help { return cmd.newHelp(fullname) },
-		}
+	complete := func(
+		ctx context.Context, partial string, inherited []completableFlag,
+	) ([]completion.Candidate, completion.Directive) {
+		flags := append(cmd.flags[:len(cmd.flags):len(cmd.flags)], inherited...)
+		return completeWord(ctx, flags, cmd.parser.Args(), nil, args, partial)
+	}
+
+	sources := parser.Sources{}
+	parseOptions := []parser.ParseOption{
+		parser.WithLookupEnv(lookupEnv),
+		parser.WithConfig(config),
+		parser.RecordSources(sources),
+		parser.WithArgIndices(argIndices),
+		parser.SuggestingFlags(inherited...),
+		parser.RejectRestArgs(),
+	}
+	if cmd.passthrough {
+		parseOptions = append(parseOptions, parser.AllowUnknownFlags())
+	}
+	var typedArgs func() any
+	if cmd.bindArgs != nil {
+		var bind parser.ParseOption
+		bind, typedArgs = cmd.bindArgs()
+		parseOptions = append(parseOptions, bind)
 	}
-	if 0 < len(rem) {
+	flags, argv, _, err := cmd.parser.Parse(args, parseOptions...)
+	if err != nil {
 		return runner{
-			Run: func(context.Context) error {
-				return fmt.Errorf("%w: too much args", flarcerror.ErrUsage)
-			},
-			Help: func() help.Help { return cmd.newHelp(fullname) },
+			Run:      func(context.Context) error { return err },
+			Help:     func() help.Help { return cmd.newHelp(fullname) },
+			Complete: complete,
 		}
 	}
 
@@ -130,15 +304,22 @@ func (cmd command[T]) prepare(
 		stdout:   stdout,
 		stderr:   stderr,
 		flags:    *flags,
+		flagDefs: cmd.parser.Flags(),
+		sources:  sources,
+		groups:   groups,
 		args:     argv,
 		params:   params,
 	}
+	if typedArgs != nil {
+		cl.typedArgs = typedArgs()
+	}
 
 	return runner{
 		Run: func(ctx context.Context) error {
 			return cmd.task(ctx, cl, params)
 		},
-		Help: func() help.Help { return cmd.newHelp(fullname) },
+		Help:     func() help.Help { return cmd.newHelp(fullname) },
+		Complete: complete,
 	}
 }
 
@@ -149,9 +330,15 @@ type commandline[T any] struct {
 	stdout io.Writer
 	stderr io.Writer
 
-	flags  T
-	args   map[string][]string
-	params []any
+	flags    T
+	flagDefs []params.Flag
+	sources  parser.Sources
+	groups   []flagSources
+	args     map[string][]string
+	params   []any
+
+	// typedArgs is positional args converted by bindArgs of the command, if any.
+	typedArgs any
 }
 
 // Commandline represents commandline interface.
@@ -171,10 +358,46 @@ type Commandline[T any] interface {
 	// Flags returns flags passed on commandline.
 	Flags() T
 
+	// IsSet returns true when the flag is given explicitly,
+	// by commandline, environment variable or configuration file.
+	//
+	// name is a flag name or an alias, with or without leading "-".
+	// Flags of command groups of this command are also looked up.
+	IsSet(name string) bool
+
+	// Source returns where the value of the flag comes from.
+	//
+	// name is a flag name or an alias, with or without leading "-".
+	// Flags of command groups of this command are also looked up.
+	// For unknown names, it returns SourceDefault.
+	Source(name string) Source
+
+	// Changed returns names of flags given explicitly, in declaration order.
+	//
+	// Flags of command groups follow ones of this command, from the nearest group.
+	Changed() []string
+
 	// Args returns positional argument values for each positinal arguments' name.
 	Args() map[string][]string
 }
 
+// CommandlineWithArgs is Commandline of commands declared with NewCommandWithArgs.
+type CommandlineWithArgs[T, A any] interface {
+	Commandline[T]
+
+	// TypedArgs returns positional arguments converted into the struct.
+	TypedArgs() A
+}
+
+type commandlineWithArgs[T, A any] struct {
+	Commandline[T]
+	typedArgs A
+}
+
+func (t commandlineWithArgs[T, A]) TypedArgs() A {
+	return t.typedArgs
+}
+
 func (t commandline[T]) Fullname() string {
 	return t.fullname
 }
@@ -195,6 +418,46 @@ func (t commandline[T]) Flags() T {
 	return t.flags
 }
 
+func (t commandline[T]) IsSet(name string) bool {
+	return t.Source(name) != SourceDefault
+}
+
+func (t commandline[T]) Source(name string) Source {
+	name = strings.TrimLeft(name, "-")
+	for _, fs := range t.flagSources() {
+		for _, f := range fs.flags {
+			if f.Match(name) {
+				return fs.sources[strings.TrimLeft(f.Name(), "-")]
+			}
+		}
+	}
+	return SourceDefault
+}
+
+func (t commandline[T]) Changed() []string {
+	changed := []string{}
+	for _, fs := range t.flagSources() {
+		for _, f := range fs.flags {
+			name := strings.TrimLeft(f.Name(), "-")
+			if fs.sources[name] != SourceDefault {
+				changed = append(changed, name)
+			}
+		}
+	}
+	return changed
+}
+
+// flagSources returns flags of this command and its command groups, from the nearest.
+func (t commandline[T]) flagSources() []flagSources {
+	return append([]flagSources{{flags: t.flagDefs, sources: t.sources}}, t.groups...)
+}
+
+// flagSources are flags with where their values come from.
+type flagSources struct {
+	flags   []params.Flag
+	sources parser.Sources
+}
+
 func (t commandline[T]) Args() map[string][]string {
 	return t.args
 }
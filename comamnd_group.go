@@ -4,14 +4,38 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 	"text/template"
 
+	"github.com/youta-t/flarc/completion"
 	"github.com/youta-t/flarc/flarcerror"
 	"github.com/youta-t/flarc/help"
 	"github.com/youta-t/flarc/params"
 	"github.com/youta-t/flarc/parser"
+	"github.com/youta-t/flarc/utils"
 )
 
+// UnknownSubcommandError is returned when an unknown subcommand is given.
+type UnknownSubcommandError struct {
+	// Name is the given subcommand name.
+	Name string
+
+	// Suggestions are names of subcommands similar to Name, most similar first.
+	Suggestions []string
+}
+
+func (e *UnknownSubcommandError) Error() string {
+	msg := fmt.Sprintf("%s: unknown subcommand: %s", ErrUsage, e.Name)
+	if dym := utils.DidYouMean(e.Suggestions); dym != "" {
+		msg += " (" + dym + ")"
+	}
+	return msg
+}
+
+func (e *UnknownSubcommandError) Unwrap() error {
+	return ErrUsage
+}
+
 // NewCommandGroup creates command group.
 //
 // # Args
@@ -39,7 +63,15 @@ func NewCommandGroup[T any](
 		}
 	}
 
-	parser, err := parser.New(&flagdef, []params.ArgDef{})
+	parser, err := parser.New(&flagdef, []params.ArgDef{}, opt.parserOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSubcommandConflicts(parser.Flags(), opt.subCommands); err != nil {
+		return nil, err
+	}
+
+	flags, err := completableFlags(parser.Flags(), opt.flagCompleters)
 	if err != nil {
 		return nil, err
 	}
@@ -47,29 +79,89 @@ func NewCommandGroup[T any](
 	cg := &commandGroup[T]{
 		shortDescription: shortDescription,
 		parser:           parser,
+		flags:            flags,
 
-		description: opt.description,
-		subcommands: opt.subCommands,
+		rawDescription: opt.rawDescription,
+		description:    opt.description,
+		subcommands:    opt.subCommands,
 	}
 
 	return cg, nil
 }
 
+// checkSubcommandConflicts reports flags of subcommands, and their descendants, sharing names with flags of the group.
+//
+// Such flags cannot be given, because the group takes them.
+func checkSubcommandConflicts(flags []params.Flag, subcommands map[string]Command) error {
+	for name, sub := range subcommands {
+		err := Walk(sub, func(path []string, c Command) error {
+			if err := parser.CheckFlagConflicts(flags, c.Flags()); err != nil {
+				return fmt.Errorf(
+					"%w, in the group and its subcommand %s",
+					err, strings.Join(append([]string{name}, path...), " "),
+				)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type CommandGroupOption func(*commandGroupOption) (*commandGroupOption, error)
 
 type commandGroupOption struct {
-	description *template.Template
-	subCommands map[string]Command
+	rawDescription string
+	description    *template.Template
+	subCommands    map[string]Command
+	parserOptions  []parser.Option
+	flagCompleters []flagCompleter
 }
 
 func WithGroupDescription(d string) CommandGroupOption {
 	return func(p *commandGroupOption) (*commandGroupOption, error) {
 		var err error
+		p.rawDescription = d
 		p.description, err = template.New("").Parse(d)
 		return p, err
 	}
 }
 
+// WithGroupEnvPrefix binds environment variables to flags of the group without env tag.
+//
+// See parser.WithEnvPrefix.
+func WithGroupEnvPrefix(prefix string) CommandGroupOption {
+	return func(p *commandGroupOption) (*commandGroupOption, error) {
+		p.parserOptions = append(p.parserOptions, parser.WithEnvPrefix(prefix))
+		return p, nil
+	}
+}
+
+// WithGroupFlagConstraints adds constraints among flags of the group.
+//
+// Violations are reported as ErrUsage.
+func WithGroupFlagConstraints(cs ...Constraint) CommandGroupOption {
+	return func(p *commandGroupOption) (*commandGroupOption, error) {
+		p.parserOptions = append(p.parserOptions, parser.WithConstraints(cs...))
+		return p, nil
+	}
+}
+
+// WithGroupFlagCompleter sets a completer for values of the flag of the group, used in shell completion.
+//
+// flag is a flag name or an alias, with or without leading "-".
+// directive tells shells how to complete in addition to candidates.
+func WithGroupFlagCompleter(flag string, complete completion.Completer, directive completion.Directive) CommandGroupOption {
+	return func(p *commandGroupOption) (*commandGroupOption, error) {
+		p.flagCompleters = append(p.flagCompleters, flagCompleter{
+			flag: flag, complete: complete, directive: directive,
+		})
+		return p, nil
+	}
+}
+
 func WithSubcommand(name string, c Command) CommandGroupOption {
 	return func(p *commandGroupOption) (*commandGroupOption, error) {
 		if _, ok := p.subCommands[name]; ok {
@@ -81,23 +173,50 @@ func WithSubcommand(name string, c Command) CommandGroupOption {
 }
 
 type commandGroup[T any] struct {
-	name             string
 	shortDescription string
+	rawDescription   string
 	description      *template.Template
 
 	parser parser.Parser[T]
 
-	subcommands map[string]Command
-}
+	// flags are flags of parser, with completers.
+	flags []completableFlag
 
-func (cg *commandGroup[T]) Name() string {
-	return cg.name
+	subcommands map[string]Command
 }
 
 func (cg *commandGroup[T]) ShortDescription() string {
 	return cg.shortDescription
 }
 
+func (cg *commandGroup[T]) Flags() []params.Flag {
+	return cg.parser.Flags()
+}
+
+func (cg *commandGroup[T]) Args() []params.Arg {
+	return nil
+}
+
+func (cg *commandGroup[T]) Description() string {
+	return cg.rawDescription
+}
+
+func (cg *commandGroup[T]) Subcommands() map[string]Command {
+	subs := make(map[string]Command, len(cg.subcommands))
+	for name, sub := range cg.subcommands {
+		subs[name] = sub
+	}
+	return subs
+}
+
+func (cg *commandGroup[T]) listConstraints() []Constraint {
+	return cg.parser.Constraints()
+}
+
+func (cg *commandGroup[T]) descriptionTemplate() *template.Template {
+	return cg.description
+}
+
 func (cg *commandGroup[T]) newHelp(fullname string) help.Help {
 	cmds := map[string]help.CommandDescriptor{}
 	for name := range cg.subcommands {
@@ -109,6 +228,7 @@ func (cg *commandGroup[T]) newHelp(fullname string) help.Help {
 		help.WithFlags(cg.parser.Flags()),
 		help.WithDescription(cg.description),
 		help.WithSubcommands(cmds),
+		help.WithConstraints(constraintDescriptions(cg.parser.Constraints())),
 	)
 }
 
@@ -117,15 +237,101 @@ func (cg *commandGroup[T]) prepare(
 	stdin io.Reader,
 	stdout io.Writer,
 	stderr io.Writer,
+	lookupEnv func(string) (string, bool),
+	config parser.Config,
 	args []string,
+	argIndices []int,
+	inherited []params.Flag,
+	groups []flagSources,
 	params ...any,
 ) runner {
+	complete := func(
+		ctx context.Context, partial string, inherited []completableFlag,
+	) ([]completion.Candidate, completion.Directive) {
+		flags := append(cg.flags[:len(cg.flags):len(cg.flags)], inherited...)
+		return completeWord(ctx, flags, nil, cg.subcommands, args, partial)
+	}
 
-	flags, _, rem, err := cg.parser.Parse(args)
+	config, subConfigs, err := splitConfig(config, cg.subcommands)
+	if err != nil {
+		return runner{
+			Run:      func(context.Context) error { return err },
+			Help:     func() help.Help { return cg.newHelp(fullname) },
+			Complete: complete,
+		}
+	}
+
+	groupFlags := cg.parser.Flags()
+	// unknown flags of subcommands may be mistyped ones of this group or its ancestors.
+	suggesting := append(groupFlags[:len(groupFlags):len(groupFlags)], inherited...)
+
+	// prepareSub prepares the subcommand named rem[0], if it exists.
+	prepareSub := func(flags *T, sources parser.Sources, rem []string, remIndices []int) (runner, bool) {
+		sub, ok := cg.subcommands[rem[0]]
+		if !ok {
+			return runner{}, false
+		}
+
+		p := append([]any{}, params...)
+		p = append(p, *flags)
+
+		r := sub.prepare(
+			fullname+" "+rem[0], stdin, stdout, stderr,
+			lookupEnv, subConfigs[rem[0]],
+			rem[1:], remIndices[1:], suggesting,
+			append([]flagSources{{flags: groupFlags, sources: sources}}, groups...), p...,
+		)
+
+		return runner{
+			Run: r.Run,
+			Help: func() help.Help {
+				h := r.Help()
+				h.AppendFlags(cg.parser.Flags()...)
+				h.AppendConstraints(constraintDescriptions(cg.parser.Constraints())...)
+				return h
+			},
+			Complete: func(
+				ctx context.Context, partial string, inherited []completableFlag,
+			) ([]completion.Candidate, completion.Directive) {
+				flags := append(cg.flags[:len(cg.flags):len(cg.flags)], inherited...)
+				return r.Complete(ctx, partial, flags)
+			},
+		}, true
+	}
+
+	// flags not for this group are left for subcommands.
+	parse := func(remIndices *[]int, options ...parser.ParseOption) (*T, []string, error) {
+		flags, _, rem, err := cg.parser.Parse(
+			args,
+			append([]parser.ParseOption{
+				parser.WithLookupEnv(lookupEnv), parser.WithConfig(config),
+				parser.AllowUnknownFlags(),
+				parser.WithArgIndices(argIndices), parser.RecordRestIndices(remIndices),
+			}, options...)...,
+		)
+		return flags, rem, err
+	}
+
+	remIndices := []int{}
+	sources := parser.Sources{}
+	flags, rem, err := parse(&remIndices, parser.RecordSources(sources))
 	if err != nil {
 		return runner{
 			Run:  func(context.Context) error { return err },
 			Help: func() help.Help { return cg.newHelp(fullname) },
+			Complete: func(
+				ctx context.Context, partial string, inherited []completableFlag,
+			) ([]completion.Candidate, completion.Directive) {
+				// flags being typed may not satisfy required flags and constraints yet.
+				remIndices := []int{}
+				flags, rem, err := parse(&remIndices, parser.SkipValidation())
+				if err == nil && 0 < len(rem) {
+					if r, ok := prepareSub(flags, parser.Sources{}, rem, remIndices); ok {
+						return r.Complete(ctx, partial, inherited)
+					}
+				}
+				return complete(ctx, partial, inherited)
+			},
 		}
 	}
 
@@ -134,32 +340,37 @@ func (cg *commandGroup[T]) prepare(
 			Run: func(context.Context) error {
 				return fmt.Errorf("%w: no subcommands", flarcerror.ErrUsage)
 			},
-			Help: func() help.Help { return cg.newHelp(fullname) },
+			Help:     func() help.Help { return cg.newHelp(fullname) },
+			Complete: complete,
 		}
 	}
 
-	for name, sub := range cg.subcommands {
-		if name == rem[0] {
-			p := append([]any{}, params...)
-			p = append(p, *flags)
-
-			r := sub.prepare(fullname+" "+name, stdin, stdout, stderr, rem[1:], p...)
-
-			return runner{
-				Run: r.Run,
-				Help: func() help.Help {
-					h := r.Help()
-					h.AppendFlags(cg.parser.Flags()...)
-					return h
-				},
-			}
+	if r, ok := prepareSub(flags, sources, rem, remIndices); ok {
+		return r
+	}
+
+	if strings.HasPrefix(rem[0], "-") {
+		flag, _, _ := strings.Cut(rem[0], "=")
+		return runner{
+			Run: func(ctx context.Context) error {
+				return &parser.UnknownFlagError{
+					Index: remIndices[0], Flag: flag, Suggestions: parser.SuggestFlags(flag, suggesting),
+				}
+			},
+			Help:     func() help.Help { return cg.newHelp(fullname) },
+			Complete: complete,
 		}
 	}
 
 	return runner{
 		Run: func(ctx context.Context) error {
-			return fmt.Errorf("%w: unknown subcommand: %s", flarcerror.ErrUsage, rem[0])
+			names := make([]string, 0, len(cg.subcommands))
+			for name := range cg.subcommands {
+				names = append(names, name)
+			}
+			return &UnknownSubcommandError{Name: rem[0], Suggestions: utils.Suggest(rem[0], names)}
 		},
-		Help: func() help.Help { return cg.newHelp(fullname) },
+		Help:     func() help.Help { return cg.newHelp(fullname) },
+		Complete: complete,
 	}
 }
@@ -0,0 +1,286 @@
+package flarc
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/youta-t/flarc/completion"
+	"github.com/youta-t/flarc/params"
+	"github.com/youta-t/flarc/parser"
+)
+
+// completionCommand is the name of hidden subcommand printing completion scripts.
+const completionCommand = "completion"
+
+func completionTree(name string, cmd Command) completion.Command {
+	c := completion.Command{
+		Name:             name,
+		ShortDescription: cmd.ShortDescription(),
+	}
+	for _, f := range cmd.Flags() {
+		c.Flags = append(c.Flags, f)
+	}
+	for _, a := range cmd.Args() {
+		c.Args = append(c.Args, a)
+	}
+	for n, sub := range cmd.Subcommands() {
+		c.Subcommands = append(c.Subcommands, completionTree(n, sub))
+	}
+	return c
+}
+
+func runCompletion(runOpt *runOption, cmd Command, helpPsr parser.Parser[helper], args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(
+			runOpt.stderr, "usage: %s %s {%s}\n",
+			runOpt.name, completionCommand, strings.Join(completion.Shells, "|"),
+		)
+		return 2
+	}
+
+	tree := completionTree(runOpt.name, cmd)
+	tree.Dynamic = true
+	if helpPsr != nil {
+		for _, f := range helpPsr.Flags() {
+			tree.Flags = append(tree.Flags, f)
+		}
+	}
+	if err := completion.Write(runOpt.stdout, args[0], tree); err != nil {
+		fmt.Fprintln(runOpt.stderr, err)
+		return 2
+	}
+	return 0
+}
+
+// runDynamicCompletion prints candidates for the last of args.
+//
+// The command tree is walked in the same way as running, with args except the last.
+func runDynamicCompletion(
+	ctx context.Context, runOpt *runOption, cmd Command, helpPsr parser.Parser[helper],
+	config parser.Config, args []string,
+) int {
+	partial := ""
+	if 0 < len(args) {
+		partial = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	inherited := []completableFlag{}
+	if helpPsr != nil {
+		// keep "--" and words after it, to complete them as positional args.
+		head, tail := args, []string{}
+		if i := slices.Index(args, "--"); 0 <= i {
+			head, tail = args[:i], args[i:]
+		}
+		if _, _, rem, err := helpPsr.Parse(head, parser.AllowUnknownFlags()); err == nil {
+			args = append(rem, tail...)
+		}
+		for _, f := range helpPsr.Flags() {
+			inherited = append(inherited, completableFlag{Flag: f})
+		}
+	}
+
+	r := cmd.prepare(
+		runOpt.name,
+		runOpt.stdin, runOpt.stdout, runOpt.stderr,
+		runOpt.lookupEnv, config,
+		args, nil, nil, nil, runOpt.params...,
+	)
+	candidates, directive := r.Complete(ctx, partial, inherited)
+	if err := completion.WriteCandidates(runOpt.stdout, candidates, directive); err != nil {
+		fmt.Fprintln(runOpt.stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// flagCompleter is a completer for values of the flag.
+type flagCompleter struct {
+	flag      string
+	complete  completion.Completer
+	directive completion.Directive
+}
+
+// completableFlag is a flag with its completer.
+type completableFlag struct {
+	params.Flag
+	complete  completion.Completer
+	directive completion.Directive
+}
+
+// Complete returns candidates for the value of the flag.
+//
+// Without completers, choices of the flag are candidates.
+func (f completableFlag) Complete(ctx context.Context, partial string) ([]completion.Candidate, completion.Directive) {
+	if f.complete != nil {
+		return f.complete(ctx, partial), f.directive
+	}
+	if cs := f.Choices(); 0 < len(cs) {
+		candidates := []completion.Candidate{}
+		for _, c := range cs {
+			candidates = append(candidates, completion.Candidate{Value: c})
+		}
+		return completion.Filter(candidates, partial), completion.DirectiveNoFiles
+	}
+	return nil, f.directive
+}
+
+// completableFlags attaches completers to flags.
+func completableFlags(flags []params.Flag, completers []flagCompleter) ([]completableFlag, error) {
+	cfs := make([]completableFlag, len(flags))
+	for i := range flags {
+		cfs[i] = completableFlag{Flag: flags[i]}
+	}
+
+COMPLETERS:
+	for _, c := range completers {
+		name := strings.TrimLeft(c.flag, "-")
+		for i := range cfs {
+			if !cfs[i].Match(name) {
+				continue
+			}
+			cfs[i].complete = c.complete
+			cfs[i].directive = c.directive
+			continue COMPLETERS
+		}
+		return nil, fmt.Errorf("completer for unknown flag: %s", c.flag)
+	}
+	return cfs, nil
+}
+
+// completeWord returns candidates for partial, the word typed after words.
+//
+// words are commandline given to the command, without the command name.
+func completeWord(
+	ctx context.Context,
+	flags []completableFlag, args []params.Arg, subcommands map[string]Command,
+	words []string, partial string,
+) ([]completion.Candidate, completion.Directive) {
+	dashdash := slices.Contains(words, "--")
+
+	if name, val, ok := strings.Cut(partial, "="); ok && !dashdash && strings.HasPrefix(name, "-") {
+		if f := valueFlag(flags, name); f != nil {
+			return f.Complete(ctx, val)
+		}
+		return nil, completion.DirectiveNoFiles
+	}
+
+	if 0 < len(words) && !dashdash {
+		if f := valueFlag(flags, words[len(words)-1]); f != nil {
+			return f.Complete(ctx, partial)
+		}
+	}
+
+	if strings.HasPrefix(partial, "-") && !dashdash {
+		candidates := []completion.Candidate{}
+		for _, f := range flags {
+			help, _, _ := strings.Cut(f.Help(), "\n")
+			names := append([]string{f.Name()}, f.Alias()...)
+			for _, n := range names {
+				candidates = append(candidates, completion.Candidate{Value: n, Description: help})
+			}
+			if !f.Negatable() {
+				continue
+			}
+			for _, n := range names {
+				if l, ok := strings.CutPrefix(n, "--"); ok {
+					candidates = append(candidates, completion.Candidate{Value: "--no-" + l})
+				}
+			}
+		}
+		return completion.Filter(candidates, partial), completion.DirectiveNoFiles
+	}
+
+	if 0 < len(subcommands) {
+		candidates := []completion.Candidate{}
+		for name, sub := range subcommands {
+			candidates = append(candidates, completion.Candidate{
+				Value: name, Description: sub.ShortDescription(),
+			})
+		}
+		slices.SortFunc(candidates, func(a, b completion.Candidate) int {
+			return cmp.Compare(a.Value, b.Value)
+		})
+		return completion.Filter(candidates, partial), completion.DirectiveNoFiles
+	}
+
+	if a := positionalAt(flags, args, words); a != nil {
+		return a.Complete(ctx, partial)
+	}
+	return nil, completion.DirectiveDefault
+}
+
+// valueFlag returns the flag waiting its value as the next word, for token.
+//
+// If token is not a flag or the flag takes no more values, it returns nil.
+func valueFlag(flags []completableFlag, token string) *completableFlag {
+	find := func(name string) *completableFlag {
+		for i := range flags {
+			if flags[i].Match(name) {
+				return &flags[i]
+			}
+		}
+		return nil
+	}
+
+	if strings.Contains(token, "=") {
+		// the value is given, like "--flag=value".
+		return nil
+	}
+
+	if name, ok := strings.CutPrefix(token, "--"); ok {
+		if f := find(name); f != nil && f.NeedsValue() {
+			return f
+		}
+		return nil
+	}
+
+	cluster, ok := strings.CutPrefix(token, "-")
+	if !ok {
+		return nil
+	}
+	for i := 0; i < len(cluster); i += 1 {
+		f := find(cluster[i : i+1])
+		if f == nil {
+			return nil
+		}
+		if f.NeedsValue() {
+			if i == len(cluster)-1 {
+				return f
+			}
+			// the rest of cluster is the value.
+			return nil
+		}
+	}
+	return nil
+}
+
+// positionalAt returns the positional arg for the word after words.
+func positionalAt(flags []completableFlag, args []params.Arg, words []string) params.Arg {
+	n := 0
+	for i := 0; i < len(words); i += 1 {
+		w := words[i]
+		if w == "--" {
+			n += len(words) - i - 1
+			break
+		}
+		if strings.HasPrefix(w, "-") && 1 < len(w) {
+			if valueFlag(flags, w) != nil {
+				i += 1
+			}
+			continue
+		}
+		n += 1
+	}
+
+	for _, a := range args {
+		if a.Repeatable() || n == 0 {
+			return a
+		}
+		n -= 1
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package completion
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Bash writes a completion script for bash.
+//
+// Load it with `source <(cmd completion bash)`.
+func Bash(w io.Writer, cmd Command) error {
+	fn := "_" + ident(cmd.Name) + "_complete"
+	nodes := walk(cmd)
+
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "# bash completion for %s\n", cmd.Name)
+	if cmd.Dynamic {
+		sb.WriteString(bashDynamic(cmd))
+	}
+	fmt.Fprintf(sb, "%s() {\n", fn)
+	sb.WriteString(`    local cur prev path w i
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev=""
+    if (( COMP_CWORD > 1 )); then
+        prev="${COMP_WORDS[COMP_CWORD-1]}"
+    fi
+    if [[ "${prev}" == "=" ]] && (( COMP_CWORD > 2 )); then
+        prev="${COMP_WORDS[COMP_CWORD-2]}"
+    fi
+    if [[ "${cur}" == "=" ]]; then
+        cur=""
+    fi
+
+    path=""
+    for (( i = 1; i < COMP_CWORD; i++ )); do
+        w="${COMP_WORDS[i]}"
+        case "${path} ${w}" in
+`)
+	if subs := subcommandPaths(nodes); 0 < len(subs) {
+		fmt.Fprintf(sb, "        %s)\n", strings.Join(subs, "|"))
+		sb.WriteString("            path=\"${path} ${w}\"\n")
+		sb.WriteString("            ;;\n")
+	}
+	sb.WriteString(`        esac
+    done
+
+    case "${path}" in
+`)
+	for _, n := range nodes {
+		fmt.Fprintf(sb, "    %s)\n", quote(pathString(n.path)))
+
+		valued := []string{}
+		for _, f := range n.flags {
+			if !f.NeedsValue() {
+				continue
+			}
+			pats := []string{}
+			for _, name := range names(f) {
+				pats = append(pats, quote(name))
+			}
+			action := fmt.Sprintf("COMPREPLY=( $(compgen -W %s -- \"${cur}\") )", quote(strings.Join(f.Choices(), " ")))
+			if cmd.Dynamic {
+				action = bashDynamicFn(cmd)
+			}
+			valued = append(valued, fmt.Sprintf(
+				"        %s)\n            %s\n            return\n            ;;\n",
+				strings.Join(pats, "|"), action,
+			))
+		}
+		if 0 < len(valued) {
+			sb.WriteString("        case \"${prev}\" in\n")
+			for _, v := range valued {
+				sb.WriteString(v)
+			}
+			sb.WriteString("        esac\n")
+		}
+
+		flagWords := []string{}
+		for _, f := range n.flags {
+			flagWords = append(flagWords, names(f)...)
+			if f.Negatable() {
+				for _, name := range names(f) {
+					if l, ok := strings.CutPrefix(name, "--"); ok {
+						flagWords = append(flagWords, "--no-"+l)
+					}
+				}
+			}
+		}
+		sb.WriteString("        if [[ \"${cur}\" == -* ]]; then\n")
+		fmt.Fprintf(sb, "            COMPREPLY=( $(compgen -W %s -- \"${cur}\") )\n", quote(strings.Join(flagWords, " ")))
+		sb.WriteString("            return\n")
+		sb.WriteString("        fi\n")
+
+		if 0 < len(n.cmd.Subcommands) {
+			subWords := []string{}
+			for _, sub := range sortedSubcommands(n.cmd) {
+				subWords = append(subWords, sub.Name)
+			}
+			fmt.Fprintf(sb, "        COMPREPLY=( $(compgen -W %s -- \"${cur}\") )\n", quote(strings.Join(subWords, " ")))
+		} else if cmd.Dynamic && 0 < len(n.cmd.Args) {
+			fmt.Fprintf(sb, "        %s\n", bashDynamicFn(cmd))
+		}
+		sb.WriteString("        ;;\n")
+	}
+	sb.WriteString("    esac\n")
+	sb.WriteString("}\n")
+	fmt.Fprintf(sb, "complete -o default -F %s %s\n", fn, cmd.Name)
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// subcommandPaths returns quoted paths of subcommands, as patterns for case statement.
+func subcommandPaths(nodes []node) []string {
+	pats := []string{}
+	for _, n := range nodes {
+		if len(n.path) == 0 {
+			continue
+		}
+		pats = append(pats, quote(pathString(n.path)))
+	}
+	return pats
+}
+
+func pathString(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	return " " + strings.Join(path, " ")
+}
+
+func bashDynamicFn(cmd Command) string {
+	return "_" + ident(cmd.Name) + "_dynamic"
+}
+
+// bashDynamic returns a function asking candidates to the command with DynamicCommand.
+//
+// It should be called in the completion function, to use its local variable cur.
+func bashDynamic(cmd Command) string {
+	return bashDynamicFn(cmd) + `() {
+    local -a args=()
+    local out line directive=0
+    for (( i = 1; i <= COMP_CWORD; i++ )); do
+        w="${COMP_WORDS[i]}"
+        if (( i > 1 )) && [[ "${w}" == "=" || "${COMP_WORDS[i-1]}" == "=" ]]; then
+            args[${#args[@]}-1]+="${w}"
+        else
+            args+=("${w}")
+        fi
+    done
+    out="$("${COMP_WORDS[0]}" ` + DynamicCommand + ` "${args[@]}" 2>/dev/null)" || return
+    COMPREPLY=()
+    while IFS= read -r line; do
+        case "${line}" in
+        :*)
+            directive="${line#:}"
+            ;;
+        *)
+            line="${line%%$'\t'*}"
+            if [[ "${line}" == "${cur}"* ]]; then
+                COMPREPLY+=("${line}")
+            fi
+            ;;
+        esac
+    done <<< "${out}"
+    if (( directive & ` + fmt.Sprint(int(DirectiveNoSpace)) + ` )); then
+        compopt -o nospace 2>/dev/null
+    fi
+    if (( directive & ` + fmt.Sprint(int(DirectiveNoFiles)) + ` )); then
+        compopt +o default 2>/dev/null
+    fi
+    if (( directive & ` + fmt.Sprint(int(DirectiveFiles)) + ` )); then
+        COMPREPLY+=( $(compgen -f -- "${cur}") )
+    fi
+}
+`
+}
@@ -0,0 +1,136 @@
+// Package completion generates shell completion scripts from command trees.
+package completion
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Flag is a flag to be completed.
+//
+// params.Flag satisfies this.
+type Flag interface {
+	Name() string
+	Alias() []string
+	Help() string
+	NeedsValue() bool
+	Negatable() bool
+	Choices() []string
+}
+
+// Arg is a positional argument to be completed.
+//
+// params.Arg satisfies this.
+type Arg interface {
+	Name() string
+	Required() bool
+	Repeatable() bool
+}
+
+// Command is a node of the command tree to be completed.
+type Command struct {
+	// Name of this command.
+	//
+	// For the root, it is the name of the executable.
+	Name string
+
+	ShortDescription string
+
+	// Flags of this command.
+	//
+	// Flags of ancestors are also completed for this command.
+	Flags []Flag
+
+	// Args are positional arguments of this command.
+	Args []Arg
+
+	Subcommands []Command
+
+	// Dynamic makes scripts ask the command for values of flags and args,
+	// with the hidden subcommand "__complete".
+	//
+	// Only the one of the root is used.
+	Dynamic bool
+}
+
+// Shells are names of supported shells.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Write writes a completion script for shell.
+func Write(w io.Writer, shell string, cmd Command) error {
+	switch shell {
+	case "bash":
+		return Bash(w, cmd)
+	case "zsh":
+		return Zsh(w, cmd)
+	case "fish":
+		return Fish(w, cmd)
+	}
+	return fmt.Errorf("%w: %s (supported: %s)", ErrUnknownShell, shell, strings.Join(Shells, ", "))
+}
+
+// ErrUnknownShell is returned when completion for the shell is not supported.
+var ErrUnknownShell = fmt.Errorf("unknown shell")
+
+// node is a command in the tree with its location.
+type node struct {
+	// path is names of subcommands from the root, excluding the root.
+	path []string
+
+	cmd Command
+
+	// flags are flags of cmd and its ancestors.
+	flags []Flag
+}
+
+// walk lists commands in the tree, depth first, subcommands sorted by name.
+func walk(root Command) []node {
+	nodes := []node{}
+
+	var visit func(path []string, cmd Command, inherited []Flag)
+	visit = func(path []string, cmd Command, inherited []Flag) {
+		flags := append(cmd.Flags[:len(cmd.Flags):len(cmd.Flags)], inherited...)
+		nodes = append(nodes, node{path: path, cmd: cmd, flags: flags})
+
+		for _, sub := range sortedSubcommands(cmd) {
+			visit(append(path[:len(path):len(path)], sub.Name), sub, flags)
+		}
+	}
+	visit([]string{}, root, nil)
+
+	return nodes
+}
+
+func sortedSubcommands(cmd Command) []Command {
+	subs := append([]Command{}, cmd.Subcommands...)
+	slices.SortFunc(subs, func(a, b Command) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return subs
+}
+
+// names returns the name and aliases of f, with leading hyphens.
+func names(f Flag) []string {
+	return append([]string{f.Name()}, f.Alias()...)
+}
+
+func firstLine(s string) string {
+	l, _, _ := strings.Cut(s, "\n")
+	return l
+}
+
+var reNotIdent = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// ident makes s usable as a part of shell function names.
+func ident(s string) string {
+	return reNotIdent.ReplaceAllString(s, "_")
+}
+
+// quote quotes s with single quotes, for shells.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,282 @@
+package completion_test
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/youta-t/flarc/completion"
+	"github.com/youta-t/flarc/params"
+	"github.com/youta-t/its"
+)
+
+func flagsOf(t *testing.T, flagdef any) []completion.Flag {
+	t.Helper()
+	rv := reflect.ValueOf(flagdef)
+	flags := []completion.Flag{}
+	for i := 0; i < rv.NumField(); i += 1 {
+		f, err := params.NewFlag(rv.Type().Field(i), rv.Field(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		flags = append(flags, f)
+	}
+	return flags
+}
+
+func testTree(t *testing.T) completion.Command {
+	type GroupFlag struct {
+		Region string `alias:"r" help:"region: it's [where]"`
+	}
+	type SubFlag struct {
+		Format string `alias:"f" choices:"json,yaml" help:"output format"`
+		Dry    bool   `help:"dry run"`
+	}
+
+	leaf := func(name string) completion.Command {
+		return completion.Command{
+			Name:             name,
+			ShortDescription: "sub command",
+			Flags:            flagsOf(t, SubFlag{}),
+			Args: []completion.Arg{
+				params.ArgDef{Name: "SRC", Required: true, Repeatable: true}.Freeze(),
+				params.ArgDef{Name: "DEST"}.Freeze(),
+			},
+		}
+	}
+
+	return completion.Command{
+		Name:             "my-app",
+		ShortDescription: "group",
+		Flags:            flagsOf(t, GroupFlag{}),
+		Subcommands: []completion.Command{
+			leaf("sub"),
+			{
+				Name:             "inner",
+				ShortDescription: "inner group",
+				Subcommands:      []completion.Command{leaf("leaf")},
+			},
+		},
+	}
+}
+
+func TestBash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not found")
+	}
+
+	script := new(strings.Builder)
+	if err := completion.Bash(script, testTree(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	theory := func(words []string, want []string) func(*testing.T) {
+		return func(t *testing.T) {
+			quoted := make([]string, len(words))
+			for i := range words {
+				quoted[i] = "'" + words[i] + "'"
+			}
+			cmd := exec.Command(
+				bash, "-c",
+				script.String()+`
+COMP_WORDS=(`+strings.Join(quoted, " ")+`)
+COMP_CWORD=$(( ${#COMP_WORDS[@]} - 1 ))
+_my_app_complete
+printf '%s\n' "${COMPREPLY[@]}"
+`,
+			)
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("%s: %s", err, out)
+			}
+			got := strings.Fields(string(out))
+
+			matchers := []its.Matcher[string]{}
+			for _, w := range want {
+				matchers = append(matchers, its.EqEq(w))
+			}
+			its.Slice(matchers...).Match(got).OrError(t)
+		}
+	}
+
+	t.Run("subcommands", theory([]string{"my-app", ""}, []string{"inner", "sub"}))
+	t.Run("subcommands with prefix", theory([]string{"my-app", "-r", "x", "s"}, []string{"sub"}))
+	t.Run("nested subcommands", theory([]string{"my-app", "inner", ""}, []string{"leaf"}))
+	t.Run("flags", theory([]string{"my-app", "-"}, []string{"--region", "-r"}))
+	t.Run("flags of subcommand, with ones of group", theory(
+		[]string{"my-app", "inner", "leaf", "--"},
+		[]string{"--format", "--dry", "--no-dry", "--region"},
+	))
+	t.Run("choices", theory([]string{"my-app", "sub", "-f", ""}, []string{"json", "yaml"}))
+	t.Run("choices after =", theory([]string{"my-app", "sub", "--format", "=", "y"}, []string{"yaml"}))
+	t.Run("value without choices", theory([]string{"my-app", "--region", ""}, []string{}))
+}
+
+func TestBash_dynamic(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not found")
+	}
+
+	tree := testTree(t)
+	tree.Dynamic = true
+	script := new(strings.Builder)
+	if err := completion.Bash(script, tree); err != nil {
+		t.Fatal(err)
+	}
+
+	theory := func(words []string, want []string) func(*testing.T) {
+		return func(t *testing.T) {
+			quoted := make([]string, len(words))
+			for i := range words {
+				quoted[i] = "'" + words[i] + "'"
+			}
+			// my-app reports its args, and then prints candidates.
+			argsFile := filepath.Join(t.TempDir(), "args")
+			cmd := exec.Command(
+				bash, "-c",
+				script.String()+`
+my-app() {
+    echo "args:$(IFS=,; echo "$*")" > '`+argsFile+`'
+    printf 'json\tJSON format\n'
+    printf 'yaml\n'
+    printf ':%d\n' `+fmt.Sprint(int(completion.DirectiveNoFiles))+`
+}
+compopt() { :; }
+COMP_WORDS=(`+strings.Join(quoted, " ")+`)
+COMP_CWORD=$(( ${#COMP_WORDS[@]} - 1 ))
+_my_app_complete
+cat '`+argsFile+`' 2>/dev/null
+printf '%s\n' "${COMPREPLY[@]}"
+`,
+			)
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("%s: %s", err, out)
+			}
+			got := strings.Fields(string(out))
+
+			matchers := []its.Matcher[string]{}
+			for _, w := range want {
+				matchers = append(matchers, its.EqEq(w))
+			}
+			its.Slice(matchers...).Match(got).OrError(t)
+		}
+	}
+
+	t.Run("value of flag", theory(
+		[]string{"my-app", "--region", ""},
+		[]string{"args:__complete,--region,", "json", "yaml"},
+	))
+	t.Run("value of flag after =", theory(
+		[]string{"my-app", "sub", "--format", "=", "y"},
+		[]string{"args:__complete,sub,--format=y", "yaml"},
+	))
+	t.Run("positional args", theory(
+		[]string{"my-app", "inner", "leaf", "x", "j"},
+		[]string{"args:__complete,inner,leaf,x,j", "json"},
+	))
+	t.Run("flags are static", theory(
+		[]string{"my-app", "sub", "--f"},
+		[]string{"--format"},
+	))
+	t.Run("subcommands are static", theory(
+		[]string{"my-app", ""},
+		[]string{"inner", "sub"},
+	))
+}
+
+func TestZsh(t *testing.T) {
+	script := new(strings.Builder)
+	if err := completion.Zsh(script, testTree(t)); err != nil {
+		t.Fatal(err)
+	}
+	got := script.String()
+
+	its.All(
+		its.StringHavingPrefix("#compdef my-app\n"),
+		its.StringContaining(`'(--region -r)'{--region=,-r}'[region\: it'\''s \[where\]]:region:_files'`),
+		its.StringContaining(`'(--format -f)'{--format=,-f}'[output format]:format:(json yaml)'`),
+		its.StringContaining(`'--dry[dry run]'`),
+		its.StringContaining(`'--no-dry'`),
+		its.StringContaining(`'inner:inner group'`),
+		its.StringContaining(`'*:SRC:_files'`),
+		its.StringContaining(`'::DEST:_files'`),
+		its.StringContaining("_my_app_inner_leaf() {\n"),
+		its.StringContaining("compdef _my_app my-app\n"),
+	).Match(got).OrError(t)
+}
+
+func TestFish(t *testing.T) {
+	script := new(strings.Builder)
+	if err := completion.Fish(script, testTree(t)); err != nil {
+		t.Fatal(err)
+	}
+	got := script.String()
+
+	its.All(
+		its.StringContaining("            case ' inner' ' inner leaf' ' sub'\n"),
+		its.StringContaining(`complete -c my-app -n '__my_app_at '\'''\''' -a 'inner' -d 'inner group'`),
+		its.StringContaining(`complete -c my-app -n '__my_app_at '\'''\''' -l 'region' -s 'r' -r -F -d 'region: it'\''s [where]'`),
+		its.StringContaining(`complete -c my-app -n '__my_app_at '\'' sub'\''' -l 'format' -s 'f' -x -a 'json yaml' -d 'output format'`),
+		its.StringContaining(`complete -c my-app -n '__my_app_at '\'' sub'\''' -l 'no-dry'`),
+		its.StringContaining(`complete -c my-app -n '__my_app_at '\'' inner leaf'\''' -F`),
+	).Match(got).OrError(t)
+}
+
+func TestZsh_dynamic(t *testing.T) {
+	tree := testTree(t)
+	tree.Dynamic = true
+	script := new(strings.Builder)
+	if err := completion.Zsh(script, tree); err != nil {
+		t.Fatal(err)
+	}
+	got := script.String()
+
+	its.All(
+		its.StringContaining("_my_app_dynamic() {\n"),
+		its.StringContaining(" __complete "),
+		its.StringContaining("    local -a _my_app_words=(\"${words[@]}\")\n"),
+		its.StringContaining(`'(--region -r)'{--region=,-r}'[region\: it'\''s \[where\]]:region:_my_app_dynamic'`),
+		its.StringContaining(`'(--format -f)'{--format=,-f}'[output format]:format:_my_app_dynamic'`),
+		its.StringContaining(`'*:SRC:_my_app_dynamic'`),
+		its.StringContaining(`'::DEST:_my_app_dynamic'`),
+	).Match(got).OrError(t)
+}
+
+func TestFish_dynamic(t *testing.T) {
+	tree := testTree(t)
+	tree.Dynamic = true
+	script := new(strings.Builder)
+	if err := completion.Fish(script, tree); err != nil {
+		t.Fatal(err)
+	}
+	got := script.String()
+
+	its.All(
+		its.StringContaining("function __my_app_dynamic\n"),
+		its.StringContaining(" __complete "),
+		its.StringContaining(`complete -c my-app -n '__my_app_at '\'''\''' -l 'region' -s 'r' -x -a '(__my_app_dynamic)' -d 'region: it'\''s [where]'`),
+		its.StringContaining(`complete -c my-app -n '__my_app_at '\'' sub'\''' -l 'format' -s 'f' -x -a '(__my_app_dynamic)' -d 'output format'`),
+		its.StringContaining(`complete -c my-app -n '__my_app_at '\'' inner leaf'\''' -a '(__my_app_dynamic)'`),
+	).Match(got).OrError(t)
+}
+
+func TestWriteCandidates(t *testing.T) {
+	got := new(strings.Builder)
+	err := completion.WriteCandidates(got, []completion.Candidate{
+		{Value: "json", Description: "JSON format\nmore lines"},
+		{Value: "yaml"},
+	}, completion.DirectiveNoSpace|completion.DirectiveNoFiles)
+	its.Nil[error]().Match(err).OrError(t)
+	its.Text("json\tJSON format\nyaml\n:3\n").Match(got.String()).OrError(t)
+}
+
+func TestWrite_unknownShell(t *testing.T) {
+	err := completion.Write(new(strings.Builder), "tcsh", testTree(t))
+	its.Error(completion.ErrUnknownShell).Match(err).OrError(t)
+}
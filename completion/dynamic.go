@@ -0,0 +1,73 @@
+package completion
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DynamicCommand is the name of the hidden subcommand for dynamic completion.
+//
+// Scripts call it as `cmd __complete ARGS... PARTIAL`, where PARTIAL is the word being completed
+// (it can be empty). The command prints candidates, one per line, as "VALUE" or
+// "VALUE\tDESCRIPTION", and then the directive as ":N" in the last line.
+//
+// For flags with value like "--flag=PARTIAL", candidates are values without "--flag=".
+const DynamicCommand = "__complete"
+
+// Candidate is a completion candidate.
+type Candidate struct {
+	Value       string
+	Description string
+}
+
+// Completer returns candidates for partial, the word being completed.
+//
+// Candidates not having partial as prefix are ignored by shells.
+type Completer func(ctx context.Context, partial string) []Candidate
+
+// Directive tells shells how to complete, in addition to candidates.
+type Directive int
+
+const (
+	// DirectiveDefault makes shells complete file names when no candidates are found.
+	DirectiveDefault Directive = 0
+
+	// DirectiveNoSpace makes shells not to add a space after the completed word.
+	//
+	// This is ignored in fish.
+	DirectiveNoSpace Directive = 1 << (iota - 1)
+
+	// DirectiveNoFiles makes shells not to complete file names, even if no candidates are found.
+	DirectiveNoFiles
+
+	// DirectiveFiles makes shells complete file names, in addition to candidates.
+	DirectiveFiles
+)
+
+// WriteCandidates writes candidates and directive in the format of DynamicCommand.
+func WriteCandidates(w io.Writer, candidates []Candidate, directive Directive) error {
+	sb := new(strings.Builder)
+	for _, c := range candidates {
+		v := firstLine(c.Value)
+		if d := firstLine(c.Description); d != "" {
+			v += "\t" + d
+		}
+		sb.WriteString(v + "\n")
+	}
+	fmt.Fprintf(sb, ":%d\n", directive)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// Filter returns candidates having prefix.
+func Filter(candidates []Candidate, prefix string) []Candidate {
+	ret := []Candidate{}
+	for _, c := range candidates {
+		if strings.HasPrefix(c.Value, prefix) {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,126 @@
+package completion
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Fish writes a completion script for fish.
+//
+// Put it as a file named "cmd.fish" in ~/.config/fish/completions,
+// or load it with `cmd completion fish | source`.
+func Fish(w io.Writer, cmd Command) error {
+	prefix := "__" + ident(cmd.Name)
+	nodes := walk(cmd)
+
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "# fish completion for %s\n", cmd.Name)
+
+	fmt.Fprintf(sb, "function %s_path\n", prefix)
+	sb.WriteString("    set -l path ''\n")
+	sb.WriteString("    set -l tokens (commandline -opc)\n")
+	sb.WriteString("    set -e tokens[1]\n")
+	sb.WriteString("    for t in $tokens\n")
+	if subs := subcommandPaths(nodes); 0 < len(subs) {
+		sb.WriteString("        switch \"$path $t\"\n")
+		fmt.Fprintf(sb, "            case %s\n", strings.Join(subs, " "))
+		sb.WriteString("                set path \"$path $t\"\n")
+		sb.WriteString("        end\n")
+	}
+	sb.WriteString("    end\n")
+	sb.WriteString("    echo \"$path\"\n")
+	sb.WriteString("end\n\n")
+
+	fmt.Fprintf(sb, "function %s_at\n", prefix)
+	fmt.Fprintf(sb, "    test (%s_path) = \"$argv[1]\"\n", prefix)
+	sb.WriteString("end\n\n")
+
+	if cmd.Dynamic {
+		sb.WriteString(fishDynamic(prefix))
+	}
+
+	fmt.Fprintf(sb, "complete -c %s -f\n", cmd.Name)
+
+	for _, n := range nodes {
+		cond := "-n " + quote(prefix+"_at "+quote(pathString(n.path)))
+
+		for _, sub := range sortedSubcommands(n.cmd) {
+			fmt.Fprintf(sb, "complete -c %s %s -a %s", cmd.Name, cond, quote(sub.Name))
+			if sd := firstLine(sub.ShortDescription); sd != "" {
+				fmt.Fprintf(sb, " -d %s", quote(sd))
+			}
+			sb.WriteString("\n")
+		}
+
+		for _, f := range n.flags {
+			opts := ""
+			for _, name := range names(f) {
+				if l, ok := strings.CutPrefix(name, "--"); ok {
+					opts += " -l " + quote(l)
+				} else {
+					opts += " -s " + quote(strings.TrimPrefix(name, "-"))
+				}
+			}
+			if f.NeedsValue() {
+				if cmd.Dynamic {
+					opts += " -x -a " + quote("("+prefix+"_dynamic)")
+				} else if cs := f.Choices(); 0 < len(cs) {
+					opts += " -x -a " + quote(strings.Join(cs, " "))
+				} else {
+					opts += " -r -F"
+				}
+			}
+			if h := firstLine(f.Help()); h != "" {
+				opts += " -d " + quote(h)
+			}
+			fmt.Fprintf(sb, "complete -c %s %s%s\n", cmd.Name, cond, opts)
+
+			if f.Negatable() {
+				for _, name := range names(f) {
+					if l, ok := strings.CutPrefix(name, "--"); ok {
+						fmt.Fprintf(sb, "complete -c %s %s -l %s\n", cmd.Name, cond, quote("no-"+l))
+					}
+				}
+			}
+		}
+
+		if len(n.cmd.Subcommands) == 0 && 0 < len(n.cmd.Args) {
+			if cmd.Dynamic {
+				fmt.Fprintf(sb, "complete -c %s %s -a %s\n", cmd.Name, cond, quote("("+prefix+"_dynamic)"))
+			} else {
+				fmt.Fprintf(sb, "complete -c %s %s -F\n", cmd.Name, cond)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// fishDynamic returns a function asking candidates to the command with DynamicCommand.
+func fishDynamic(prefix string) string {
+	return "function " + prefix + `_dynamic
+    set -l tokens (commandline -opc)
+    set -l cmd $tokens[1]
+    set -e tokens[1]
+    set -l current (commandline -ct)
+    set -l directive 0
+    set -l found 0
+    for line in ($cmd ` + DynamicCommand + ` $tokens "$current" 2>/dev/null)
+        if string match -q -- ':*' $line
+            set directive (string sub -s 2 -- $line)
+        else
+            echo $line
+            set found (math $found + 1)
+        end
+    end
+    if test (math "bitand($directive, ` + fmt.Sprint(int(DirectiveFiles)) + `)") -ne 0
+        __fish_complete_path "$current"
+    else if test $found -eq 0; and test (math "bitand($directive, ` + fmt.Sprint(int(DirectiveNoFiles)) + `)") -eq 0
+        __fish_complete_path "$current"
+    end
+end
+
+`
+}
@@ -0,0 +1,212 @@
+package completion
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Zsh writes a completion script for zsh.
+//
+// Put it as a file named "_cmd" in a directory listed in $fpath,
+// or load it with `source <(cmd completion zsh)`.
+func Zsh(w io.Writer, cmd Command) error {
+	root := "_" + ident(cmd.Name)
+
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "#compdef %s\n", cmd.Name)
+	fmt.Fprintf(sb, "# zsh completion for %s\n", cmd.Name)
+
+	dynamic := ""
+	if cmd.Dynamic {
+		dynamic = root + "_dynamic"
+		sb.WriteString(zshDynamic(root))
+	}
+
+	for _, n := range walk(cmd) {
+		fn := root
+		for _, p := range n.path {
+			fn += "_" + ident(p)
+		}
+
+		specs := []string{}
+		for _, f := range n.flags {
+			specs = append(specs, zshFlagSpecs(f, dynamic)...)
+		}
+
+		fmt.Fprintf(sb, "\n%s() {\n", fn)
+		if dynamic != "" && len(n.path) == 0 {
+			// _arguments in groups modifies words and CURRENT. Keep them for dynamic completion.
+			fmt.Fprintf(sb, "    local -a %s_words=(\"${words[@]}\")\n", root)
+			fmt.Fprintf(sb, "    local %s_current=$CURRENT\n", root)
+		}
+		if len(n.cmd.Subcommands) == 0 {
+			for _, a := range n.cmd.Args {
+				specs = append(specs, zshArgSpec(a, dynamic))
+			}
+			sb.WriteString("    _arguments -s")
+			for _, s := range specs {
+				sb.WriteString(" \\\n        " + s)
+			}
+			sb.WriteString("\n}\n")
+			continue
+		}
+
+		sb.WriteString("    local context state state_descr line\n")
+		sb.WriteString("    typeset -A opt_args\n")
+		sb.WriteString("    _arguments -s -C")
+		for _, s := range specs {
+			sb.WriteString(" \\\n        " + s)
+		}
+		sb.WriteString(" \\\n        '1: :->subcommands'")
+		sb.WriteString(" \\\n        '*:: :->args'\n")
+		sb.WriteString("\n    case $state in\n")
+		sb.WriteString("    subcommands)\n")
+		sb.WriteString("        local -a subcommands\n")
+		sb.WriteString("        subcommands=(\n")
+		for _, sub := range sortedSubcommands(n.cmd) {
+			d := strings.ReplaceAll(sub.Name, ":", `\:`)
+			if sd := firstLine(sub.ShortDescription); sd != "" {
+				d += ":" + sd
+			}
+			fmt.Fprintf(sb, "            %s\n", quote(d))
+		}
+		sb.WriteString("        )\n")
+		sb.WriteString("        _describe -t commands 'subcommand' subcommands\n")
+		sb.WriteString("        ;;\n")
+		sb.WriteString("    args)\n")
+		sb.WriteString("        case $line[1] in\n")
+		for _, sub := range sortedSubcommands(n.cmd) {
+			fmt.Fprintf(sb, "        %s)\n", quote(sub.Name))
+			fmt.Fprintf(sb, "            %s_%s\n", fn, ident(sub.Name))
+			sb.WriteString("            ;;\n")
+		}
+		sb.WriteString("        esac\n")
+		sb.WriteString("        ;;\n")
+		sb.WriteString("    esac\n")
+		sb.WriteString("}\n")
+	}
+
+	fmt.Fprintf(sb, "\nif [ \"$funcstack[1]\" = %s ]; then\n", quote(root))
+	fmt.Fprintf(sb, "    %s \"$@\"\n", root)
+	sb.WriteString("else\n")
+	fmt.Fprintf(sb, "    compdef %s %s\n", root, cmd.Name)
+	sb.WriteString("fi\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// zshEscape escapes s for descriptions in specs of _arguments.
+func zshEscape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`,
+	).Replace(firstLine(s))
+}
+
+// zshFlagSpecs returns specs of _arguments for f.
+//
+// If dynamic is not empty, it is used as the action for values.
+func zshFlagSpecs(f Flag, dynamic string) []string {
+	ns := names(f)
+	help := zshEscape(f.Help())
+
+	value := ""
+	if f.NeedsValue() {
+		action := "_files"
+		if dynamic != "" {
+			action = dynamic
+		} else if cs := f.Choices(); 0 < len(cs) {
+			action = "(" + strings.Join(cs, " ") + ")"
+		}
+		value = ":" + strings.TrimLeft(f.Name(), "-") + ":" + action
+	}
+
+	spec := func(name string) string {
+		if value != "" && strings.HasPrefix(name, "--") {
+			return name + "="
+		}
+		return name
+	}
+
+	specs := []string{}
+	if len(ns) == 1 {
+		specs = append(specs, quote(spec(ns[0])+"["+help+"]"+value))
+	} else {
+		s := make([]string, len(ns))
+		for i := range ns {
+			s[i] = spec(ns[i])
+		}
+		specs = append(specs,
+			quote("("+strings.Join(ns, " ")+")")+
+				"{"+strings.Join(s, ",")+"}"+
+				quote("["+help+"]"+value),
+		)
+	}
+
+	if f.Negatable() {
+		for _, name := range ns {
+			if l, ok := strings.CutPrefix(name, "--"); ok {
+				specs = append(specs, quote("--no-"+l))
+			}
+		}
+	}
+	return specs
+}
+
+// zshArgSpec returns a spec of _arguments for a.
+//
+// If dynamic is not empty, it is used as the action.
+func zshArgSpec(a Arg, dynamic string) string {
+	name := zshEscape(a.Name())
+	action := "_files"
+	if dynamic != "" {
+		action = dynamic
+	}
+	switch {
+	case a.Repeatable():
+		return quote("*:" + name + ":" + action)
+	case a.Required():
+		return quote(":" + name + ":" + action)
+	default:
+		return quote("::" + name + ":" + action)
+	}
+}
+
+// zshDynamic returns a function asking candidates to the command with DynamicCommand.
+//
+// It uses words and CURRENT kept by the root function.
+func zshDynamic(root string) string {
+	return "\n" + root + `_dynamic() {
+    local -a candidates nospace
+    local out line directive=0
+    out="$(${` + root + `_words[1]} ` + DynamicCommand + ` "${(@)` + root + `_words[2,` + root + `_current]}" 2>/dev/null)" || return 1
+    for line in "${(@f)out}"; do
+        case "${line}" in
+        :*)
+            directive="${line#:}"
+            ;;
+        *$'\t'*)
+            candidates+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+            ;;
+        *)
+            candidates+=("${line//:/\\:}")
+            ;;
+        esac
+    done
+    if (( directive & ` + fmt.Sprint(int(DirectiveNoSpace)) + ` )); then
+        nospace=(-S '')
+    fi
+    if (( directive & ` + fmt.Sprint(int(DirectiveFiles)) + ` )); then
+        _files
+    fi
+    if (( ${#candidates} )); then
+        _describe -t values 'value' candidates "${nospace[@]}"
+        return
+    fi
+    if (( ! (directive & ` + fmt.Sprint(int(DirectiveNoFiles)) + `) )); then
+        _files
+    fi
+}
+`
+}
@@ -0,0 +1,90 @@
+package flarc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/youta-t/flarc/parser"
+)
+
+// WithConfigFile loads flag values from a JSON file.
+//
+// Keys of the top level object are flag names of the command passed to Run.
+// For command groups, values for flags of a subcommand are put in a nested object
+// keyed by the subcommand name, like
+//
+//	{
+//	    "group-flag": "value",
+//	    "sub": {
+//	        "sub-flag": 42,
+//	        "repeatable-flag": ["a", "b"]
+//	    }
+//	}
+//
+// Values in the file are overridden by environment variables and the commandline.
+//
+// If the file does not exist, it is ignored.
+func WithConfigFile(path string) RunOption {
+	return func(ro *runOption) *runOption {
+		ro.configFile = path
+		return ro
+	}
+}
+
+func loadConfigFile(path string) (parser.Config, error) {
+	if path == "" {
+		return parser.Config{}, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return parser.Config{}, nil
+	} else if err != nil {
+		return parser.Config{}, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	values := map[string]any{}
+	if err := dec.Decode(&values); err != nil {
+		return parser.Config{}, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return parser.Config{Values: values}, nil
+}
+
+// splitConfig separates values for subcommands from cfg.
+//
+// # Returns
+//
+// - parser.Config: values for the group itself.
+//
+// - map[string]parser.Config: values for each subcommands.
+//
+// - error: ErrConfig wrapped error when a value for subcommand is not an object.
+func splitConfig(cfg parser.Config, subcommands map[string]Command) (parser.Config, map[string]parser.Config, error) {
+	own := parser.Config{Key: cfg.Key, Values: map[string]any{}}
+	subs := map[string]parser.Config{}
+
+	for k, v := range cfg.Values {
+		if _, ok := subcommands[k]; !ok {
+			own.Values[k] = v
+			continue
+		}
+		key := append(cfg.Key[:len(cfg.Key):len(cfg.Key)], k)
+		values, ok := v.(map[string]any)
+		if !ok {
+			return parser.Config{}, nil, fmt.Errorf(
+				"%w: values for subcommand should be an object: %s",
+				parser.ErrConfig, strings.Join(key, "."),
+			)
+		}
+		subs[k] = parser.Config{Key: key, Values: values}
+	}
+
+	return own, subs, nil
+}
@@ -0,0 +1,29 @@
+package flarc
+
+import "github.com/youta-t/flarc/parser"
+
+// Constraint is a relationship among flags. See parser.Constraint.
+type Constraint = parser.Constraint
+
+// Requires declares that when flag is given, all of requirements should be given too. See parser.Requires.
+func Requires(flag string, requirements ...string) Constraint {
+	return parser.Requires(flag, requirements...)
+}
+
+// MutuallyExclusive declares that at most one of flags can be given. See parser.MutuallyExclusive.
+func MutuallyExclusive(flags ...string) Constraint {
+	return parser.MutuallyExclusive(flags...)
+}
+
+// AtLeastOneOf declares that one or more of flags should be given. See parser.AtLeastOneOf.
+func AtLeastOneOf(flags ...string) Constraint {
+	return parser.AtLeastOneOf(flags...)
+}
+
+func constraintDescriptions(cs []Constraint) []string {
+	ds := make([]string, len(cs))
+	for i := range cs {
+		ds[i] = cs[i].String()
+	}
+	return ds
+}
@@ -0,0 +1,121 @@
+package flarc
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/youta-t/flarc/help"
+)
+
+// WriteManPages writes man pages (section 1) of cmd and its subcommands into dir.
+//
+// name is the name of the executable, as WithName for Run.
+// Pages are named after paths of commands, like "NAME.1" for cmd and "NAME-SUB.1" for its subcommand.
+//
+// Pages of subcommands have flags of their command groups, and refer their parent and siblings in SEE ALSO.
+func WriteManPages(dir string, name string, cmd Command) error {
+	return writeDocs(dir, name, cmd, ".1", help.Help.WriteMan)
+}
+
+// WriteMarkdownDocs writes Markdown documents of cmd and its subcommands into dir, one file per command.
+//
+// name is the name of the executable, as WithName for Run.
+// Files are named after paths of commands, like "NAME.md" for cmd and "NAME-SUB.md" for its subcommand,
+// and link each other.
+//
+// Documents have the same contents as help messages.
+func WriteMarkdownDocs(dir string, name string, cmd Command) error {
+	return writeDocs(dir, name, cmd, ".md", help.Help.WriteMarkdown)
+}
+
+func writeDocs(dir string, name string, cmd Command, ext string, write func(help.Help, io.Writer) error) error {
+	helps, err := docHelps(name, cmd)
+	if err != nil {
+		return err
+	}
+	for fullname, h := range helps {
+		filename := filepath.Join(dir, help.PageName(fullname)+ext)
+		if err := writeDoc(filename, h, write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// docHelps returns helps of cmd and its subcommands, by their full names.
+//
+// Helps of subcommands have flags of their command groups, and refer their parent and siblings.
+func docHelps(name string, cmd Command) (map[string]help.Help, error) {
+	hp, err := helpParser()
+	if err != nil {
+		return nil, err
+	}
+
+	type page struct {
+		fullname string
+		cmd      Command
+		parent   string
+		siblings []string
+
+		// inherited are commands groups of cmd, from the nearest.
+		inherited []Command
+	}
+
+	pages := []page{{fullname: name, cmd: cmd}}
+	for i := 0; i < len(pages); i += 1 {
+		p := pages[i]
+
+		subs := p.cmd.Subcommands()
+		subnames := make([]string, 0, len(subs))
+		for s := range subs {
+			subnames = append(subnames, s)
+		}
+		slices.Sort(subnames)
+		for _, sub := range subnames {
+			siblings := []string{}
+			for _, s := range subnames {
+				if s != sub {
+					siblings = append(siblings, p.fullname+" "+s)
+				}
+			}
+			pages = append(pages, page{
+				fullname:  p.fullname + " " + sub,
+				cmd:       subs[sub],
+				parent:    p.fullname,
+				siblings:  siblings,
+				inherited: append([]Command{p.cmd}, p.inherited...),
+			})
+		}
+	}
+
+	helps := map[string]help.Help{}
+	for _, p := range pages {
+		h := p.cmd.newHelp(p.fullname)
+		for _, c := range p.inherited {
+			h.AppendFlags(c.Flags()...)
+			h.AppendConstraints(constraintDescriptions(c.listConstraints())...)
+		}
+		h.AppendFlags(hp.Flags()...)
+		if p.parent != "" {
+			h.AppendSeeAlso(p.parent)
+		}
+		h.AppendSeeAlso(p.siblings...)
+		helps[p.fullname] = h
+	}
+	return helps, nil
+}
+
+func writeDoc(filename string, h help.Help, write func(help.Help, io.Writer) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := write(h, f); err != nil {
+		return err
+	}
+	return f.Close()
+}
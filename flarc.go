@@ -7,7 +7,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
 
+	"github.com/youta-t/flarc/completion"
 	"github.com/youta-t/flarc/flarcerror"
 	"github.com/youta-t/flarc/help"
 	"github.com/youta-t/flarc/params"
@@ -16,35 +21,110 @@ import (
 
 var ErrUsage = flarcerror.ErrUsage
 
+// ErrUnknownFlag is returned when an unknown flag is given. This wraps ErrUsage.
+var ErrUnknownFlag = parser.ErrUnknownFlag
+
+// ErrFlagConflict is returned from NewCommand and NewCommandGroup when flags share a name.
+//
+// Conflicts with the help flag are reported by Run, because the help flag is given by WithHelp.
+var ErrFlagConflict = parser.ErrFlagConflict
+
+// Source represents where the value of a flag comes from.
+type Source = parser.Source
+
+const (
+	// SourceDefault means the flag is not given. The value is the default.
+	SourceDefault = parser.SourceDefault
+
+	// SourceConfig means the flag is set from a configuration file.
+	SourceConfig = parser.SourceConfig
+
+	// SourceEnv means the flag is set from an environment variable.
+	SourceEnv = parser.SourceEnv
+
+	// SourceCommandline means the flag is given on the commandline.
+	SourceCommandline = parser.SourceCommandline
+)
+
 type helper struct {
-	Help bool `alias:"h" help:"show help message"`
+	Help bool `alias:"h" help:"show help message" negatable:"false"`
 }
 
 func helpParser() (parser.Parser[helper], error) {
 	return parser.New(&helper{}, []params.ArgDef{})
 }
 
+// checkHelpConflicts reports flags of cmd, and its subcommands, sharing names with the help flag.
+//
+// Such flags cannot be given, because Run takes them as the help flag.
+func checkHelpConflicts(helpFlags []params.Flag, cmd Command) error {
+	return Walk(cmd, func(path []string, c Command) error {
+		if err := parser.CheckFlagConflicts(helpFlags, c.Flags()); err != nil {
+			if len(path) == 0 {
+				return fmt.Errorf("%w, in the help flag and the command", err)
+			}
+			return fmt.Errorf(
+				"%w, in the help flag and the subcommand %s", err, strings.Join(path, " "),
+			)
+		}
+		return nil
+	})
+}
+
 type Command interface {
 	ShortDescription() string
 
+	// Description returns the description given by WithDescription or WithGroupDescription, as it is.
+	//
+	// It is a template of text/template, and {{ .Command }} in it is the full name of the command.
+	Description() string
+
+	// Flags returns flags of this command, excluding ones of its command groups.
+	Flags() []params.Flag
+
+	// Args returns positional arguments of this command.
+	//
+	// Command groups have no args.
+	Args() []params.Arg
+
+	// Subcommands returns a copy of subcommands, by their names.
+	//
+	// Commands other than command groups have no subcommands.
+	Subcommands() map[string]Command
+
+	listConstraints() []Constraint
+	descriptionTemplate() *template.Template
+
+	newHelp(fullname string) help.Help
+
 	prepare(
 		invokedAs string,
 		stdin io.Reader,
 		stdout io.Writer,
 		stderr io.Writer,
+		lookupEnv func(string) (string, bool),
+		config parser.Config,
 		args []string,
+		argIndices []int,
+		inherited []params.Flag,
+		groups []flagSources,
 		params ...any,
 	) runner
 }
 
 type runOption struct {
-	name    string
-	stdin   io.Reader
-	stdout  io.Writer
-	stderr  io.Writer
-	useHelp bool
-	argv    []string
-	params  []any
+	name       string
+	stdin      io.Reader
+	stdout     io.Writer
+	stderr     io.Writer
+	lookupEnv  func(string) (string, bool)
+	configFile string
+	useHelp    bool
+	helpWidth  *int
+	helpTpl    *template.Template
+	completion bool
+	argv       []string
+	params     []any
 }
 
 type RunOption func(*runOption) *runOption
@@ -60,6 +140,11 @@ func WithArgs(argv []string) RunOption {
 }
 
 // WithHelp add global flags --help, -h
+//
+// If they are added, Run fails with ErrFlagConflict before parsing args,
+// when flags of the command or its subcommands share names with them.
+// This is checked by Run, not by NewCommand and NewCommandGroup,
+// because commands having such flags work with WithHelp(false).
 func WithHelp(need bool) RunOption {
 	return func(ro *runOption) *runOption {
 		ro.useHelp = need
@@ -67,6 +152,44 @@ func WithHelp(need bool) RunOption {
 	}
 }
 
+// WithHelpWidth sets the width which help messages are wrapped in.
+//
+// By default, it is taken from the environment variable COLUMNS, or help.DefaultWidth if not set.
+// If width is 0 or less, help messages are not wrapped.
+func WithHelpWidth(width int) RunOption {
+	return func(ro *runOption) *runOption {
+		ro.helpWidth = &width
+		return ro
+	}
+}
+
+// WithHelpTemplate replaces the layout of help messages.
+//
+// The template is executed with help.Model.
+// To modify the default layout partially, redefine its blocks on help.DefaultTemplate().
+// Templates using functions for help layout should be parsed with help.Funcs().
+func WithHelpTemplate(tpl *template.Template) RunOption {
+	return func(ro *runOption) *runOption {
+		ro.helpTpl = tpl
+		return ro
+	}
+}
+
+// WithCompletion adds hidden subcommand "completion <shell>",
+// printing completion script for the shell.
+//
+// Supported shells are bash, zsh and fish.
+//
+// Scripts complete values of flags and positional args dynamically,
+// calling the command with hidden subcommand "__complete".
+// Completers for them are set with WithFlagCompleter, WithGroupFlagCompleter and ArgDef.Complete.
+func WithCompletion(need bool) RunOption {
+	return func(ro *runOption) *runOption {
+		ro.completion = need
+		return ro
+	}
+}
+
 // WithInput replace Stdin
 func WithInput(in io.Reader) RunOption {
 	return func(ro *runOption) *runOption {
@@ -103,6 +226,16 @@ func WithOutput(out io.Writer, errout io.Writer) RunOption {
 	}
 }
 
+// WithLookupEnv replaces the source of environment variables bound to flags.
+//
+// By default, os.LookupEnv is used.
+func WithLookupEnv(lookup func(string) (string, bool)) RunOption {
+	return func(ro *runOption) *runOption {
+		ro.lookupEnv = lookup
+		return ro
+	}
+}
+
 // WithParams passes extra parameters
 //
 // If pass this multiple times, parameters are appended with previous ones.
@@ -128,19 +261,25 @@ func WithParams(param []any) RunOption {
 // - int: status code of this command.
 func Run(ctx context.Context, cmd Command, options ...RunOption) int {
 	runOpt := &runOption{
-		name:    filepath.Base(os.Args[0]),
-		stdin:   os.Stdin,
-		stdout:  os.Stdout,
-		stderr:  os.Stderr,
-		argv:    os.Args[1:],
-		useHelp: true,
+		name:      filepath.Base(os.Args[0]),
+		stdin:     os.Stdin,
+		stdout:    os.Stdout,
+		stderr:    os.Stderr,
+		lookupEnv: os.LookupEnv,
+		argv:      os.Args[1:],
+		useHelp:   true,
 	}
 	for _, o := range options {
 		runOpt = o(runOpt)
 	}
 
+	config, err := loadConfigFile(runOpt.configFile)
+	if err != nil {
+		fmt.Fprintln(runOpt.stderr, err)
+		return 1
+	}
+
 	argv := runOpt.argv
-	showHelp := false
 	var helpPsr parser.Parser[helper]
 	if runOpt.useHelp {
 		hp, err := helpParser()
@@ -148,43 +287,92 @@ func Run(ctx context.Context, cmd Command, options ...RunOption) int {
 			fmt.Fprintln(runOpt.stderr, err)
 			return 1
 		}
+		if err := checkHelpConflicts(hp.Flags(), cmd); err != nil {
+			fmt.Fprintln(runOpt.stderr, err)
+			return 1
+		}
 		helpPsr = hp
+	}
+
+	if runOpt.completion && 0 < len(argv) {
+		if _, ok := cmd.Subcommands()[argv[0]]; !ok {
+			switch argv[0] {
+			case completionCommand:
+				return runCompletion(runOpt, cmd, helpPsr, argv[1:])
+			case completion.DynamicCommand:
+				return runDynamicCompletion(ctx, runOpt, cmd, helpPsr, config, argv[1:])
+			}
+		}
+	}
 
-		hf, _, argv_, err := helpPsr.Parse(argv)
+	showHelp := false
+	// argvIndices[i] is the index of argv[i] in runOpt.argv. If nil, they are same.
+	var argvIndices []int
+	// helpErr is the error parsing help flags. It is reported as errors of the command.
+	var helpErr error
+	if helpPsr != nil {
+		hf, _, argv_, err := helpPsr.Parse(
+			argv, parser.AllowUnknownFlags(), parser.RecordRestIndices(&argvIndices),
+		)
 		if err != nil {
-			fmt.Fprintln(runOpt.stderr, err)
-			return 1
+			helpErr = err
+			argvIndices = nil
+		} else {
+			showHelp = hf.Help
+			argv = argv_
 		}
-		showHelp = hf.Help
-		argv = argv_
 	}
 
+	var inherited []params.Flag
+	if helpPsr != nil {
+		inherited = helpPsr.Flags()
+	}
 	r := cmd.prepare(
 		runOpt.name,
 		runOpt.stdin, runOpt.stdout, runOpt.stderr,
-		argv, runOpt.params...,
+		runOpt.lookupEnv, config,
+		argv, argvIndices, inherited, nil, runOpt.params...,
 	)
 
 	if showHelp {
-		hlp := r.Help()
-		if helpPsr != nil {
-			hlp.AppendFlags(helpPsr.Flags()...)
+		if err := writeHelp(runOpt, r, helpPsr); err != nil {
+			fmt.Fprintln(runOpt.stderr, err)
+			return 1
 		}
-		hlp.Write(runOpt.stderr)
 		return 0
 	}
 
-	if err := r.Run(ctx); err == nil {
+	if helpErr != nil {
+		err = helpErr
+	} else {
+		err = r.Run(ctx)
+	}
+	errs := splitErrors(err)
+
+	if err == nil {
 		return 0
 	} else if errors.Is(err, ErrUsage) {
-		fmt.Fprintln(runOpt.stderr, err)
-		fmt.Fprintln(runOpt.stderr)
+		if len(errs) == 1 {
+			fmt.Fprintln(runOpt.stderr, err)
+			fmt.Fprintln(runOpt.stderr)
+			if index, ok := errorIndex(err); ok {
+				writeCaret(runOpt.stderr, "    ", runOpt.name, runOpt.argv, index)
+				fmt.Fprintln(runOpt.stderr)
+			}
+		} else {
+			for _, e := range errs {
+				fmt.Fprintln(runOpt.stderr, "- "+e.Error())
+				if index, ok := errorIndex(e); ok {
+					writeCaret(runOpt.stderr, "      ", runOpt.name, runOpt.argv, index)
+				}
+			}
+			fmt.Fprintln(runOpt.stderr)
+		}
 
-		hlp := r.Help()
-		if helpPsr != nil {
-			hlp.AppendFlags(helpPsr.Flags()...)
+		if err := writeHelp(runOpt, r, helpPsr); err != nil {
+			fmt.Fprintln(runOpt.stderr, err)
+			return 1
 		}
-		hlp.Write(runOpt.stderr)
 		return 2
 	} else {
 		fmt.Fprintln(runOpt.stderr, err)
@@ -192,9 +380,78 @@ func Run(ctx context.Context, cmd Command, options ...RunOption) int {
 	}
 }
 
+// writeHelp writes the help of r into stderr, with flags of helpPsr if any.
+func writeHelp(runOpt *runOption, r runner, helpPsr parser.Parser[helper]) error {
+	hlp := r.Help()
+	if helpPsr != nil {
+		hlp.AppendFlags(helpPsr.Flags()...)
+	}
+
+	width := help.WidthFromEnv(runOpt.lookupEnv)
+	if runOpt.helpWidth != nil {
+		width = *runOpt.helpWidth
+	}
+	hlp.SetWidth(width)
+	if runOpt.helpTpl != nil {
+		hlp.SetTemplate(runOpt.helpTpl)
+	}
+
+	return hlp.Write(runOpt.stderr)
+}
+
+// splitErrors returns errors joined in err.
+//
+// If err is not joined one, it returns err itself only. If err is nil, it returns nothing.
+func splitErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
+
+// errorIndex returns the index of the token in the commandline which err is about, if it is known.
+func errorIndex(err error) (int, bool) {
+	if pe := new(parser.ParseError); errors.As(err, &pe) {
+		return pe.Index, 0 <= pe.Index
+	}
+	if ue := new(parser.UnknownFlagError); errors.As(err, &ue) {
+		return ue.Index, 0 <= ue.Index
+	}
+	return -1, false
+}
+
+// writeCaret writes the commandline with a caret under argv[index], with indent.
+func writeCaret(w io.Writer, indent string, name string, argv []string, index int) {
+	line := new(strings.Builder)
+	line.WriteString(indent + name)
+	offset, width := 0, 0
+	for i, a := range argv {
+		if a == "" || strings.ContainsAny(a, " \t\n\"'") {
+			a = strconv.Quote(a)
+		}
+		line.WriteString(" ")
+		if i == index {
+			offset, width = utf8.RuneCountInString(line.String()), utf8.RuneCountInString(a)
+		}
+		line.WriteString(a)
+	}
+	fmt.Fprintln(w, line.String())
+	fmt.Fprintln(w, strings.Repeat(" ", offset)+"^"+strings.Repeat("~", max(width-1, 0)))
+}
+
 type runner struct {
 	Run  func(context.Context) error
 	Help func() help.Help
+
+	// Complete returns completion candidates for partial, the word typed after args.
+	//
+	// inherited are flags of ancestors.
+	Complete func(
+		ctx context.Context, partial string, inherited []completableFlag,
+	) ([]completion.Candidate, completion.Directive)
 }
 
 // FindParam finds T-typed value from params.
@@ -6,10 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/youta-t/flarc"
+	"github.com/youta-t/flarc/completion"
+	"github.com/youta-t/flarc/help"
 	"github.com/youta-t/flarc/internal/gen_mock"
 	"github.com/youta-t/its"
 	"github.com/youta-t/its/itskit"
@@ -215,8 +222,8 @@ Description:
 
 Flags:
 
-    -f, --flag  help message
-    --help, -h  show help message
+    -f, --[no-]flag  help message
+    --help, -h       show help message
 
 Args:
 
@@ -238,6 +245,9 @@ Args:
 			stdout: its.EqEq(""),
 			stderr: its.Text(`usage error: parse error: not-bool is not bool: -f
 
+    test -f=not-bool source1 source2 dest
+         ^~~~~~~~~~~
+
 test -- testing flarg
 
 Usage:
@@ -250,8 +260,8 @@ Description:
 
 Flags:
 
-    -f, --flag  help message
-    --help, -h  show help message
+    -f, --[no-]flag  help message
+    --help, -h       show help message
 
 Args:
 
@@ -492,9 +502,9 @@ Description:
 
 Flags:
 
-    -f, --flag  help message
-    -i, --int   help for command group flag
-    --help, -h  show help message
+    -f, --[no-]flag  help message
+    -i, --int        help for command group flag
+    --help, -h       show help message
 
 Args:
 
@@ -523,9 +533,9 @@ Description:
 
 Flags:
 
-    -f, --flag  help message
-    -i, --int   help for command group flag
-    --help, -h  show help message
+    -f, --[no-]flag  help message
+    -i, --int        help for command group flag
+    --help, -h       show help message
 
 Args:
 
@@ -678,9 +688,9 @@ Description:
 
 Flags:
 
-    -f, --flag  help message
-    -i, --int   help for command group flag
-    --help, -h  show help message
+    -f, --[no-]flag  help message
+    -i, --int        help for command group flag
+    --help, -h       show help message
 
 Args:
 
@@ -714,3 +724,2045 @@ Args:
 		},
 	))
 }
+
+func TestCommand_reuse(t *testing.T) {
+	type Flag struct {
+		N     int
+		Names []string
+	}
+
+	cmd, err := flarc.NewCommand(
+		"reusable command",
+		Flag{N: -1},
+		flarc.Args{},
+		func(ctx context.Context, cl flarc.Commandline[Flag], _ []any) error {
+			fmt.Fprintf(cl.Stdout(), "%d %v", cl.Flags().N, cl.Flags().Names)
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grp, err := flarc.NewCommandGroup(
+		"reusable group", struct{}{},
+		flarc.WithSubcommand("sub", cmd),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 32
+	wg := new(sync.WaitGroup)
+	for i := 0; i < n; i += 1 {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			args := []string{"sub"}
+			want := "-1 []"
+			if i%2 == 0 {
+				s := fmt.Sprint(i)
+				args = append(args, "-n", s, "--names", s)
+				want = fmt.Sprintf("%d [%d]", i, i)
+			}
+
+			stdout := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), grp,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(stdout, nil),
+			)
+			its.EqEq(0).Match(status).OrError(t)
+			its.EqEq(want).Match(stdout.String()).OrError(t)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestCommand_env(t *testing.T) {
+	type FlagSuper struct {
+		Region string `help:"region name"`
+	}
+	type FlagSub struct {
+		Timeout int `env:"TIMEOUT" help:"timeout in second"`
+		Verbose bool
+	}
+
+	env := map[string]string{
+		"APP_REGION":  "env-region",
+		"TIMEOUT":     "10",
+		"APP_VERBOSE": "true",
+	}
+	lookupEnv := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	theory := func(args []string, wantStatus int, wantStdout string, wantStderr string) func(*testing.T) {
+		return func(t *testing.T) {
+			sub, err := flarc.NewCommand(
+				"subcommand", FlagSub{}, flarc.Args{},
+				func(ctx context.Context, cl flarc.Commandline[FlagSub], params []any) error {
+					super, _ := flarc.FindParam[FlagSuper](params)
+					fmt.Fprintf(cl.Stdout(), "%s %d %v", super.Region, cl.Flags().Timeout, cl.Flags().Verbose)
+					return nil
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			grp, err := flarc.NewCommandGroup(
+				"group", FlagSuper{Region: "default"},
+				flarc.WithGroupEnvPrefix("APP"),
+				flarc.WithSubcommand("sub", sub),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stdout := new(strings.Builder)
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), grp,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(stdout, stderr),
+				flarc.WithLookupEnv(lookupEnv),
+			)
+			its.EqEq(wantStatus).Match(status).OrError(t)
+			its.Text(wantStdout).Match(stdout.String()).OrError(t)
+			its.Text(wantStderr).Match(stderr.String()).OrError(t)
+		}
+	}
+
+	t.Run("flags are set from env", theory(
+		[]string{"sub"}, 0, "env-region 10 false", "",
+	))
+
+	t.Run("commandline overrides env", theory(
+		[]string{"sub", "--region", "cli-region", "--timeout", "20"}, 0, "cli-region 20 false", "",
+	))
+
+	t.Run("help shows env", theory(
+		[]string{"sub", "-h"}, 0, "", `test sub -- subcommand
+
+Usage:
+
+    test sub --timeout=0 --[no-]verbose=false --region=default --help=false
+
+Flags:
+
+    --timeout   timeout in second [env: TIMEOUT]
+    --[no-]verbose
+    --region    region name [env: APP_REGION]
+    --help, -h  show help message
+`,
+	))
+}
+
+func TestRun_helpWidth(t *testing.T) {
+	type Flag struct {
+		Name                   string `alias:"n" help:"name of the resource to be created, which should be unique in the project"`
+		DryRun                 bool   `help:"show what would be done"`
+		ConfigurationDirectory string `help:"directory having configuration files"`
+	}
+
+	theory := func(env map[string]string, options []flarc.RunOption, wantStderr string) func(*testing.T) {
+		return func(t *testing.T) {
+			sub, err := flarc.NewCommand(
+				"subcommand which does something long enough to be wrapped", struct{}{}, flarc.Args{},
+				func(ctx context.Context, cl flarc.Commandline[struct{}], params []any) error {
+					return nil
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			grp, err := flarc.NewCommandGroup(
+				"group", Flag{},
+				flarc.WithGroupDescription(`this is a command group description, long enough to be wrapped in narrow terminals.
+    indented lines keep their indentation when wrapped.`),
+				flarc.WithSubcommand("sub", sub),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), grp,
+				append(
+					[]flarc.RunOption{
+						flarc.WithName("test"),
+						flarc.WithArgs([]string{"-h"}),
+						flarc.WithOutput(nil, stderr),
+						flarc.WithLookupEnv(func(name string) (string, bool) {
+							v, ok := env[name]
+							return v, ok
+						}),
+					},
+					options...,
+				)...,
+			)
+			its.EqEq(0).Match(status).OrError(t)
+			its.Text(wantStderr).Match(stderr.String()).OrError(t)
+		}
+	}
+
+	t.Run("wrapped in COLUMNS", theory(
+		map[string]string{"COLUMNS": "50"}, nil,
+		`test -- group
+
+Usage:
+
+    test --name --[no-]dry-run=false --configuration-directory --help=false
+
+Description:
+
+    this is a command group description, long
+    enough to be wrapped in narrow terminals.
+        indented lines keep their indentation when
+        wrapped.
+
+Flags:
+
+    --name, -n                name of the resource
+                              to be created, which
+                              should be unique in
+                              the project
+    --[no-]dry-run            show what would be
+                              done
+    --configuration-directory
+                              directory having
+                              configuration files
+    --help, -h                show help message
+
+Subcommands:
+
+    sub         subcommand which does something
+                long enough to be wrapped
+
+`,
+	))
+
+	t.Run("wrapped in 80 columns by default", theory(
+		map[string]string{}, nil,
+		`test -- group
+
+Usage:
+
+    test --name --[no-]dry-run=false --configuration-directory --help=false
+
+Description:
+
+    this is a command group description, long enough to be wrapped in narrow
+    terminals.
+        indented lines keep their indentation when wrapped.
+
+Flags:
+
+    --name, -n                 name of the resource to be created, which should
+                               be unique in the project
+    --[no-]dry-run             show what would be done
+    --configuration-directory  directory having configuration files
+    --help, -h                 show help message
+
+Subcommands:
+
+    sub         subcommand which does something long enough to be wrapped
+
+`,
+	))
+
+	t.Run("WithHelpWidth overrides COLUMNS", theory(
+		map[string]string{"COLUMNS": "50"}, []flarc.RunOption{flarc.WithHelpWidth(0)},
+		`test -- group
+
+Usage:
+
+    test --name --[no-]dry-run=false --configuration-directory --help=false
+
+Description:
+
+    this is a command group description, long enough to be wrapped in narrow terminals.
+        indented lines keep their indentation when wrapped.
+
+Flags:
+
+    --name, -n                 name of the resource to be created, which should be unique in the project
+    --[no-]dry-run             show what would be done
+    --configuration-directory  directory having configuration files
+    --help, -h                 show help message
+
+Subcommands:
+
+    sub         subcommand which does something long enough to be wrapped
+
+`,
+	))
+}
+
+func TestRun_helpTemplate(t *testing.T) {
+	type Flag struct {
+		Name  string `alias:"n" env:"NAME" help:"your name"`
+		Level string `choices:"debug,info" help:"log level"`
+	}
+
+	theory := func(tpl *template.Template, wantStatus int, wantStderr string) func(*testing.T) {
+		return func(t *testing.T) {
+			sub, err := flarc.NewCommand(
+				"subcommand", Flag{Level: "info"}, flarc.Args{{Name: "SOURCE", Required: true, Help: "input file"}},
+				func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+					return nil
+				},
+				flarc.WithDescription("{{ .Command }} does something."),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			grp, err := flarc.NewCommandGroup(
+				"group", struct{}{}, flarc.WithSubcommand("sub", sub),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), grp,
+				flarc.WithName("test"),
+				flarc.WithArgs([]string{"sub", "-h"}),
+				flarc.WithOutput(nil, stderr),
+				flarc.WithLookupEnv(func(string) (string, bool) { return "", false }),
+				flarc.WithHelpTemplate(tpl),
+			)
+			its.EqEq(wantStatus).Match(status).OrError(t)
+			its.Text(wantStderr).Match(stderr.String()).OrError(t)
+		}
+	}
+
+	t.Run("custom template", theory(
+		template.Must(template.New("").Funcs(help.Funcs()).Parse(`NAME
+  {{ .Name }} - {{ .ShortDescription }}
+
+SYNOPSIS
+  {{ .Usage }}
+
+DESCRIPTION
+  {{ .Description }}
+
+OPTIONS
+{{- range .Flags }}
+  {{ join " | " .Names }} ({{ .Type }}{{ if .Default }}, default: {{ .Default }}{{ end }}{{ if .Env }}, env: {{ .Env }}{{ end }})
+      {{ .Help }}{{ if .Choices }} One of {{ join ", " .Choices }}.{{ end }}
+{{- end }}
+
+ARGUMENTS
+{{- range .Args }}
+  {{ .Name }}{{ if .Required }} (required){{ end }}: {{ .Help }}
+{{- end }}
+`)),
+		0,
+		`NAME
+  test sub - subcommand
+
+SYNOPSIS
+  test sub --name --level=debug|info --help=false SOURCE
+
+DESCRIPTION
+  test sub does something.
+
+OPTIONS
+  --name | -n (string, env: NAME)
+      your name
+  --level (string, default: info)
+      log level One of debug, info.
+  --help | -h (bool, default: false)
+      show help message
+
+ARGUMENTS
+  SOURCE (required): input file
+`,
+	))
+
+	t.Run("redefine a block of the default template", theory(
+		template.Must(help.DefaultTemplate().Parse(`{{ define "usage" }}
+USAGE: {{ .Usage }}
+{{ end }}`)),
+		0,
+		`test sub -- subcommand
+
+USAGE: test sub --name --level=debug|info --help=false SOURCE
+
+Description:
+
+    test sub does something.
+
+Flags:
+
+    --name, -n  your name [env: NAME]
+    --level     log level [choices: debug, info]
+    --help, -h  show help message
+
+Args:
+
+    SOURCE      input file
+`,
+	))
+
+	t.Run("errors in templates are reported", theory(
+		template.Must(template.New("").Parse(`{{ .Missing }}`)),
+		1,
+		`template: :1:3: executing "" at <.Missing>: can't evaluate field Missing in type help.Model
+`,
+	))
+}
+
+func TestRun_configFile(t *testing.T) {
+	type FlagSuper struct {
+		Region string
+	}
+	type FlagSub struct {
+		Count int `env:"COUNT"`
+		Tags  []string
+	}
+
+	theory := func(
+		config string, args []string, env map[string]string,
+		wantStatus int, wantStdout its.Matcher[string], wantStderr its.Matcher[string],
+	) func(*testing.T) {
+		return func(t *testing.T) {
+			sub, err := flarc.NewCommand(
+				"subcommand", FlagSub{Count: 1}, flarc.Args{},
+				func(ctx context.Context, cl flarc.Commandline[FlagSub], params []any) error {
+					super, _ := flarc.FindParam[FlagSuper](params)
+					fmt.Fprintf(cl.Stdout(), "%s %d %v", super.Region, cl.Flags().Count, cl.Flags().Tags)
+					return nil
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			grp, err := flarc.NewCommandGroup(
+				"group", FlagSuper{Region: "default"},
+				flarc.WithSubcommand("sub", sub),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(t.TempDir(), "config.json")
+			if config != "" {
+				if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stdout := new(strings.Builder)
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), grp,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(stdout, stderr),
+				flarc.WithConfigFile(path),
+				flarc.WithLookupEnv(func(s string) (string, bool) {
+					v, ok := env[s]
+					return v, ok
+				}),
+			)
+			its.EqEq(wantStatus).Match(status).OrError(t)
+			wantStdout.Match(stdout.String()).OrError(t)
+			wantStderr.Match(stderr.String()).OrError(t)
+		}
+	}
+
+	t.Run("config file is not exist", theory(
+		"", []string{"sub"}, nil,
+		0, its.EqEq("default 1 []"), its.EqEq(""),
+	))
+
+	t.Run("values from config file", theory(
+		`{"region": "config", "sub": {"count": 3, "tags": ["a", "b"]}}`,
+		[]string{"sub"}, nil,
+		0, its.EqEq("config 3 [a b]"), its.EqEq(""),
+	))
+
+	t.Run("env and commandline override config file", theory(
+		`{"region": "config", "sub": {"count": 3, "tags": ["a", "b"]}}`,
+		[]string{"sub", "--region", "cli"}, map[string]string{"COUNT": "5"},
+		0, its.EqEq("cli 5 [a b]"), its.EqEq(""),
+	))
+
+	t.Run("unknown key", theory(
+		`{"region": "config", "sub": {"cuont": 3}}`,
+		[]string{"sub"}, nil,
+		2, its.EqEq(""),
+		its.StringHavingPrefix("usage error: invalid config: unknown key: sub.cuont\n"),
+	))
+
+	t.Run("type mismatch", theory(
+		`{"sub": {"count": "3"}}`,
+		[]string{"sub"}, nil,
+		2, its.EqEq(""),
+		its.StringHavingPrefix("usage error: invalid config: string is not acceptable for int: sub.count\n"),
+	))
+
+	t.Run("subcommand value is not an object", theory(
+		`{"sub": 1}`,
+		[]string{"sub"}, nil,
+		2, its.EqEq(""),
+		its.StringHavingPrefix("usage error: invalid config: values for subcommand should be an object: sub\n"),
+	))
+
+	t.Run("broken file", theory(
+		`{"sub": `,
+		[]string{"sub"}, nil,
+		1, its.EqEq(""), its.StringHavingPrefix("config file "),
+	))
+}
+
+func TestCommandline_sources(t *testing.T) {
+	type Flag struct {
+		Count   int `alias:"c"`
+		Verbose bool
+		Name    string `env:"NAME"`
+		Region  string
+	}
+
+	cmd, err := flarc.NewCommand(
+		"command", Flag{Count: 1}, flarc.Args{},
+		func(ctx context.Context, cl flarc.Commandline[Flag], _ []any) error {
+			its.EqEq(true).Match(cl.IsSet("count")).OrError(t)
+			its.EqEq(true).Match(cl.IsSet("-c")).OrError(t)
+			its.EqEq(true).Match(cl.IsSet("--name")).OrError(t)
+			its.EqEq(false).Match(cl.IsSet("verbose")).OrError(t)
+			its.EqEq(false).Match(cl.IsSet("unknown")).OrError(t)
+
+			its.EqEq(flarc.SourceCommandline).Match(cl.Source("count")).OrError(t)
+			its.EqEq(flarc.SourceEnv).Match(cl.Source("name")).OrError(t)
+			its.EqEq(flarc.SourceConfig).Match(cl.Source("region")).OrError(t)
+			its.EqEq(flarc.SourceDefault).Match(cl.Source("verbose")).OrError(t)
+
+			its.Slice(
+				its.EqEq("count"), its.EqEq("name"), its.EqEq("region"),
+			).Match(cl.Changed()).OrError(t)
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"region": "config"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	status := flarc.Run(
+		context.Background(), cmd,
+		flarc.WithArgs([]string{"-c", "1"}),
+		flarc.WithOutput(nil, nil),
+		flarc.WithConfigFile(path),
+		flarc.WithLookupEnv(func(s string) (string, bool) {
+			if s == "NAME" {
+				return "env", true
+			}
+			return "", false
+		}),
+	)
+	its.EqEq(0).Match(status).OrError(t)
+}
+
+func TestCommandline_sourcesOfGroups(t *testing.T) {
+	type OuterFlag struct {
+		Region  string `alias:"r" env:"REGION"`
+		Profile string
+	}
+	type InnerFlag struct {
+		Verbose bool `alias:"v"`
+		Debug   bool
+	}
+	type Flag struct {
+		Count int
+	}
+
+	called := false
+	cmd, err := flarc.NewCommand(
+		"command", Flag{}, flarc.Args{},
+		func(ctx context.Context, cl flarc.Commandline[Flag], _ []any) error {
+			called = true
+			its.EqEq(true).Match(cl.IsSet("count")).OrError(t)
+			its.EqEq(true).Match(cl.IsSet("-v")).OrError(t)
+			its.EqEq(true).Match(cl.IsSet("region")).OrError(t)
+			its.EqEq(false).Match(cl.IsSet("debug")).OrError(t)
+			its.EqEq(false).Match(cl.IsSet("profile")).OrError(t)
+
+			its.EqEq(flarc.SourceCommandline).Match(cl.Source("verbose")).OrError(t)
+			its.EqEq(flarc.SourceEnv).Match(cl.Source("-r")).OrError(t)
+			its.EqEq(flarc.SourceDefault).Match(cl.Source("profile")).OrError(t)
+
+			its.Slice(
+				its.EqEq("count"), its.EqEq("verbose"), its.EqEq("region"),
+			).Match(cl.Changed()).OrError(t)
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner, err := flarc.NewCommandGroup("inner", InnerFlag{}, flarc.WithSubcommand("sub", cmd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer, err := flarc.NewCommandGroup("outer", OuterFlag{}, flarc.WithSubcommand("inner", inner))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := flarc.Run(
+		context.Background(), outer,
+		flarc.WithArgs([]string{"inner", "-v", "sub", "--count", "2"}),
+		flarc.WithOutput(nil, nil),
+		flarc.WithLookupEnv(func(s string) (string, bool) {
+			if s == "REGION" {
+				return "env", true
+			}
+			return "", false
+		}),
+	)
+	its.EqEq(0).Match(status).OrError(t)
+	its.EqEq(true).Match(called).OrError(t)
+}
+
+func TestCommand_constraints(t *testing.T) {
+	type Flag struct {
+		Name string `required:"true" help:"your name"`
+		JSON bool   `flag:"json" negatable:"false"`
+		YAML bool   `flag:"yaml" negatable:"false"`
+	}
+
+	theory := func(args []string, wantStatus int, wantStdout string, wantStderr string) func(*testing.T) {
+		return func(t *testing.T) {
+			cmd, err := flarc.NewCommand(
+				"constraints", Flag{}, flarc.Args{},
+				func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+					fmt.Fprint(cl.Stdout(), cl.Flags().Name)
+					return nil
+				},
+				flarc.WithFlagConstraints(flarc.MutuallyExclusive("json", "yaml")),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stdout := new(strings.Builder)
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), cmd,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(stdout, stderr),
+			)
+			its.EqEq(wantStatus).Match(status).OrError(t)
+			its.Text(wantStdout).Match(stdout.String()).OrError(t)
+			its.Text(wantStderr).Match(stderr.String()).OrError(t)
+		}
+	}
+
+	help := `test -- constraints
+
+Usage:
+
+    test --name --json=false --yaml=false --help=false
+
+Flags:
+
+    --name      your name [required]
+    --json
+    --yaml
+    --help, -h  show help message
+
+Constraints:
+
+    --json, --yaml are mutually exclusive
+`
+
+	t.Run("constraints are satisfied", theory(
+		[]string{"--name", "flarc", "--json"}, 0, "flarc", "",
+	))
+
+	t.Run("help shows constraints", theory(
+		[]string{"-h"}, 0, "", help,
+	))
+
+	t.Run("violations are usage error", theory(
+		[]string{"--json", "--yaml"}, 2, "",
+		`- usage error: required flag is not given: --name
+- usage error: constraint violated: --json, --yaml are mutually exclusive
+
+`+help,
+	))
+
+	t.Run("too much args are listed with other usage errors", theory(
+		[]string{"--json", "--yaml", "extra"}, 2, "",
+		`- usage error: required flag is not given: --name
+- usage error: constraint violated: --json, --yaml are mutually exclusive
+- usage error: too much args
+
+`+help,
+	))
+}
+
+type outputFormat string
+
+func (outputFormat) Choices() []string {
+	return []string{"json", "yaml", "table"}
+}
+
+func TestCommand_choices(t *testing.T) {
+	type Flag struct {
+		Format outputFormat `help:"output format"`
+		Color  string       `choices:"auto,always,never"`
+	}
+
+	theory := func(args []string, wantStatus int, wantStdout string, wantStderr string) func(*testing.T) {
+		return func(t *testing.T) {
+			cmd, err := flarc.NewCommand(
+				"choices", Flag{Format: "table", Color: "auto"}, flarc.Args{},
+				func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+					fmt.Fprint(cl.Stdout(), cl.Flags().Format, " ", cl.Flags().Color)
+					return nil
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stdout := new(strings.Builder)
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), cmd,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(stdout, stderr),
+			)
+			its.EqEq(wantStatus).Match(status).OrError(t)
+			its.Text(wantStdout).Match(stdout.String()).OrError(t)
+			its.Text(wantStderr).Match(stderr.String()).OrError(t)
+		}
+	}
+
+	help := `test -- choices
+
+Usage:
+
+    test --format=json|yaml|table --color=auto|always|never --help=false
+
+Flags:
+
+    --format    output format [choices: json, yaml, table]
+    --color     [choices: auto, always, never]
+    --help, -h  show help message
+`
+
+	t.Run("values in choices are accepted", theory(
+		[]string{"--format", "yaml", "--color", "never"}, 0, "yaml never", "",
+	))
+
+	t.Run("defaults are kept", theory(
+		[]string{}, 0, "table auto", "",
+	))
+
+	t.Run("help shows choices", theory(
+		[]string{"-h"}, 0, "", help,
+	))
+
+	t.Run("values not in choices are usage error", theory(
+		[]string{"--format", "xml"}, 2, "",
+		"usage error: parse error: not in choices: xml is not one of json, yaml, table: --format\n\n"+
+			"    test --format xml\n"+
+			"                  ^~~\n\n"+help,
+	))
+}
+
+func TestCommandWithArgs(t *testing.T) {
+	type Flag struct {
+		Verbose bool `alias:"v"`
+	}
+	type Args struct {
+		Count   int      `arg:"COUNT" required:"true" help:"how many times"`
+		Targets []string `arg:"TARGET" help:"targets"`
+	}
+
+	theory := func(args []string, wantStatus int, wantStdout string, wantStderr string) func(*testing.T) {
+		return func(t *testing.T) {
+			cmd, err := flarc.NewCommandWithArgs(
+				"typed args", Flag{}, Args{},
+				func(ctx context.Context, cl flarc.CommandlineWithArgs[Flag, Args], params []any) error {
+					a := cl.TypedArgs()
+					fmt.Fprint(cl.Stdout(), a.Count, " ", a.Targets, " ", cl.Args()["COUNT"])
+					return nil
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stdout := new(strings.Builder)
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), cmd,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(stdout, stderr),
+			)
+			its.EqEq(wantStatus).Match(status).OrError(t)
+			its.Text(wantStdout).Match(stdout.String()).OrError(t)
+			its.Text(wantStderr).Match(stderr.String()).OrError(t)
+		}
+	}
+
+	help := `test -- typed args
+
+Usage:
+
+    test --[no-]verbose=false --help=false COUNT [TARGET[, ...]]
+
+Flags:
+
+    --[no-]verbose, -v
+    --help, -h  show help message
+
+Args:
+
+    COUNT       how many times
+    TARGET      targets
+`
+
+	t.Run("args are converted", theory(
+		[]string{"3", "a", "b"}, 0, "3 [a b] [3]", "",
+	))
+
+	t.Run("help shows args", theory(
+		[]string{"-h"}, 0, "", help,
+	))
+
+	t.Run("invalid args are usage error", theory(
+		[]string{"three"}, 2, "", `usage error: parse error: three is not int: COUNT
+
+    test three
+         ^~~~~
+
+`+help,
+	))
+
+	t.Run("invalid args are reported with other usage errors", theory(
+		[]string{"-v=maybe", "three", "a"}, 2, "", `- usage error: parse error: maybe is not bool: -v
+      test -v=maybe three a
+           ^~~~~~~~
+- usage error: parse error: three is not int: COUNT
+      test -v=maybe three a
+                    ^~~~~
+
+`+help,
+	))
+}
+
+func TestRun_completion(t *testing.T) {
+	type Flag struct {
+		Format string `alias:"f" choices:"json,yaml"`
+	}
+
+	theory := func(args []string, enabled bool, wantStatus int, wantStdout its.Matcher[string], wantStderr string) func(*testing.T) {
+		return func(t *testing.T) {
+			sub, err := flarc.NewCommand(
+				"subcommand", Flag{}, flarc.Args{},
+				func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+					fmt.Fprint(cl.Stdout(), "run")
+					return nil
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			grp, err := flarc.NewCommandGroup(
+				"group", struct{}{}, flarc.WithSubcommand("sub", sub),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stdout := new(strings.Builder)
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), grp,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(stdout, stderr),
+				flarc.WithCompletion(enabled),
+			)
+			its.EqEq(wantStatus).Match(status).OrError(t)
+			wantStdout.Match(stdout.String()).OrError(t)
+			its.Text(wantStderr).Match(stderr.String()).OrError(t)
+		}
+	}
+
+	t.Run("prints bash completion", theory(
+		[]string{"completion", "bash"}, true, 0,
+		its.All(
+			its.StringHavingPrefix("# bash completion for test\n"),
+			its.StringContaining("compgen -W 'sub'"),
+			its.StringContaining("compgen -W '--format -f --help -h'"),
+			its.StringHavingSuffix("complete -o default -F _test_complete test\n"),
+		),
+		"",
+	))
+
+	t.Run("prints zsh completion", theory(
+		[]string{"completion", "zsh"}, true, 0,
+		its.StringHavingPrefix("#compdef test\n"),
+		"",
+	))
+
+	t.Run("prints fish completion", theory(
+		[]string{"completion", "fish"}, true, 0,
+		its.StringHavingPrefix("# fish completion for test\n"),
+		"",
+	))
+
+	t.Run("unknown shell", theory(
+		[]string{"completion", "tcsh"}, true, 2,
+		its.EqEq(""),
+		"unknown shell: tcsh (supported: bash, zsh, fish)\n",
+	))
+
+	t.Run("shell is not given", theory(
+		[]string{"completion"}, true, 2,
+		its.EqEq(""),
+		"usage: test completion {bash|zsh|fish}\n",
+	))
+
+	t.Run("hidden in help", theory(
+		[]string{"-h"}, true, 0,
+		its.EqEq(""),
+		`test -- group
+
+Usage:
+
+    test --help=false
+
+Flags:
+
+    --help, -h  show help message
+
+Subcommands:
+
+    sub         subcommand
+
+`,
+	))
+
+	t.Run("not enabled", theory(
+		[]string{"completion", "bash"}, false, 2,
+		its.EqEq(""),
+		`usage error: unknown subcommand: completion
+
+test -- group
+
+Usage:
+
+    test --help=false
+
+Flags:
+
+    --help, -h  show help message
+
+Subcommands:
+
+    sub         subcommand
+
+`,
+	))
+}
+
+func TestRun_dynamicCompletion(t *testing.T) {
+	type GroupFlag struct {
+		Region string `alias:"r" help:"region to use"`
+	}
+	type Flag struct {
+		Format string `alias:"f" choices:"json,yaml" help:"output format"`
+		Dry    bool   `help:"dry run"`
+		Tag    string
+	}
+
+	theory := func(args []string, enabled bool, wantStatus int, wantStdout string) func(*testing.T) {
+		return func(t *testing.T) {
+			sub, err := flarc.NewCommand(
+				"subcommand", Flag{},
+				flarc.Args{
+					{
+						Name: "FILE", Required: true, Repeatable: true,
+						Complete: func(ctx context.Context, partial string) []completion.Candidate {
+							return completion.Filter([]completion.Candidate{
+								{Value: "a.txt"}, {Value: "b.txt"},
+							}, partial)
+						},
+						CompleteDirective: completion.DirectiveFiles,
+					},
+				},
+				func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+					fmt.Fprint(cl.Stdout(), "run")
+					return nil
+				},
+				flarc.WithFlagCompleter(
+					"tag",
+					func(ctx context.Context, partial string) []completion.Candidate {
+						return []completion.Candidate{{Value: partial + "-v1", Description: "version 1"}}
+					},
+					completion.DirectiveNoSpace,
+				),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			grp, err := flarc.NewCommandGroup(
+				"group", GroupFlag{},
+				flarc.WithSubcommand("sub", sub),
+				flarc.WithSubcommand("status", sub),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stdout := new(strings.Builder)
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), grp,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(stdout, stderr),
+				flarc.WithCompletion(enabled),
+			)
+			its.EqEq(wantStatus).Match(status).OrError(t)
+			its.Text(wantStdout).Match(stdout.String()).OrError(t)
+		}
+	}
+
+	t.Run("subcommands", theory(
+		[]string{"__complete", ""}, true, 0,
+		"status\tsubcommand\nsub\tsubcommand\n:2\n",
+	))
+	t.Run("subcommands with prefix", theory(
+		[]string{"__complete", "-r", "x", "su"}, true, 0,
+		"sub\tsubcommand\n:2\n",
+	))
+	t.Run("flags of group", theory(
+		[]string{"__complete", "-"}, true, 0,
+		"--region\tregion to use\n-r\tregion to use\n--help\tshow help message\n-h\tshow help message\n:2\n",
+	))
+	t.Run("flags of subcommand, with inherited ones", theory(
+		[]string{"__complete", "sub", "--"}, true, 0,
+		"--format\toutput format\n--dry\tdry run\n--no-dry\n--tag\n--region\tregion to use\n--help\tshow help message\n:2\n",
+	))
+	t.Run("choices", theory(
+		[]string{"__complete", "sub", "-f", ""}, true, 0,
+		"json\nyaml\n:2\n",
+	))
+	t.Run("choices after =", theory(
+		[]string{"__complete", "sub", "--format=y"}, true, 0,
+		"yaml\n:2\n",
+	))
+	t.Run("flag completer", theory(
+		[]string{"__complete", "sub", "--tag", "x"}, true, 0,
+		"x-v1\tversion 1\n:1\n",
+	))
+	t.Run("arg completer", theory(
+		[]string{"__complete", "sub", "--dry", "a"}, true, 0,
+		"a.txt\n:4\n",
+	))
+	t.Run("flag without completers", theory(
+		[]string{"__complete", "--region", ""}, true, 0,
+		":0\n",
+	))
+	t.Run("not enabled", theory(
+		[]string{"__complete", ""}, false, 2,
+		"",
+	))
+}
+
+func TestRun_dynamicCompletion_incompleteGroupFlags(t *testing.T) {
+	type GroupFlag struct {
+		Region string `required:"true"`
+		JSON   bool   `flag:"json"`
+		YAML   bool   `flag:"yaml"`
+	}
+	type Flag struct {
+		Format string `choices:"json,yaml" help:"output format"`
+	}
+
+	theory := func(args []string, wantStdout string) func(*testing.T) {
+		return func(t *testing.T) {
+			sub, err := flarc.NewCommand(
+				"subcommand", Flag{}, flarc.Args{},
+				func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+					return nil
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			grp, err := flarc.NewCommandGroup(
+				"group", GroupFlag{},
+				flarc.WithSubcommand("sub", sub),
+				flarc.WithGroupFlagConstraints(flarc.MutuallyExclusive("json", "yaml")),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stdout := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), grp,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(stdout, io.Discard),
+				flarc.WithCompletion(true),
+				flarc.WithHelp(false),
+			)
+			its.EqEq(0).Match(status).OrError(t)
+			its.Text(wantStdout).Match(stdout.String()).OrError(t)
+		}
+	}
+
+	t.Run("value of subcommand flag, without required group flag", theory(
+		[]string{"__complete", "sub", "--format", ""},
+		"json\nyaml\n:2\n",
+	))
+	t.Run("flags of subcommand, without required group flag", theory(
+		[]string{"__complete", "sub", "--"},
+		"--format\toutput format\n--region\n--json\n--no-json\n--yaml\n--no-yaml\n:2\n",
+	))
+	t.Run("flags of subcommand, violating constraints of group", theory(
+		[]string{"__complete", "--region", "x", "--json", "--yaml", "sub", "--format", "j"},
+		"json\n:2\n",
+	))
+	t.Run("invalid value of group flag", theory(
+		[]string{"__complete", "--json=x", "sub", "--format", ""},
+		"sub\tsubcommand\n:2\n",
+	))
+}
+
+func TestWriteManPages(t *testing.T) {
+	type GroupFlag struct {
+		Region string `alias:"r" help:"region to use" env:"REGION"`
+	}
+	type Flag struct {
+		Format string `alias:"f" choices:"json,yaml" help:"output format"`
+		Dry    bool   `help:"dry run"`
+		Limit  int
+	}
+
+	sub, err := flarc.NewCommand(
+		"run a task", Flag{Limit: 10},
+		flarc.Args{
+			{Name: "SRC", Required: true, Help: "source file"},
+		},
+		func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+			return nil
+		},
+		flarc.WithDescription(`Run the task with {{ .Command }}.
+
+.dots and \backslashes are escaped.`),
+		flarc.WithFlagConstraints(flarc.MutuallyExclusive("format", "dry")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grp, err := flarc.NewCommandGroup(
+		"my application", GroupFlag{},
+		flarc.WithSubcommand("run", sub),
+		flarc.WithSubcommand("status", sub),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := flarc.WriteManPages(dir, "my-app", grp); err != nil {
+		t.Fatal(err)
+	}
+
+	pages, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range pages {
+		pages[i] = filepath.Base(pages[i])
+	}
+	its.Slice(
+		its.EqEq("my-app-run.1"),
+		its.EqEq("my-app-status.1"),
+		its.EqEq("my-app.1"),
+	).Match(pages).OrError(t)
+
+	t.Run("command group", func(t *testing.T) {
+		got, err := os.ReadFile(filepath.Join(dir, "my-app.1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		its.Text(`.TH "MY\-APP" 1
+.SH NAME
+my\-app \- my application
+.SH SYNOPSIS
+\fBmy\-app\fR \-\-region \-\-help=false
+.SH OPTIONS
+.TP
+\fB\-\-region\fR, \fB\-r\fR
+region to use [env: REGION]
+.TP
+\fB\-\-help\fR=\fIfalse\fR, \fB\-h\fR
+show help message
+.SH COMMANDS
+.TP
+\fBmy\-app\-run\fR(1)
+run a task
+.TP
+\fBmy\-app\-status\fR(1)
+run a task
+`).Match(string(got)).OrError(t)
+	})
+
+	t.Run("subcommand", func(t *testing.T) {
+		got, err := os.ReadFile(filepath.Join(dir, "my-app-run.1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		its.Text(`.TH "MY\-APP\-RUN" 1
+.SH NAME
+my\-app\-run \- run a task
+.SH SYNOPSIS
+\fBmy\-app run\fR \-\-format=json|yaml \-\-[no\-]dry=false \-\-limit=10 \-\-region \-\-help=false SRC
+.SH DESCRIPTION
+Run the task with my\-app run.
+.PP
+\&.dots and \ebackslashes are escaped.
+.SH OPTIONS
+.TP
+\fB\-\-format\fR=\fIjson|yaml\fR, \fB\-f\fR
+output format [choices: json, yaml]
+.TP
+\fB\-\-[no\-]dry\fR=\fIfalse\fR
+dry run
+.TP
+\fB\-\-limit\fR=\fI10\fR
+.TP
+\fB\-\-region\fR, \fB\-r\fR
+region to use [env: REGION]
+.TP
+\fB\-\-help\fR=\fIfalse\fR, \fB\-h\fR
+show help message
+.SS Constraints
+.IP \(bu 2
+\-\-format, \-\-dry are mutually exclusive
+.SH ARGUMENTS
+.TP
+\fBSRC\fR
+source file
+.SH SEE ALSO
+\fBmy\-app\fR(1), \fBmy\-app\-status\fR(1)
+`).Match(string(got)).OrError(t)
+	})
+}
+
+func TestWriteMarkdownDocs(t *testing.T) {
+	type GroupFlag struct {
+		Region string `alias:"r" help:"region to use" env:"REGION"`
+	}
+	type Flag struct {
+		Format string `alias:"f" choices:"json,yaml" help:"output format"`
+		Dry    bool   `help:"dry run"`
+		Limit  int
+	}
+
+	sub, err := flarc.NewCommand(
+		"run a task", Flag{Limit: 10},
+		flarc.Args{
+			{Name: "SRC", Required: true, Help: "source file"},
+		},
+		func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+			return nil
+		},
+		flarc.WithDescription(`Run the task with {{ .Command }}.
+`),
+		flarc.WithFlagConstraints(flarc.MutuallyExclusive("format", "dry")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grp, err := flarc.NewCommandGroup(
+		"my application", GroupFlag{},
+		flarc.WithSubcommand("run", sub),
+		flarc.WithSubcommand("status", sub),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := flarc.WriteMarkdownDocs(dir, "my-app", grp); err != nil {
+		t.Fatal(err)
+	}
+
+	pages, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range pages {
+		pages[i] = filepath.Base(pages[i])
+	}
+	its.Slice(
+		its.EqEq("my-app-run.md"),
+		its.EqEq("my-app-status.md"),
+		its.EqEq("my-app.md"),
+	).Match(pages).OrError(t)
+
+	t.Run("command group", func(t *testing.T) {
+		got, err := os.ReadFile(filepath.Join(dir, "my-app.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		its.Text("# my-app\n" +
+			"\n" +
+			"my application\n" +
+			"\n" +
+			"## Usage\n" +
+			"\n" +
+			"```\n" +
+			"my-app --region --help=false\n" +
+			"```\n" +
+			"\n" +
+			"## Flags\n" +
+			"\n" +
+			"| Flag | Value | Description |\n" +
+			"| ---- | ----- | ----------- |\n" +
+			"| `--region`, `-r` |  | region to use [env: REGION] |\n" +
+			"| `--help`, `-h` | `false` | show help message |\n" +
+			"\n" +
+			"## Subcommands\n" +
+			"\n" +
+			"| Subcommand | Description |\n" +
+			"| ---------- | ----------- |\n" +
+			"| [run](my-app-run.md) | run a task |\n" +
+			"| [status](my-app-status.md) | run a task |\n",
+		).Match(string(got)).OrError(t)
+	})
+
+	t.Run("subcommand", func(t *testing.T) {
+		got, err := os.ReadFile(filepath.Join(dir, "my-app-run.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		its.Text("# my-app run\n" +
+			"\n" +
+			"run a task\n" +
+			"\n" +
+			"## Usage\n" +
+			"\n" +
+			"```\n" +
+			"my-app run --format=json|yaml --[no-]dry=false --limit=10 --region --help=false SRC\n" +
+			"```\n" +
+			"\n" +
+			"## Description\n" +
+			"\n" +
+			"Run the task with my-app run.\n" +
+			"\n" +
+			"## Flags\n" +
+			"\n" +
+			"| Flag | Value | Description |\n" +
+			"| ---- | ----- | ----------- |\n" +
+			"| `--format`, `-f` | `json\\|yaml` | output format [choices: json, yaml] |\n" +
+			"| `--[no-]dry` | `false` | dry run |\n" +
+			"| `--limit` | `10` |  |\n" +
+			"| `--region`, `-r` |  | region to use [env: REGION] |\n" +
+			"| `--help`, `-h` | `false` | show help message |\n" +
+			"\n" +
+			"### Constraints\n" +
+			"\n" +
+			"- --format, --dry are mutually exclusive\n" +
+			"\n" +
+			"## Args\n" +
+			"\n" +
+			"| Arg | Description |\n" +
+			"| --- | ----------- |\n" +
+			"| `SRC` | source file |\n" +
+			"\n" +
+			"## See also\n" +
+			"\n" +
+			"- [my-app](my-app.md)\n" +
+			"- [my-app status](my-app-status.md)\n",
+		).Match(string(got)).OrError(t)
+	})
+}
+
+func TestWriteSpec(t *testing.T) {
+	type GroupFlag struct {
+		Region string `alias:"r" help:"region to use" env:"REGION"`
+	}
+	type Flag struct {
+		Format string        `alias:"f" choices:"json,yaml" help:"output format" required:"true"`
+		Dry    bool          `help:"dry run"`
+		Wait   time.Duration `metavar:"DURATION"`
+	}
+
+	sub, err := flarc.NewCommand(
+		"run a task", Flag{Wait: 3 * time.Second},
+		flarc.Args{
+			{Name: "SRC", Required: true, Repeatable: true, Help: "source files"},
+		},
+		func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+			return nil
+		},
+		flarc.WithDescription(`Run the task with {{ .Command }}.`),
+		flarc.WithFlagConstraints(flarc.MutuallyExclusive("format", "dry")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grp, err := flarc.NewCommandGroup(
+		"my application", GroupFlag{},
+		flarc.WithSubcommand("run", sub),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := new(strings.Builder)
+	if err := flarc.WriteSpec(got, "my-app", grp); err != nil {
+		t.Fatal(err)
+	}
+
+	its.Text(`{
+  "name": "my-app",
+  "path": [
+    "my-app"
+  ],
+  "shortDescription": "my application",
+  "description": "",
+  "flags": [
+    {
+      "name": "--region",
+      "aliases": [
+        "-r"
+      ],
+      "type": "string",
+      "default": "",
+      "metavar": "",
+      "help": "region to use",
+      "env": "REGION",
+      "required": false,
+      "negatable": false,
+      "choices": []
+    },
+    {
+      "name": "--help",
+      "aliases": [
+        "-h"
+      ],
+      "type": "bool",
+      "default": "false",
+      "metavar": "false",
+      "help": "show help message",
+      "env": "",
+      "required": false,
+      "negatable": false,
+      "choices": []
+    }
+  ],
+  "constraints": [],
+  "args": [],
+  "subcommands": [
+    {
+      "name": "run",
+      "path": [
+        "my-app",
+        "run"
+      ],
+      "shortDescription": "run a task",
+      "description": "Run the task with my-app run.",
+      "flags": [
+        {
+          "name": "--format",
+          "aliases": [
+            "-f"
+          ],
+          "type": "string",
+          "default": "",
+          "metavar": "json|yaml",
+          "help": "output format",
+          "env": "",
+          "required": true,
+          "negatable": false,
+          "choices": [
+            "json",
+            "yaml"
+          ]
+        },
+        {
+          "name": "--dry",
+          "aliases": [],
+          "type": "bool",
+          "default": "false",
+          "metavar": "false",
+          "help": "dry run",
+          "env": "",
+          "required": false,
+          "negatable": true,
+          "choices": []
+        },
+        {
+          "name": "--wait",
+          "aliases": [],
+          "type": "time.Duration",
+          "default": "3s",
+          "metavar": "DURATION",
+          "help": "",
+          "env": "",
+          "required": false,
+          "negatable": false,
+          "choices": []
+        }
+      ],
+      "constraints": [
+        "--format, --dry are mutually exclusive"
+      ],
+      "args": [
+        {
+          "name": "SRC",
+          "help": "source files",
+          "required": true,
+          "repeatable": true
+        }
+      ],
+      "subcommands": []
+    }
+  ]
+}
+`).Match(got.String()).OrError(t)
+}
+
+func TestWalk(t *testing.T) {
+	type Flag struct {
+		Format string `alias:"f" help:"output format"`
+	}
+	leaf, err := flarc.NewCommand(
+		"leaf command", Flag{},
+		flarc.Args{{Name: "SRC", Required: true}},
+		func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+			return nil
+		},
+		flarc.WithDescription("description of {{ .Command }}"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner, err := flarc.NewCommandGroup(
+		"inner group", struct{}{},
+		flarc.WithSubcommand("b", leaf),
+		flarc.WithSubcommand("a", leaf),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := flarc.NewCommandGroup(
+		"root group", struct{}{},
+		flarc.WithSubcommand("leaf", leaf),
+		flarc.WithSubcommand("inner", inner),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("visits all commands", func(t *testing.T) {
+		visited := []string{}
+		err := flarc.Walk(root, func(path []string, c flarc.Command) error {
+			visited = append(visited, strings.Join(path, " ")+": "+c.ShortDescription())
+			return nil
+		})
+		its.Nil[error]().Match(err).OrError(t)
+		its.Slice(
+			its.EqEq(": root group"),
+			its.EqEq("inner: inner group"),
+			its.EqEq("inner a: leaf command"),
+			its.EqEq("inner b: leaf command"),
+			its.EqEq("leaf: leaf command"),
+		).Match(visited).OrError(t)
+	})
+
+	t.Run("skips subcommands", func(t *testing.T) {
+		visited := []string{}
+		err := flarc.Walk(root, func(path []string, c flarc.Command) error {
+			visited = append(visited, strings.Join(path, " "))
+			if 0 < len(path) && path[0] == "inner" {
+				return flarc.SkipSubcommands
+			}
+			return nil
+		})
+		its.Nil[error]().Match(err).OrError(t)
+		its.Slice(its.EqEq(""), its.EqEq("inner"), its.EqEq("leaf")).Match(visited).OrError(t)
+	})
+
+	t.Run("stops with error", func(t *testing.T) {
+		errStop := errors.New("stop")
+		visited := []string{}
+		err := flarc.Walk(root, func(path []string, c flarc.Command) error {
+			visited = append(visited, strings.Join(path, " "))
+			if len(path) == 2 {
+				return errStop
+			}
+			return nil
+		})
+		its.Error(errStop).Match(err).OrError(t)
+		its.Slice(its.EqEq(""), its.EqEq("inner"), its.EqEq("inner a")).Match(visited).OrError(t)
+	})
+
+	t.Run("accessors", func(t *testing.T) {
+		its.EqEq("description of {{ .Command }}").Match(leaf.Description()).OrError(t)
+		its.EqEq("").Match(root.Description()).OrError(t)
+
+		flags := []string{}
+		for _, f := range leaf.Flags() {
+			flags = append(flags, f.Usage())
+		}
+		its.Slice(its.EqEq("--format")).Match(flags).OrError(t)
+
+		args := []string{}
+		for _, a := range leaf.Args() {
+			args = append(args, a.Name())
+		}
+		its.Slice(its.EqEq("SRC")).Match(args).OrError(t)
+		its.EqEq(0).Match(len(root.Args())).OrError(t)
+		its.EqEq(0).Match(len(leaf.Subcommands())).OrError(t)
+
+		subs := root.Subcommands()
+		delete(subs, "leaf")
+		its.EqEq(2).Match(len(root.Subcommands())).OrError(t)
+	})
+}
+
+func TestRun_suggestions(t *testing.T) {
+	type GroupFlag struct {
+		Region string `alias:"r"`
+	}
+	type Flag struct {
+		Format string `alias:"f"`
+	}
+
+	sub, err := flarc.NewCommand(
+		"subcommand", Flag{}, flarc.Args{},
+		func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+			fmt.Fprint(cl.Stdout(), "run")
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grp, err := flarc.NewCommandGroup(
+		"group", GroupFlag{},
+		flarc.WithSubcommand("status", sub),
+		flarc.WithSubcommand("start", sub),
+		flarc.WithSubcommand("list", sub),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	theory := func(args []string, wantStatus int, wantMessage string) func(*testing.T) {
+		return func(t *testing.T) {
+			stdout := new(strings.Builder)
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), grp,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(stdout, stderr),
+			)
+			its.EqEq(wantStatus).Match(status).OrError(t)
+			msg, _, _ := strings.Cut(stderr.String(), "\n")
+			its.EqEq(wantMessage).Match(msg).OrError(t)
+		}
+	}
+
+	t.Run("mistyped subcommand", theory(
+		[]string{"statsu"}, 2,
+		"usage error: unknown subcommand: statsu (did you mean `status`?)",
+	))
+	t.Run("prefix of subcommands", theory(
+		[]string{"sta"}, 2,
+		"usage error: unknown subcommand: sta (did you mean `start` or `status`?)",
+	))
+	t.Run("unknown subcommand", theory(
+		[]string{"delete"}, 2,
+		"usage error: unknown subcommand: delete",
+	))
+	t.Run("mistyped flag of subcommand", theory(
+		[]string{"list", "--fromat=json"}, 2,
+		"usage error: unknown flag: --fromat (did you mean `--format`?)",
+	))
+	t.Run("mistyped flag of group", theory(
+		[]string{"--regoin", "x", "list"}, 2,
+		"usage error: unknown flag: --regoin (did you mean `--region`?)",
+	))
+	t.Run("flags of subcommand and group are suggested in one ranking", theory(
+		[]string{"list", "--regions"}, 2,
+		"usage error: unknown flag: --regions (did you mean `--region`?)",
+	))
+	t.Run("mistyped flags are not confused with the help flag", theory(
+		[]string{"list", "--format", "json"}, 0,
+		"",
+	))
+
+	t.Run("UnknownSubcommandError", func(t *testing.T) {
+		err := error(&flarc.UnknownSubcommandError{Name: "stats", Suggestions: []string{"status", "start"}})
+		its.Error(flarc.ErrUsage).Match(err).OrError(t)
+		its.EqEq("usage error: unknown subcommand: stats (did you mean `status` or `start`?)").
+			Match(err.Error()).OrError(t)
+	})
+}
+
+func TestRun_unknownFlags(t *testing.T) {
+	type Flag struct {
+		Verbose bool `alias:"v"`
+	}
+
+	theory := func(passthrough bool, args []string, wantStatus int, wantMessage string, wantArgs its.Matcher[[]string]) func(*testing.T) {
+		return func(t *testing.T) {
+			options := []flarc.CommandOption{}
+			if passthrough {
+				options = append(options, flarc.WithUnknownFlagsPassthrough())
+			}
+
+			var gotArgs []string
+			cmd, err := flarc.NewCommand(
+				"wrapper", Flag{}, flarc.Args{{Name: "ARGS", Repeatable: true}},
+				func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+					gotArgs = cl.Args()["ARGS"]
+					return nil
+				},
+				options...,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), cmd,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(nil, stderr),
+			)
+			its.EqEq(wantStatus).Match(status).OrError(t)
+			msg, _, _ := strings.Cut(stderr.String(), "\n")
+			its.EqEq(wantMessage).Match(msg).OrError(t)
+			wantArgs.Match(gotArgs).OrError(t)
+		}
+	}
+
+	t.Run("unknown flag is usage error", theory(
+		false, []string{"-v", "--color=auto", "x"}, 2,
+		"usage error: unknown flag: --color", its.Nil[[]string](),
+	))
+	t.Run("unknown short flag is usage error", theory(
+		false, []string{"-n", "x"}, 2,
+		"usage error: unknown flag: -n", its.Nil[[]string](),
+	))
+	t.Run("unknown flags are passed through", theory(
+		true, []string{"-v", "--color=auto", "-n", "x"}, 0,
+		"", its.Slice(its.EqEq("--color=auto"), its.EqEq("-n"), its.EqEq("x")),
+	))
+}
+
+func TestRun_shortFlagClusters(t *testing.T) {
+	type GroupFlag struct {
+		Global bool `alias:"g"`
+	}
+	type Flag struct {
+		Verbose bool `alias:"v"`
+	}
+
+	theory := func(args []string, wantStatus int, wantMessage string, wantHelp bool) func(*testing.T) {
+		return func(t *testing.T) {
+			sub, err := flarc.NewCommand(
+				"subcommand", Flag{}, flarc.Args{},
+				func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+					return nil
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			grp, err := flarc.NewCommandGroup("group", GroupFlag{}, flarc.WithSubcommand("sub", sub))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), grp,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(nil, stderr),
+			)
+			its.EqEq(wantStatus).Match(status).OrError(t)
+			msg, _, _ := strings.Cut(stderr.String(), "\n")
+			its.EqEq(wantMessage).Match(msg).OrError(t)
+			its.EqEq(wantHelp).Match(strings.Contains(stderr.String(), "\nUsage:\n")).OrError(t)
+		}
+	}
+
+	t.Run("-hv mixing help and flags of subcommand", theory(
+		[]string{"sub", "-hv"}, 2, "usage error: unknown flag: -h (did you mean `--help`?)", true,
+	))
+	t.Run("-vh mixing flags of subcommand and help", theory(
+		[]string{"sub", "-vh"}, 2, "usage error: unknown short flag: -h: -vh", true,
+	))
+	t.Run("-hg mixing help and flags of group", theory(
+		[]string{"-hg", "sub"}, 2, "usage error: unknown flag: -hg", true,
+	))
+	t.Run("-gv mixing flags of group and subcommand", theory(
+		[]string{"sub", "-gv"}, 2, "usage error: unknown flag: -g (did you mean `--global`?)", true,
+	))
+	t.Run("-vg mixing flags of subcommand and group", theory(
+		[]string{"sub", "-vg"}, 2, "usage error: unknown short flag: -g: -vg", true,
+	))
+	t.Run("clusters in the same level are parsed", theory(
+		[]string{"-g", "sub", "-vv"}, 0, "", false,
+	))
+	t.Run("invalid value of help flag is usage error", theory(
+		[]string{"sub", "--help=maybe"}, 2, "usage error: parse error: maybe is not bool: --help", true,
+	))
+}
+
+func TestRun_parseErrorCaret(t *testing.T) {
+	type GroupFlag struct {
+		Region string `alias:"r"`
+	}
+	type Flag struct {
+		Count int `alias:"c"`
+	}
+
+	sub, err := flarc.NewCommand(
+		"subcommand", Flag{}, flarc.Args{{Name: "ARGS", Repeatable: true}},
+		func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grp, err := flarc.NewCommandGroup(
+		"group", GroupFlag{},
+		flarc.WithSubcommand("list", sub),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	theory := func(args []string, want string) func(*testing.T) {
+		return func(t *testing.T) {
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), grp,
+				flarc.WithName("test"),
+				flarc.WithArgs(args),
+				flarc.WithOutput(nil, stderr),
+			)
+			its.EqEq(2).Match(status).OrError(t)
+			got, _, _ := strings.Cut(stderr.String(), "\ntest ")
+			its.Text(want).Match(got).OrError(t)
+		}
+	}
+
+	t.Run("value of flag", theory(
+		[]string{"-r", "us", "list", "a", "--count", "many"},
+		`usage error: parse error: many is not int: --count
+
+    test -r us list a --count many
+                              ^~~~
+`,
+	))
+	t.Run("flag with value", theory(
+		[]string{"list", "--region=us", "-c=many", "b"},
+		`usage error: parse error: many is not int: -c
+
+    test list --region=us -c=many b
+                          ^~~~~~~
+`,
+	))
+	t.Run("errors are listed", theory(
+		[]string{"list", "-c", "x", "--verbose", "--count=y"},
+		`- usage error: parse error: x is not int: -c
+      test list -c x --verbose --count=y
+                   ^
+- usage error: unknown flag: --verbose
+      test list -c x --verbose --count=y
+                     ^~~~~~~~~
+- usage error: parse error: y is not int: --count
+      test list -c x --verbose --count=y
+                               ^~~~~~~~~
+`,
+	))
+	t.Run("unknown flag", theory(
+		[]string{"list", "a", "--cuont=1"},
+		"usage error: unknown flag: --cuont (did you mean `--count`?)\n"+`
+    test list a --cuont=1
+                ^~~~~~~~~
+`,
+	))
+	t.Run("unknown flag in clustered short flags", theory(
+		[]string{"-r", "us", "list", "-xc", "1"},
+		`usage error: unknown flag: -x
+
+    test -r us list -xc 1
+                    ^~~
+`,
+	))
+	t.Run("unknown flag before subcommand", theory(
+		[]string{"-r", "us", "--regoin", "eu", "list"},
+		"usage error: unknown flag: --regoin (did you mean `--region`?)\n"+`
+    test -r us --regoin eu list
+               ^~~~~~~~
+`,
+	))
+	t.Run("tokens with spaces are quoted", theory(
+		[]string{"list", "a b", "-c", "two words"},
+		`usage error: parse error: two words is not int: -c
+
+    test list "a b" -c "two words"
+                       ^~~~~~~~~~~
+`,
+	))
+}
+
+func TestRun_helpConflicts(t *testing.T) {
+	type Flag struct {
+		Host string `alias:"h"`
+	}
+	host := ""
+	cmd, err := flarc.NewCommand(
+		"command", Flag{}, flarc.Args{},
+		func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error {
+			host = cl.Flags().Host
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type GroupFlag struct {
+		Help string
+	}
+	group, err := flarc.NewCommandGroup("group", GroupFlag{}, flarc.WithSubcommand("sub", cmd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer, err := flarc.NewCommandGroup("outer", struct{}{}, flarc.WithSubcommand("group", group))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	theory := func(cmd flarc.Command, options []flarc.RunOption, wantStatus int, wantStderr string) func(*testing.T) {
+		return func(t *testing.T) {
+			host = ""
+			stdout := new(strings.Builder)
+			stderr := new(strings.Builder)
+			status := flarc.Run(
+				context.Background(), cmd,
+				append([]flarc.RunOption{flarc.WithOutput(stdout, stderr)}, options...)...,
+			)
+			its.EqEq(wantStatus).Match(status).OrError(t)
+			its.EqEq(wantStderr).Match(stderr.String()).OrError(t)
+		}
+	}
+
+	t.Run("command", theory(
+		cmd, []flarc.RunOption{flarc.WithArgs([]string{"-h", "example.com"})},
+		1, "flag name conflicts: -h is declared by field Help and field Host, in the help flag and the command\n",
+	))
+
+	t.Run("subcommands", theory(
+		outer, []flarc.RunOption{flarc.WithArgs([]string{"group", "sub"})},
+		1, "flag name conflicts: --help is declared by field Help and field Help, in the help flag and the subcommand group\n",
+	))
+
+	t.Run("without the help flag", func(t *testing.T) {
+		theory(
+			cmd, []flarc.RunOption{flarc.WithHelp(false), flarc.WithArgs([]string{"-h", "example.com"})},
+			0, "",
+		)(t)
+		its.EqEq("example.com").Match(host).OrError(t)
+	})
+}
+
+func TestNewCommand_conflicts(t *testing.T) {
+	task := func(ctx context.Context, cl flarc.Commandline[struct{}], params []any) error {
+		return nil
+	}
+
+	t.Run("group flags shadowing flags of subcommands", func(t *testing.T) {
+		type GroupFlag struct {
+			Region string `alias:"r"`
+		}
+		type Flag struct {
+			Recursive bool `alias:"r"`
+		}
+
+		leaf, err := flarc.NewCommand(
+			"leaf", Flag{}, flarc.Args{},
+			func(ctx context.Context, cl flarc.Commandline[Flag], params []any) error { return nil },
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, err := flarc.NewCommand("other", struct{}{}, flarc.Args{}, task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inner, err := flarc.NewCommandGroup(
+			"inner", struct{}{},
+			flarc.WithSubcommand("copy", leaf),
+			flarc.WithSubcommand("list", other),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = flarc.NewCommandGroup(
+			"outer", GroupFlag{},
+			flarc.WithSubcommand("files", inner),
+		)
+		its.Error(flarc.ErrFlagConflict).Match(err).OrError(t)
+		its.EqEq("flag name conflicts: -r is declared by field Region and field Recursive, in the group and its subcommand files copy").
+			Match(fmt.Sprint(err)).OrError(t)
+	})
+
+	t.Run("no conflicts", func(t *testing.T) {
+		type GroupFlag struct {
+			Region string `alias:"r"`
+		}
+		sub, err := flarc.NewCommand("sub", struct{}{}, flarc.Args{}, task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = flarc.NewCommandGroup("group", GroupFlag{}, flarc.WithSubcommand("sub", sub))
+		its.Nil[error]().Match(err).OrError(t)
+	})
+}
@@ -0,0 +1,387 @@
+// flarcgen generates parsers for structs declaring flags, which work without reflection.
+//
+// Parsers created by parser.New inspect flag structs with reflection on each start up.
+// Generated parsers declare the same flags with typed code, and behave identically.
+//
+// This is designed to be used as go:generate:
+//
+//	//go:generate go run github.com/youta-t/flarc/flarcgen -type Flag
+//
+// For the struct Flag, it generates the function
+//
+//	func NewFlagParser(flagdef *Flag, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Flag], error)
+//
+// into the file "<source>_flarcgen.go" in the same package.
+// For unexported structs, the function is unexported, like newFlagParser.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/youta-t/flarc/params/fieldtype"
+)
+
+type names []string
+
+func (n *names) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*n = append(*n, s)
+		}
+	}
+	return nil
+}
+
+func (n *names) String() string {
+	return strings.Join(*n, ",")
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("flarcgen: ")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprint(
+			flag.CommandLine.Output(),
+			`flarcgen generates parsers for structs declaring flags, which work without reflection.
+This is designed to be used as go:generate.
+
+It generates a file named after a file having go:generate directive, with suffix "_flarcgen.go".
+For the struct Flag, the file has the function NewFlagParser, which works as parser.New.
+
+`,
+		)
+		flag.PrintDefaults()
+	}
+
+	targets := names{}
+	flag.Var(&targets, "type", "struct names to generate parsers. Repeatable, or comma separated. Required.")
+	flag.Var(&targets, "t", "alias of -type")
+	psource := flag.String("source", os.Getenv("GOFILE"), "file declaring structs. If not set, use environmental variable GOFILE.")
+	poutput := flag.String("output", "", `file to be written. By default, the source with suffix "_flarcgen.go".`)
+	flag.Parse()
+
+	if len(targets) == 0 {
+		flag.Usage()
+		log.Fatal("-type is required")
+	}
+	if *psource == "" {
+		flag.Usage()
+		log.Fatal("-source is required")
+	}
+
+	source, err := filepath.Abs(*psource)
+	if err != nil {
+		log.Fatal(err)
+	}
+	output := *poutput
+	if output == "" {
+		output = strings.TrimSuffix(source, ".go") + "_flarcgen.go"
+	}
+	output, err = filepath.Abs(output)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := load(filepath.Dir(source), output)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := generate(pkg, targets)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// load type-checks the package in dir. The file exclude, which is generated before, is ignored.
+func load(dir string, exclude string) (*types.Package, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	files := []*ast.File{}
+	for _, name := range bp.GoFiles {
+		path := filepath.Join(dir, name)
+		if path == exclude {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	return conf.Check(bp.ImportPath, fset, files, nil)
+}
+
+// generate renders the file declaring parsers for structs named targets in pkg.
+func generate(pkg *types.Package, targets []string) ([]byte, error) {
+	g := &generator{pkg: pkg, imports: map[string]string{
+		"github.com/youta-t/flarc/params": "params",
+		"github.com/youta-t/flarc/parser": "parser",
+	}}
+
+	file := genFile{Package: pkg.Name()}
+	for _, name := range targets {
+		s, err := g.genStruct(name)
+		if err != nil {
+			return nil, err
+		}
+		file.Structs = append(file.Structs, s)
+	}
+
+	std, others := []string{}, []string{}
+	for path, name := range g.imports {
+		spec := strconv.Quote(path)
+		if filepath.Base(path) != name {
+			spec = name + " " + spec
+		}
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			others = append(others, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+	file.Imports = append(std, "")
+	file.Imports = append(file.Imports, others...)
+
+	buf := new(bytes.Buffer)
+	if err := fileTemplate.Execute(buf, file); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+var fileTemplate = template.Must(template.New("").Parse(`// Code generated by flarcgen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{ range .Structs }}
+// {{ .Func }} creates a parser for {{ .Name }}, as parser.New does, without reflection.
+func {{ .Func }}(flagdef *{{ .Name }}, pos []params.ArgDef, options ...parser.Option) (parser.Parser[{{ .Name }}], error) {
+	fields := []parser.Field[{{ .Name }}]{
+	{{- range .Fields }}
+		{{ . }},
+	{{- end }}
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
+{{ end }}`))
+
+type genFile struct {
+	Package string
+	Imports []string
+	Structs []genStruct
+}
+
+type genStruct struct {
+	Name   string
+	Func   string
+	Fields []string
+}
+
+type generator struct {
+	pkg *types.Package
+
+	// imports maps paths of packages referred in generated code to their names.
+	imports map[string]string
+}
+
+// qualifier names packages in generated code, and records them to be imported.
+//
+// Packages sharing a name with others are imported with aliases, like "errors2".
+func (g *generator) qualifier(p *types.Package) string {
+	if p == g.pkg {
+		return ""
+	}
+	if name, ok := g.imports[p.Path()]; ok {
+		return name
+	}
+
+	name := p.Name()
+	for i := 2; g.imported(name); i += 1 {
+		name = p.Name() + strconv.Itoa(i)
+	}
+	g.imports[p.Path()] = name
+	return name
+}
+
+// imported reports a package is imported as name.
+func (g *generator) imported(name string) bool {
+	for _, n := range g.imports {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+func (g *generator) genStruct(name string) (genStruct, error) {
+	obj, ok := g.pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return genStruct{}, fmt.Errorf("type not found: %s", name)
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok || named.TypeParams().Len() != 0 {
+		return genStruct{}, fmt.Errorf("%s should be a non-generic defined type", name)
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return genStruct{}, fmt.Errorf("%s should be struct", name)
+	}
+
+	fn := "New" + name + "Parser"
+	if !obj.Exported() {
+		r := []rune(name)
+		r[0] = unicode.ToUpper(r[0])
+		fn = "new" + string(r) + "Parser"
+	}
+
+	gs := genStruct{Name: name, Func: fn}
+	for i := 0; i < st.NumFields(); i += 1 {
+		code, err := g.genField(name, st.Field(i), st.Tag(i))
+		if err != nil {
+			return genStruct{}, fmt.Errorf("%s.%s: %w", name, st.Field(i).Name(), err)
+		}
+		gs.Fields = append(gs.Fields, code)
+	}
+	return gs, nil
+}
+
+// genField renders the parser.Field declaring the flag for fld in the struct named owner.
+func (g *generator) genField(owner string, fld *types.Var, tag string) (string, error) {
+	if !fld.Exported() {
+		return "", errors.New("unexported field")
+	}
+
+	typ := fld.Type()
+	f := g.typeString(typ)
+	head := fmt.Sprintf(
+		"%s, %s,\nfunc(t *%s) *%s { return &t.%s },",
+		strconv.Quote(fld.Name()), tagLiteral(tag), owner, f, fld.Name(),
+	)
+
+	if fieldtype.IsCallback(typ) {
+		// values are passed to the field in flagdef, so there is nothing to store.
+		return fmt.Sprintf("parser.TypedField[%s, %s, %s](\n%s\nnil, nil,\n)", owner, f, f, head), nil
+	}
+
+	// shape are pointers ("*") and slices ("[]") wrapping leaf, from the outside.
+	shape := []string{}
+	levels := []types.Type{typ}
+	leaf := typ
+STRIP:
+	for {
+		switch t := leaf.(type) {
+		case *types.Pointer:
+			shape, leaf = append(shape, "*"), t.Elem()
+		case *types.Slice:
+			shape, leaf = append(shape, "[]"), t.Elem()
+		default:
+			break STRIP
+		}
+		levels = append(levels, leaf)
+	}
+
+	constructor := "parser.TypedField"
+	switch {
+	case fieldtype.IsBasicLeaf(leaf), fieldtype.IsTextUnmarshaler(leaf):
+	case fieldtype.IsString(leaf):
+		constructor = "parser.StringField"
+	default:
+		return "", fmt.Errorf("unsupported type: %s", f)
+	}
+
+	l := g.typeString(leaf)
+
+	set := new(strings.Builder)
+	fmt.Fprintf(set, "func(dest *%s) func(%s) {\n", f, l)
+	for k, s := range shape {
+		if s == "[]" {
+			// a fresh slice for each binding, as values set before are overwritten.
+			fmt.Fprintf(set, "s%d := %s{}\n", k, g.typeString(levels[k]))
+		}
+	}
+	fmt.Fprintf(set, "return func(v %s) {\n", l)
+	value := "v"
+	for k := len(shape) - 1; 0 <= k; k -= 1 {
+		switch shape[k] {
+		case "[]":
+			fmt.Fprintf(set, "s%d = append(s%d, %s)\n", k, k, value)
+			value = fmt.Sprintf("s%d", k)
+		case "*":
+			fmt.Fprintf(set, "p%d := %s\n", k, value)
+			value = fmt.Sprintf("&p%d", k)
+		}
+	}
+	fmt.Fprintf(set, "*dest = %s\n}\n}", value)
+
+	get := new(strings.Builder)
+	if len(shape) == 0 {
+		fmt.Fprintf(get, "func(f %s) []%s { return []%s{f} }", f, l, l)
+	} else {
+		fmt.Fprintf(get, "func(f %s) []%s {\nvs := []%s{}\n", f, l, l)
+		value := "f"
+		for k, s := range shape {
+			switch s {
+			case "[]":
+				fmt.Fprintf(get, "for _, v%d := range %s {\n", k, value)
+				value = fmt.Sprintf("v%d", k)
+			case "*":
+				fmt.Fprintf(get, "if %s != nil {\n", value)
+				value = "*" + value
+			}
+		}
+		fmt.Fprintf(get, "vs = append(vs, %s)\n%sreturn vs\n}", value, strings.Repeat("}\n", len(shape)))
+	}
+
+	return fmt.Sprintf(
+		"%s[%s, %s, %s](\n%s\n%s,\n%s,\n)",
+		constructor, owner, f, l, head, set, get,
+	), nil
+}
+
+func tagLiteral(tag string) string {
+	if tag == "" || strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/youta-t/its"
+)
+
+// parser/internal has parsers generated by flarcgen, which are tested to behave as parser.New.
+func TestGenerate_upToDate(t *testing.T) {
+	dir, err := filepath.Abs("../parser/internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	theory := func(source string, targets []string) func(*testing.T) {
+		return func(t *testing.T) {
+			output := filepath.Join(dir, strings.TrimSuffix(source, ".go")+"_flarcgen.go")
+
+			pkg, err := load(dir, output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := generate(pkg, targets)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			its.EqEq(string(want)).Match(string(got)).OrError(t)
+		}
+	}
+
+	t.Run("typed.go", theory("typed.go", []string{"Flag", "Shapes", "Tagged", "Kinds"}))
+	t.Run("tables.go", theory("tables.go", []string{
+		"Cluster", "Negation", "Env", "Config", "Sources", "Constraints", "Unknown", "Located", "Collected",
+	}))
+}
+
+func TestGenerate_sameNamedPackages(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module a\n",
+		"x/errors/errors.go": `package errors
+
+type Code int
+
+func (c *Code) UnmarshalText(b []byte) error { return nil }
+`,
+		"y/errors/errors.go": `package errors
+
+type Code string
+
+func (c *Code) UnmarshalText(b []byte) error { return nil }
+`,
+		"z/params/params.go": `package params
+
+type Level string
+`,
+		"flag.go": `package a
+
+import (
+	xerrors "a/x/errors"
+	"a/y/errors"
+	"a/z/params"
+)
+
+type Flag struct {
+	X     xerrors.Code
+	Y     errors.Code
+	Level params.Level
+}
+`,
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// packages are imported from the working directory, as go generate does.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	pkg, err := load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generate(pkg, []string{"Flag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`"a/x/errors"`, `errors2 "a/y/errors"`, `params2 "a/z/params"`,
+		"func(t *Flag) *errors.Code { return &t.X }",
+		"func(t *Flag) *errors2.Code { return &t.Y }",
+		"func(t *Flag) *params2.Level { return &t.Level }",
+	} {
+		its.StringContaining(want).Match(string(got)).OrError(t)
+	}
+}
+
+func TestGenerate_unsupported(t *testing.T) {
+	dir := t.TempDir()
+	src := `package a
+
+type Flag struct {
+	Events chan string
+}
+
+type hidden struct {
+	name string
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = generate(pkg, []string{"Flag"})
+	its.EqEq("Flag.Events: unsupported type: chan string").Match(err.Error()).OrError(t)
+
+	_, err = generate(pkg, []string{"hidden"})
+	its.EqEq("hidden.name: unexported field").Match(err.Error()).OrError(t)
+
+	_, err = generate(pkg, []string{"Missing"})
+	its.EqEq("type not found: Missing").Match(err.Error()).OrError(t)
+}
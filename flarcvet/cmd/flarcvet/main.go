@@ -0,0 +1,15 @@
+// Command flarcvet checks structs declaring flags and args for flarc.
+//
+// Run it with go vet:
+//
+//	go vet -vettool=$(which flarcvet) ./...
+package main
+
+import (
+	"github.com/youta-t/flarc/flarcvet"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(flarcvet.Analyzer)
+}
@@ -0,0 +1,382 @@
+// Package flarcvet provides an analyzer checking structs declaring flags and args for flarc.
+//
+// The analyzer finds type arguments of flarc.NewCommand, flarc.NewCommandGroup, flarc.NewCommandWithArgs,
+// parser.New and parser.NewTypedArgs, and reports mistakes in their fields,
+// which are found only at runtime otherwise:
+// unexported fields, fields of unsupported types, malformed tags, misspelled tag keys,
+// invalid tag values and conflicting flag names.
+//
+// It can be run with go vet:
+//
+//	go install github.com/youta-t/flarc/flarcvet/cmd/flarcvet@latest
+//	go vet -vettool=$(which flarcvet) ./...
+package flarcvet
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/youta-t/flarc/params/fieldtype"
+	"github.com/youta-t/flarc/utils"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Analyzer reports mistakes in structs declaring flags and args.
+var Analyzer = &analysis.Analyzer{
+	Name:     "flarcvet",
+	Doc:      "check structs declaring flags and args for flarc",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// kind is what a struct declares.
+type kind int
+
+const (
+	kindFlags kind = iota
+	kindArgs
+)
+
+func (k kind) String() string {
+	if k == kindArgs {
+		return "args"
+	}
+	return "flags"
+}
+
+// item returns what each field of the struct declares.
+func (k kind) item() string {
+	if k == kindArgs {
+		return "an arg"
+	}
+	return "a flag"
+}
+
+// tagKeys are tag keys recognized for each kind.
+var tagKeys = map[kind][]string{
+	kindFlags: {"flag", "alias", "help", "metavar", "negatable", "env", "required", "requires", "choices"},
+	kindArgs:  {"arg", "required", "help", "choices"},
+}
+
+// targets maps package paths and function names to kinds of their type arguments, in order.
+var targets = map[string]map[string][]kind{
+	"github.com/youta-t/flarc": {
+		"NewCommand":         {kindFlags},
+		"NewCommandGroup":    {kindFlags},
+		"NewCommandWithArgs": {kindFlags, kindArgs},
+	},
+	"github.com/youta-t/flarc/parser": {
+		"New":          {kindFlags},
+		"NewTypedArgs": {kindArgs},
+	},
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	c := &checker{pass: pass, reported: map[token.Pos]map[string]struct{}{}}
+
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if !ok || fn.Pkg() == nil {
+			return
+		}
+		kinds, ok := targets[fn.Pkg().Path()][fn.Name()]
+		if !ok {
+			return
+		}
+		id := funcIdent(call.Fun)
+		if id == nil {
+			return
+		}
+		inst, ok := pass.TypesInfo.Instances[id]
+		if !ok {
+			return
+		}
+		for i, k := range kinds {
+			if inst.TypeArgs.Len() <= i {
+				break
+			}
+			targ := inst.TypeArgs.At(i)
+			if _, ok := targ.(*types.TypeParam); ok {
+				// generic wrappers are checked where they are instantiated.
+				continue
+			}
+			c.check(call, targ, k)
+		}
+	})
+	return nil, nil
+}
+
+// funcIdent returns the identifier of the function called by fun.
+func funcIdent(fun ast.Expr) *ast.Ident {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f
+	case *ast.SelectorExpr:
+		return f.Sel
+	case *ast.IndexExpr:
+		return funcIdent(f.X)
+	case *ast.IndexListExpr:
+		return funcIdent(f.X)
+	case *ast.ParenExpr:
+		return funcIdent(f.X)
+	}
+	return nil
+}
+
+type checker struct {
+	pass *analysis.Pass
+
+	// reported are messages reported for each position.
+	//
+	// A struct used in many calls is reported once.
+	reported map[token.Pos]map[string]struct{}
+}
+
+func (c *checker) reportf(pos token.Pos, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if _, ok := c.reported[pos][msg]; ok {
+		return
+	}
+	if c.reported[pos] == nil {
+		c.reported[pos] = map[string]struct{}{}
+	}
+	c.reported[pos][msg] = struct{}{}
+	c.pass.Report(analysis.Diagnostic{Pos: pos, Message: msg})
+}
+
+// inPackage reports pos is in files of the package being analyzed.
+func (c *checker) inPackage(pos token.Pos) bool {
+	for _, f := range c.pass.Files {
+		if f.Pos() <= pos && pos <= f.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// check reports mistakes in the struct t declaring k, passed to call.
+func (c *checker) check(call *ast.CallExpr, t types.Type, k kind) {
+	qualifier := types.RelativeTo(c.pass.Pkg)
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		c.reportf(call.Pos(), "%s should be declared with struct, but %s", k, types.TypeString(t, qualifier))
+		return
+	}
+
+	names := map[string]string{} // flag names to declarations of them, for messages
+	requires := map[*types.Var][]string{}
+	fieldPos := map[*types.Var]token.Pos{}
+	for i := 0; i < st.NumFields(); i += 1 {
+		fld := st.Field(i)
+
+		// mistakes in other packages are reported at the call.
+		pos := call.Pos()
+		where := fmt.Sprintf("field %s of %s", fld.Name(), types.TypeString(t, qualifier))
+		if c.inPackage(fld.Pos()) {
+			pos = fld.Pos()
+			where = "field " + fld.Name()
+		}
+		fieldPos[fld] = pos
+
+		tags, ok := parseTag(st.Tag(i))
+		if !ok {
+			c.reportf(pos, "%s: malformed struct tag: %s", where, st.Tag(i))
+			continue
+		}
+		if k == kindArgs && tags["arg"] == "-" {
+			continue
+		}
+
+		if !fld.Exported() {
+			c.reportf(pos, "%s: unexported field cannot be %s", where, k.item())
+			continue
+		}
+		if !fieldtype.Supported(fld.Type()) {
+			c.reportf(pos, "%s: unsupported type %s", where, types.TypeString(fld.Type(), qualifier))
+		}
+
+		for key := range tags {
+			if !contains(tagKeys[k], key) {
+				// tags for other libraries are allowed, but misspelled ones are not.
+				if dym := utils.DidYouMean(utils.Suggest(key, tagKeys[k])); dym != "" {
+					c.reportf(pos, "%s: unknown tag key %s (%s)", where, key, dym)
+				}
+			}
+		}
+		for _, key := range []string{"required", "negatable"} {
+			if v, ok := tags[key]; ok && contains(tagKeys[k], key) {
+				if _, err := strconv.ParseBool(v); err != nil {
+					c.reportf(pos, "%s: tag %s should be bool, but %q", where, key, v)
+				}
+			}
+		}
+		if v, ok := tags["choices"]; ok {
+			if contains(strings.Split(v, ","), "") {
+				c.reportf(pos, "%s: empty choice in tag choices: %q", where, v)
+			}
+		}
+
+		if k == kindArgs {
+			name := tags["arg"]
+			if name == "" {
+				name = strings.ToUpper(strings.ReplaceAll(utils.ToKebab(fld.Name()), "-", "_"))
+			}
+			if other, ok := names[name]; ok {
+				c.reportf(pos, "%s: arg name %s conflicts with %s", where, name, other)
+				continue
+			}
+			names[name] = "field " + fld.Name()
+			continue
+		}
+
+		fieldNames := []string{utils.ToKebab(fld.Name())}
+		if v, ok := tags["flag"]; ok && v != "" {
+			fieldNames[0] = v
+		}
+		if v, ok := tags["alias"]; ok {
+			fieldNames = append(fieldNames, strings.Split(v, ",")...)
+		}
+		declared := map[string]string{}
+		for i, n := range fieldNames {
+			if n == "" {
+				c.reportf(pos, "%s: empty alias in tag alias: %q", where, tags["alias"])
+				continue
+			}
+			if msg := invalidName(n); msg != "" {
+				if 0 < i {
+					msg = "alias " + msg
+				}
+				c.reportf(pos, "%s: %s", where, msg)
+				continue
+			}
+			declared[n] = "field " + fld.Name()
+		}
+		if negatable(fld.Type(), tags) {
+			for _, n := range fieldNames {
+				if 1 < len(n) && invalidName(n) == "" {
+					declared["no-"+n] = "negation of field " + fld.Name()
+				}
+			}
+		}
+		for _, n := range fieldNames {
+			for _, m := range []string{n, "no-" + n} {
+				decl, ok := declared[m]
+				if !ok {
+					continue
+				}
+				delete(declared, m) // aliases may repeat the name.
+				if other, ok := names[m]; ok {
+					c.reportf(pos, "%s: flag name %s is declared by %s and %s", where, hyphen(m), other, decl)
+					continue
+				}
+				names[m] = decl
+			}
+		}
+
+		if v, ok := tags["requires"]; ok {
+			requires[fld] = strings.Split(v, ",")
+		}
+	}
+
+	for fld, rs := range requires {
+		for _, r := range rs {
+			if _, ok := names[strings.TrimLeft(r, "-")]; !ok {
+				c.reportf(
+					fieldPos[fld], "field %s: tag requires has unknown flag: %q",
+					fld.Name(), r,
+				)
+			}
+		}
+	}
+}
+
+// negatable reports flags typed t with tags can be negated with "--no-" prefix.
+func negatable(t types.Type, tags map[string]string) bool {
+	if v, ok := tags["negatable"]; ok {
+		if b, err := strconv.ParseBool(v); err == nil && !b {
+			return false
+		}
+	}
+	return types.Identical(fieldtype.Elem(t), types.Typ[types.Bool])
+}
+
+// invalidName returns why non-empty name cannot be a flag name. If name is valid, it returns "".
+func invalidName(name string) string {
+	switch {
+	case strings.HasPrefix(name, "-"):
+		return fmt.Sprintf("should not start with \"-\": %s", name)
+	case strings.ContainsAny(name, "= \t\n\""):
+		return fmt.Sprintf("should not contain \"=\", quotes nor spaces: %q", name)
+	}
+	return ""
+}
+
+func hyphen(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTag parses struct tag in the conventional format, as reflect.StructTag.
+//
+// Values of duplicated keys are the first ones.
+// If tag is not in the format, it returns false.
+func parseTag(tag string) (map[string]string, bool) {
+	tags := map[string]string{}
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			break
+		}
+
+		i := 0
+		for i < len(tag) && ' ' < tag[i] && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i += 1
+		}
+		if i == 0 || len(tag) <= i+1 || tag[i] != ':' || tag[i+1] != '"' {
+			return nil, false
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i += 1
+			}
+			i += 1
+		}
+		if len(tag) <= i {
+			return nil, false
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			return nil, false
+		}
+		tag = tag[i+1:]
+
+		if _, ok := tags[key]; !ok {
+			tags[key] = value
+		}
+	}
+	return tags, true
+}
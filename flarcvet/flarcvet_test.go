@@ -0,0 +1,300 @@
+package flarcvet_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"testing"
+
+	"github.com/youta-t/flarc/flarcvet"
+	"github.com/youta-t/its"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// stubs are minimal declarations of packages which the analyzer looks for.
+var stubs = map[string]string{
+	"github.com/youta-t/flarc": `
+package flarc
+
+type Command interface{}
+type Args []struct{}
+type Option func()
+
+func NewCommand[T any](d string, flagdef T, args Args, task any, option ...Option) (Command, error) {
+	return nil, nil
+}
+
+func NewCommandGroup[T any](d string, flagdef T, option ...Option) (Command, error) {
+	return nil, nil
+}
+
+func NewCommandWithArgs[T any, A any](d string, flagdef T, argdef A, task any, option ...Option) (Command, error) {
+	return nil, nil
+}
+`,
+	"github.com/youta-t/flarc/parser": `
+package parser
+
+func New[T any](flagdef *T, args []any) (any, error) {
+	return nil, nil
+}
+
+func NewTypedArgs[A any](argdef *A) (any, error) {
+	return nil, nil
+}
+`,
+}
+
+type stubImporter struct {
+	fset *token.FileSet
+	pkgs map[string]*types.Package
+	std  types.Importer
+}
+
+func (si *stubImporter) Import(path string) (*types.Package, error) {
+	if pkg, ok := si.pkgs[path]; ok {
+		return pkg, nil
+	}
+	src, ok := stubs[path]
+	if !ok {
+		return si.std.Import(path)
+	}
+	f, err := parser.ParseFile(si.fset, path+"/stub.go", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	conf := types.Config{Importer: si}
+	pkg, err := conf.Check(path, si.fset, []*ast.File{f}, nil)
+	if err != nil {
+		return nil, err
+	}
+	si.pkgs[path] = pkg
+	return pkg, nil
+}
+
+// analyze runs flarcvet.Analyzer for src, and returns diagnostics as "LINE: MESSAGE", in order.
+func analyze(t *testing.T, src string) []string {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "a.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []*ast.File{f}
+
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+		Instances:  map[*ast.Ident]types.Instance{},
+	}
+	conf := types.Config{
+		Importer: &stubImporter{fset: fset, pkgs: map[string]*types.Package{}, std: importer.Default()},
+	}
+	pkg, err := conf.Check("a", fset, files, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	pass := &analysis.Pass{
+		Analyzer:  flarcvet.Analyzer,
+		Fset:      fset,
+		Files:     files,
+		Pkg:       pkg,
+		TypesInfo: info,
+		ResultOf: map[*analysis.Analyzer]any{
+			inspect.Analyzer: inspector.New(files),
+		},
+		Report: func(d analysis.Diagnostic) {
+			got = append(got, fmt.Sprintf("%d: %s", fset.Position(d.Pos).Line, d.Message))
+		},
+	}
+	if _, err := flarcvet.Analyzer.Run(pass); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestAnalyzer(t *testing.T) {
+	type When struct {
+		src string
+	}
+	type Then struct {
+		diagnostics []string
+	}
+
+	theory := func(when When, then Then) func(*testing.T) {
+		return func(t *testing.T) {
+			got := analyze(t, when.src)
+
+			matchers := []its.Matcher[string]{}
+			for _, d := range then.diagnostics {
+				matchers = append(matchers, its.EqEq(d))
+			}
+			its.Slice(matchers...).Match(got).OrError(t)
+		}
+	}
+
+	t.Run("supported fields", theory(
+		When{
+			src: `package a
+
+import (
+	"time"
+
+	"github.com/youta-t/flarc"
+	"github.com/youta-t/flarc/parser"
+)
+
+type Text struct{}
+
+func (*Text) UnmarshalText([]byte) error { return nil }
+
+type Value struct{}
+
+func (*Value) String() string     { return "" }
+func (*Value) Set(string) error   { return nil }
+
+type Name string
+
+type Flag struct {
+	Str      string   ` + "`alias:\"s\" help:\"a string\" metavar:\"STR\" env:\"STR\" required:\"true\"`" + `
+	Ints     []int    ` + "`requires:\"str,--verbose\"`" + `
+	Verbose  *bool    ` + "`negatable:\"false\" json:\"verbose\"`" + `
+	Timeout  time.Duration
+	At       time.Time
+	Text     Text
+	Value    *Value
+	Name     Name ` + "`choices:\"a,b\"`" + `
+	Callback func(string) error
+}
+
+type Arg struct {
+	Source []string ` + "`arg:\"SRC\" required:\"true\"`" + `
+	Dest   string
+	skip   int ` + "`arg:\"-\"`" + `
+}
+
+func main() {
+	flarc.NewCommandWithArgs("", Flag{}, Arg{}, nil)
+	parser.New(&Flag{}, nil)
+	parser.NewTypedArgs(&Arg{})
+}
+`,
+		},
+		Then{diagnostics: []string{}},
+	))
+
+	t.Run("mistakes", theory(
+		When{
+			src: `package a
+
+import "github.com/youta-t/flarc"
+
+type Flag struct {
+	Force   bool   ` + "`alias:\"f,\"`" + `
+	Verbose bool   ` + "`halp:\"be verbose\"`" + `
+	Debug   bool   ` + "`required:\"yes\"`" + `
+	Format  string ` + "`alias:\"f\" requires:\"outptu\"`" + `
+	Events  chan string
+	Kind    string ` + "`choices:\"a,,b\"`" + `
+	Bad     string ` + "`alias:\"-b\"`" + `
+	Broken  string ` + "`alias:f`" + `
+	count   int
+}
+
+func main() {
+	flarc.NewCommand("", Flag{}, nil, nil)
+	flarc.NewCommandGroup("", Flag{})
+}
+`,
+		},
+		Then{diagnostics: []string{
+			"10: field Events: unsupported type chan string",
+			"11: field Kind: empty choice in tag choices: \"a,,b\"",
+			"12: field Bad: alias should not start with \"-\": -b",
+			"13: field Broken: malformed struct tag: alias:f",
+			"14: field count: unexported field cannot be a flag",
+			"6: field Force: empty alias in tag alias: \"f,\"",
+			"7: field Verbose: unknown tag key halp (did you mean `help`?)",
+			"8: field Debug: tag required should be bool, but \"yes\"",
+			"9: field Format: flag name -f is declared by field Force and field Format",
+			"9: field Format: tag requires has unknown flag: \"outptu\"",
+		}},
+	))
+
+	t.Run("negations", theory(
+		When{
+			src: `package a
+
+import "github.com/youta-t/flarc"
+
+type Flag struct {
+	Color   bool
+	NoColor bool
+	Cache   bool ` + "`negatable:\"false\"`" + `
+	NoCache bool
+}
+
+func main() {
+	flarc.NewCommand("", Flag{}, nil, nil)
+}
+`,
+		},
+		Then{diagnostics: []string{
+			"7: field NoColor: flag name --no-color is declared by negation of field Color and field NoColor",
+		}},
+	))
+
+	t.Run("args", theory(
+		When{
+			src: `package a
+
+import "github.com/youta-t/flarc"
+
+type Flag struct{}
+
+type Arg struct {
+	Source string
+	Src    string ` + "`arg:\"SOURCE\"`" + `
+	dest   string
+	Helps  string ` + "`halp:\"help\" alias:\"h\"`" + `
+}
+
+func main() {
+	flarc.NewCommandWithArgs("", Flag{}, Arg{}, nil)
+}
+`,
+		},
+		Then{diagnostics: []string{
+			"10: field dest: unexported field cannot be an arg",
+			"11: field Helps: unknown tag key halp (did you mean `help`?)",
+			"9: field Src: arg name SOURCE conflicts with field Source",
+		}},
+	))
+
+	t.Run("not struct", theory(
+		When{
+			src: `package a
+
+import "github.com/youta-t/flarc"
+
+func main() {
+	flarc.NewCommand("", map[string]string{}, nil, nil)
+}
+`,
+		},
+		Then{diagnostics: []string{
+			"6: flags should be declared with struct, but map[string]string",
+		}},
+	))
+}
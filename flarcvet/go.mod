@@ -0,0 +1,11 @@
+module github.com/youta-t/flarc/flarcvet
+
+go 1.21
+
+require (
+	github.com/youta-t/flarc v0.0.0-20261017091156-cc41837ecd70
+	github.com/youta-t/its v0.4.0
+	golang.org/x/tools v0.24.0
+)
+
+require golang.org/x/mod v0.20.0 // indirect
@@ -1,10 +1,8 @@
 package help
 
 import (
-	"cmp"
 	"fmt"
 	"io"
-	"slices"
 	"strings"
 	"text/template"
 
@@ -45,6 +43,34 @@ func WithDescription(tpl *template.Template) Option {
 	}
 }
 
+// WithConstraints sets descriptions of constraints among flags.
+func WithConstraints(cs []string) Option {
+	return func(h *help) *help {
+		h.constraints = append(h.constraints, cs...)
+		return h
+	}
+}
+
+// WithWidth sets the width which help texts are wrapped in.
+//
+// By default, it is DefaultWidth. If width is 0 or less, help texts are not wrapped.
+func WithWidth(width int) Option {
+	return func(h *help) *help {
+		h.width = width
+		return h
+	}
+}
+
+// WithTemplate replaces the template of Write.
+//
+// The template is executed with Model. Templates using Funcs should be parsed with them.
+func WithTemplate(tpl *template.Template) Option {
+	return func(h *help) *help {
+		h.template = tpl
+		return h
+	}
+}
+
 func New(
 	fullname string, shortDescription string,
 	options ...Option,
@@ -52,6 +78,7 @@ func New(
 	h := &help{
 		fullname:         fullname,
 		shortDescription: shortDescription,
+		width:            DefaultWidth,
 		flags:            new(paramSection[params.Flag]),
 		args:             new(paramSection[params.Arg]),
 
@@ -71,6 +98,31 @@ type Help interface {
 	Write(w io.Writer) error
 
 	AppendFlags(...params.Flag)
+
+	// AppendConstraints adds descriptions of constraints among flags.
+	AppendConstraints(...string)
+
+	// WriteMan writes the help as a man page (section 1) in roff.
+	WriteMan(w io.Writer) error
+
+	// WriteMarkdown writes the help as a Markdown document.
+	//
+	// Subcommands and related commands are linked as "PageName(fullname).md".
+	WriteMarkdown(w io.Writer) error
+
+	// AppendSeeAlso adds full names of related commands, for man pages and Markdown documents.
+	AppendSeeAlso(...string)
+
+	// SetWidth sets the width which Write wraps help texts in.
+	//
+	// If width is 0 or less, help texts are not wrapped.
+	SetWidth(width int)
+
+	// SetTemplate replaces the template of Write. See WithTemplate.
+	SetTemplate(tpl *template.Template)
+
+	// Model returns the data which the template of Write is executed with.
+	Model() (Model, error)
 }
 
 type help struct {
@@ -80,67 +132,53 @@ type help struct {
 	flags            *paramSection[params.Flag]
 	args             *paramSection[params.Arg]
 	subcommands      *subcommandSection
+	constraints      []string
+	seeAlso          []string
+	width            int
+	template         *template.Template
 }
 
 func (h *help) AppendFlags(flgs ...params.Flag) {
 	h.flags.Append(flgs...)
 }
 
-func (h *help) Write(w io.Writer) error {
-	fmt.Fprint(w, h.fullname)
-	if h.shortDescription != "" {
-		fmt.Fprint(w, " -- ", h.shortDescription)
-	}
-	fmt.Fprintln(w)
-
-	fmt.Fprintln(w)
-	fmt.Fprintln(w, "Usage:")
-	fmt.Fprintln(w)
-
-	fmt.Fprintf(w, "    %s", h.fullname)
-	h.flags.WriteUsage(w)
-	h.args.WriteUsage(w)
-	fmt.Fprintln(w)
-
-	if h.description != nil {
-		fmt.Fprintln(w)
-		fmt.Fprintln(w, "Description:")
-		fmt.Fprintln(w)
-
-		sb := new(strings.Builder)
-		err := h.description.Execute(sb, struct{ Command string }{Command: h.fullname})
-		if err != nil {
-			return err
-		}
+func (h *help) AppendConstraints(cs ...string) {
+	h.constraints = append(h.constraints, cs...)
+}
 
-		for _, line := range strings.Split(sb.String(), "\n") {
-			fmt.Fprint(w, "    ")
-			fmt.Fprintln(w, line)
-		}
-	}
+func (h *help) AppendSeeAlso(fullnames ...string) {
+	h.seeAlso = append(h.seeAlso, fullnames...)
+}
 
-	if 0 < h.flags.Len() {
-		fmt.Fprintln(w)
-		fmt.Fprintln(w, "Flags:")
-		fmt.Fprintln(w)
-		h.flags.WriteHelp(w)
-	}
+func (h *help) SetWidth(width int) {
+	h.width = width
+}
+
+func (h *help) SetTemplate(tpl *template.Template) {
+	h.template = tpl
+}
 
-	if 0 < h.args.Len() {
-		fmt.Fprintln(w)
-		fmt.Fprintln(w, "Args:")
-		fmt.Fprintln(w)
-		h.args.WriteHelp(w)
+func (h *help) Write(w io.Writer) error {
+	m, err := h.Model()
+	if err != nil {
+		return err
 	}
 
-	if 0 < h.subcommands.Len() {
-		fmt.Fprintln(w)
-		fmt.Fprintln(w, "Subcommands:")
-		fmt.Fprintln(w)
-		h.subcommands.WriteHelp(w)
+	tpl := h.template
+	if tpl == nil {
+		tpl = defaultTemplate
+	}
+	sb := new(strings.Builder)
+	if err := tpl.Execute(sb, m); err != nil {
+		return err
 	}
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
 
-	return nil
+// PageName returns the name of document pages for the command, like "my-app-sub" for "my-app sub".
+func PageName(fullname string) string {
+	return strings.Join(strings.Fields(fullname), "-")
 }
 
 type paramSection[T interface {
@@ -169,41 +207,56 @@ func (s *paramSection[T]) WriteUsage(w io.Writer) {
 	}
 }
 
-func (s *paramSection[T]) WriteHelp(w io.Writer) {
-	for _, c := range s.content {
-		var names []string
-		if a, ok := any(c).(interface{ Alias() []string }); !ok {
-			names = []string{c.Name()}
-		} else {
-			alias := a.Alias()
-			names = make([]string, 1+len(alias))
-			names[0] = c.Name()
-			copy(names[1:], alias)
+// paramNames returns the name and aliases of c.
+//
+// Negatable names are shown like "--[no-]name".
+func paramNames[T interface{ Name() string }](c T) []string {
+	var names []string
+	if a, ok := any(c).(interface{ Alias() []string }); !ok {
+		names = []string{c.Name()}
+	} else {
+		alias := a.Alias()
+		names = make([]string, 1+len(alias))
+		names[0] = c.Name()
+		copy(names[1:], alias)
+	}
+	if n, ok := any(c).(interface{ Negatable() bool }); ok && n.Negatable() {
+		for i := range names {
+			if l, ok := strings.CutPrefix(names[i], "--"); ok {
+				names[i] = "--[no-]" + l
+			}
 		}
+	}
+	return names
+}
 
-		helpText := c.Help()
-		n := strings.Join(names, ", ")
-
+// annotatedHelp returns the help message of c, with annotations like "[required]".
+func annotatedHelp[T interface{ Help() string }](c T) string {
+	helpText := c.Help()
+	if f, ok := any(c).(params.Flag); ok && f.Required() {
 		if helpText == "" {
-			fmt.Fprintf(w, "    %s", n)
-		} else if len(n) <= 12 {
-			s := fmt.Sprintf("    %-12s", n)
-			fmt.Fprint(w, s)
+			helpText = "[required]"
 		} else {
-			fmt.Fprintf(w, "    %s\n            ", n)
+			helpText += " [required]"
 		}
-
-		help := strings.Split(helpText, "\n")
-		if 0 < len(help) {
-			fmt.Fprint(w, help[0])
-			help = help[1:]
+	}
+	if f, ok := any(c).(params.Flag); ok && 0 < len(f.Choices()) {
+		choices := "[choices: " + strings.Join(f.Choices(), ", ") + "]"
+		if helpText == "" {
+			helpText = choices
+		} else {
+			helpText += " " + choices
 		}
-		for _, l := range help {
-			fmt.Fprint(w, "           ", l)
-			fmt.Fprintln(w)
+	}
+	if e, ok := any(c).(interface{ Env() string }); ok && e.Env() != "" {
+		env := "[env: " + e.Env() + "]"
+		if helpText == "" {
+			helpText = env
+		} else {
+			helpText += " " + env
 		}
-		fmt.Fprintln(w)
 	}
+	return helpText
 }
 
 type subcommandSection struct {
@@ -219,46 +272,3 @@ func (scs *subcommandSection) Append(cmds map[string]CommandDescriptor) {
 		scs.cmds[name] = cmd
 	}
 }
-
-func (scs *subcommandSection) WriteHelp(w io.Writer) {
-
-	type subcommand struct {
-		Name    string
-		Command CommandDescriptor
-	}
-
-	subcommands := make([]subcommand, len(scs.cmds))
-	{
-		i := 0
-		for name, cmd := range scs.cmds {
-			subcommands[i] = subcommand{Name: name, Command: cmd}
-			i += 1
-		}
-
-		slices.SortFunc(subcommands, func(a, b subcommand) int {
-			return cmp.Compare(a.Name, b.Name)
-		})
-	}
-
-	for _, c := range subcommands {
-		sd := c.Command.ShortDescription()
-
-		if sd == "" {
-			fmt.Fprintf(w, "    %s", c.Name)
-		} else if name := c.Name; len(name) <= 12 {
-			fmt.Fprintf(w, "    %-12s", c.Name)
-		} else {
-			fmt.Fprintf(w, "    %s\n                ", c.Name)
-		}
-		help := strings.Split(sd, "\n")
-		if 0 < len(help) {
-			fmt.Fprintln(w, help[0])
-			help = help[1:]
-		}
-		for _, l := range help {
-			fmt.Fprintln(w, "                ", l)
-		}
-	}
-
-	fmt.Fprintln(w)
-}
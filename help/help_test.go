@@ -0,0 +1,401 @@
+package help_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/youta-t/flarc/help"
+	"github.com/youta-t/flarc/params"
+	"github.com/youta-t/its"
+)
+
+type shortDescription string
+
+func (s shortDescription) ShortDescription() string {
+	return string(s)
+}
+
+func flagsOf(t *testing.T, flagdef any) []params.Flag {
+	t.Helper()
+	rv := reflect.ValueOf(flagdef).Elem()
+	flgs := []params.Flag{}
+	for i := 0; i < rv.NumField(); i++ {
+		f, err := params.NewFlag(rv.Type().Field(i), rv.Field(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		flgs = append(flgs, f)
+	}
+	return flgs
+}
+
+// newHelp returns a help having every kind of sections.
+func newHelp(t *testing.T, options ...help.Option) help.Help {
+	t.Helper()
+	type Flag struct {
+		Region   string   `alias:"r" help:"region where resources are deployed in. it is used when no region is given in config files." env:"APP_REGION"`
+		Format   string   `alias:"f" choices:"json,yaml" help:"output format"`
+		Tag      []string `help:"tags attached to resources"`
+		Limit    int      `metavar:"N" help:"the maximum number of resources to be listed" required:"true"`
+		Dry      bool     `help:"dry run"`
+		Parallel int
+	}
+	flagdef := &Flag{Region: "us-east-1", Tag: []string{"team", "app"}, Limit: 10}
+
+	desc := template.Must(template.New("").Parse(
+		`List resources with {{ .Command }}. Resources are listed from every region unless --region is given, and listed in the order they are created.
+
+Example:
+    {{ .Command }} --region ap-northeast-1 --format json --limit 100 --tag production src`,
+	))
+
+	h := help.New(
+		"my-app list", "list resources",
+		append([]help.Option{
+			help.WithDescription(desc),
+			help.WitArgs([]params.Arg{
+				params.ArgDef{Name: "SOURCE", Required: true, Help: "source of resources"}.Freeze(),
+				params.ArgDef{Name: "EXTRA", Repeatable: true}.Freeze(),
+			}),
+			help.WithSubcommands(map[string]help.CommandDescriptor{
+				"versions":  shortDescription("list versions of resources"),
+				"instances": shortDescription("list instances of resources, including ones in stopped or terminated states"),
+			}),
+			help.WithConstraints([]string{"--format, --dry are mutually exclusive"}),
+		}, options...)...,
+	)
+	h.AppendFlags(flagsOf(t, flagdef)...)
+	h.AppendSeeAlso("my-app", "my-app show")
+	return h
+}
+
+func TestHelp_Write(t *testing.T) {
+	theory := func(width int, want string) func(*testing.T) {
+		return func(t *testing.T) {
+			sb := new(strings.Builder)
+			if err := newHelp(t, help.WithWidth(width)).Write(sb); err != nil {
+				t.Fatal(err)
+			}
+			its.Text(want).Match(sb.String()).OrError(t)
+		}
+	}
+
+	t.Run("wrapped in 80", theory(
+		80,
+		"my-app list -- list resources\n"+
+			"\n"+
+			"Usage:\n"+
+			"\n"+
+			"    my-app list --region=us-east-1 --format=json|yaml --tag --limit=N --[no-]dry=false --parallel=0 SOURCE [EXTRA[, ...]]\n"+
+			"\n"+
+			"Description:\n"+
+			"\n"+
+			"    List resources with my-app list. Resources are listed from every region\n"+
+			"    unless --region is given, and listed in the order they are created.\n"+
+			"    \n"+
+			"    Example:\n"+
+			"        my-app list --region ap-northeast-1 --format json --limit 100 --tag\n"+
+			"        production src\n"+
+			"\n"+
+			"Flags:\n"+
+			"\n"+
+			"    --region, -r  region where resources are deployed in. it is used when no\n"+
+			"                  region is given in config files. [env: APP_REGION]\n"+
+			"    --format, -f  output format [choices: json, yaml]\n"+
+			"    --tag         tags attached to resources\n"+
+			"    --limit       the maximum number of resources to be listed [required]\n"+
+			"    --[no-]dry    dry run\n"+
+			"    --parallel\n"+
+			"\n"+
+			"Constraints:\n"+
+			"\n"+
+			"    --format, --dry are mutually exclusive\n"+
+			"\n"+
+			"Args:\n"+
+			"\n"+
+			"    SOURCE      source of resources\n"+
+			"    EXTRA\n"+
+			"\n"+
+			"Subcommands:\n"+
+			"\n"+
+			"    instances   list instances of resources, including ones in stopped or\n"+
+			"                terminated states\n"+
+			"    versions    list versions of resources\n"+
+			"\n",
+	))
+
+	t.Run("wrapped in narrow width", theory(
+		40,
+		"my-app list -- list resources\n"+
+			"\n"+
+			"Usage:\n"+
+			"\n"+
+			"    my-app list --region=us-east-1 --format=json|yaml --tag --limit=N --[no-]dry=false --parallel=0 SOURCE [EXTRA[, ...]]\n"+
+			"\n"+
+			"Description:\n"+
+			"\n"+
+			"    List resources with my-app list.\n"+
+			"    Resources are listed from every\n"+
+			"    region unless --region is given, and\n"+
+			"    listed in the order they are\n"+
+			"    created.\n"+
+			"    \n"+
+			"    Example:\n"+
+			"        my-app list --region\n"+
+			"        ap-northeast-1 --format json\n"+
+			"        --limit 100 --tag production src\n"+
+			"\n"+
+			"Flags:\n"+
+			"\n"+
+			"    --region, -r  region where resources\n"+
+			"                  are deployed in. it is\n"+
+			"                  used when no region is\n"+
+			"                  given in config files.\n"+
+			"                  [env: APP_REGION]\n"+
+			"    --format, -f  output format\n"+
+			"                  [choices: json, yaml]\n"+
+			"    --tag         tags attached to\n"+
+			"                  resources\n"+
+			"    --limit       the maximum number of\n"+
+			"                  resources to be listed\n"+
+			"                  [required]\n"+
+			"    --[no-]dry    dry run\n"+
+			"    --parallel\n"+
+			"\n"+
+			"Constraints:\n"+
+			"\n"+
+			"    --format, --dry are mutually\n"+
+			"    exclusive\n"+
+			"\n"+
+			"Args:\n"+
+			"\n"+
+			"    SOURCE      source of resources\n"+
+			"    EXTRA\n"+
+			"\n"+
+			"Subcommands:\n"+
+			"\n"+
+			"    instances   list instances of\n"+
+			"                resources, including\n"+
+			"                ones in stopped or\n"+
+			"                terminated states\n"+
+			"    versions    list versions of\n"+
+			"                resources\n"+
+			"\n",
+	))
+
+	t.Run("not wrapped", theory(
+		0,
+		"my-app list -- list resources\n"+
+			"\n"+
+			"Usage:\n"+
+			"\n"+
+			"    my-app list --region=us-east-1 --format=json|yaml --tag --limit=N --[no-]dry=false --parallel=0 SOURCE [EXTRA[, ...]]\n"+
+			"\n"+
+			"Description:\n"+
+			"\n"+
+			"    List resources with my-app list. Resources are listed from every region unless --region is given, and listed in the order they are created.\n"+
+			"    \n"+
+			"    Example:\n"+
+			"        my-app list --region ap-northeast-1 --format json --limit 100 --tag production src\n"+
+			"\n"+
+			"Flags:\n"+
+			"\n"+
+			"    --region, -r  region where resources are deployed in. it is used when no region is given in config files. [env: APP_REGION]\n"+
+			"    --format, -f  output format [choices: json, yaml]\n"+
+			"    --tag         tags attached to resources\n"+
+			"    --limit       the maximum number of resources to be listed [required]\n"+
+			"    --[no-]dry    dry run\n"+
+			"    --parallel\n"+
+			"\n"+
+			"Constraints:\n"+
+			"\n"+
+			"    --format, --dry are mutually exclusive\n"+
+			"\n"+
+			"Args:\n"+
+			"\n"+
+			"    SOURCE      source of resources\n"+
+			"    EXTRA\n"+
+			"\n"+
+			"Subcommands:\n"+
+			"\n"+
+			"    instances   list instances of resources, including ones in stopped or terminated states\n"+
+			"    versions    list versions of resources\n"+
+			"\n",
+	))
+
+	t.Run("too long names are placed on their own lines", func(t *testing.T) {
+		type Flag struct {
+			IncludeTerminatedInstances bool   `help:"list also instances which are terminated"`
+			Q                          string `help:"query"`
+			V                          bool
+		}
+		h := help.New("app", "", help.WithWidth(50))
+		h.AppendFlags(flagsOf(t, &Flag{})...)
+
+		sb := new(strings.Builder)
+		if err := h.Write(sb); err != nil {
+			t.Fatal(err)
+		}
+		its.Text(
+			"app\n" +
+				"\n" +
+				"Usage:\n" +
+				"\n" +
+				"    app --[no-]include-terminated-instances=false -q -v=false\n" +
+				"\n" +
+				"Flags:\n" +
+				"\n" +
+				"    --[no-]include-terminated-instances\n" +
+				"                              list also instances\n" +
+				"                              which are terminated\n" +
+				"    -q                        query\n" +
+				"    -v\n",
+		).Match(sb.String()).OrError(t)
+	})
+}
+
+func TestHelp_Model(t *testing.T) {
+	m, err := newHelp(t).Model()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type flagSpec struct {
+		Name    string
+		Aliases string
+		Default string
+		Env     string
+	}
+	specs := make([]flagSpec, len(m.Flags))
+	for i, f := range m.Flags {
+		specs[i] = flagSpec{Name: f.Name, Aliases: strings.Join(f.Aliases, ","), Default: f.Default, Env: f.Env}
+	}
+	its.Slice(
+		its.EqEq(flagSpec{Name: "--region", Aliases: "-r", Default: "us-east-1", Env: "APP_REGION"}),
+		its.EqEq(flagSpec{Name: "--format", Aliases: "-f", Default: ""}),
+		its.EqEq(flagSpec{Name: "--tag", Default: "team,app"}),
+		its.EqEq(flagSpec{Name: "--limit", Default: "10"}),
+		its.EqEq(flagSpec{Name: "--dry", Default: "false"}),
+		its.EqEq(flagSpec{Name: "--parallel", Default: "0"}),
+	).Match(specs).OrError(t)
+
+	its.Slice(
+		its.EqEq("my-app"),
+		its.EqEq("my-app show"),
+	).Match(m.SeeAlso).OrError(t)
+}
+
+func TestHelp_WriteMan(t *testing.T) {
+	sb := new(strings.Builder)
+	if err := newHelp(t).WriteMan(sb); err != nil {
+		t.Fatal(err)
+	}
+	its.Text(`.TH "MY\-APP\-LIST" 1
+.SH NAME
+my\-app\-list \- list resources
+.SH SYNOPSIS
+\fBmy\-app list\fR \-\-region=us\-east\-1 \-\-format=json|yaml \-\-tag \-\-limit=N \-\-[no\-]dry=false \-\-parallel=0 SOURCE [EXTRA[, ...]]
+.SH DESCRIPTION
+List resources with my\-app list. Resources are listed from every region unless \-\-region is given, and listed in the order they are created.
+.PP
+Example:
+    my\-app list \-\-region ap\-northeast\-1 \-\-format json \-\-limit 100 \-\-tag production src
+.SH OPTIONS
+.TP
+\fB\-\-region\fR=\fIus\-east\-1\fR, \fB\-r\fR
+region where resources are deployed in. it is used when no region is given in config files. [env: APP_REGION]
+.TP
+\fB\-\-format\fR=\fIjson|yaml\fR, \fB\-f\fR
+output format [choices: json, yaml]
+.TP
+\fB\-\-tag\fR
+tags attached to resources
+.TP
+\fB\-\-limit\fR=\fIN\fR
+the maximum number of resources to be listed [required]
+.TP
+\fB\-\-[no\-]dry\fR=\fIfalse\fR
+dry run
+.TP
+\fB\-\-parallel\fR=\fI0\fR
+.SS Constraints
+.IP \(bu 2
+\-\-format, \-\-dry are mutually exclusive
+.SH ARGUMENTS
+.TP
+\fBSOURCE\fR
+source of resources
+.TP
+\fB[EXTRA[, ...]]\fR
+.SH COMMANDS
+.TP
+\fBmy\-app\-list\-instances\fR(1)
+list instances of resources, including ones in stopped or terminated states
+.TP
+\fBmy\-app\-list\-versions\fR(1)
+list versions of resources
+.SH SEE ALSO
+\fBmy\-app\fR(1), \fBmy\-app\-show\fR(1)
+`).Match(sb.String()).OrError(t)
+}
+
+func TestHelp_WriteMarkdown(t *testing.T) {
+	sb := new(strings.Builder)
+	if err := newHelp(t).WriteMarkdown(sb); err != nil {
+		t.Fatal(err)
+	}
+	its.Text(
+		"# my-app list\n" +
+			"\n" +
+			"list resources\n" +
+			"\n" +
+			"## Usage\n" +
+			"\n" +
+			"```\n" +
+			"my-app list --region=us-east-1 --format=json|yaml --tag --limit=N --[no-]dry=false --parallel=0 SOURCE [EXTRA[, ...]]\n" +
+			"```\n" +
+			"\n" +
+			"## Description\n" +
+			"\n" +
+			"List resources with my-app list. Resources are listed from every region unless --region is given, and listed in the order they are created.\n" +
+			"\n" +
+			"Example:\n" +
+			"    my-app list --region ap-northeast-1 --format json --limit 100 --tag production src\n" +
+			"\n" +
+			"## Flags\n" +
+			"\n" +
+			"| Flag | Value | Description |\n" +
+			"| ---- | ----- | ----------- |\n" +
+			"| `--region`, `-r` | `us-east-1` | region where resources are deployed in. it is used when no region is given in config files. [env: APP_REGION] |\n" +
+			"| `--format`, `-f` | `json\\|yaml` | output format [choices: json, yaml] |\n" +
+			"| `--tag` |  | tags attached to resources |\n" +
+			"| `--limit` | `N` | the maximum number of resources to be listed [required] |\n" +
+			"| `--[no-]dry` | `false` | dry run |\n" +
+			"| `--parallel` | `0` |  |\n" +
+			"\n" +
+			"### Constraints\n" +
+			"\n" +
+			"- --format, --dry are mutually exclusive\n" +
+			"\n" +
+			"## Args\n" +
+			"\n" +
+			"| Arg | Description |\n" +
+			"| --- | ----------- |\n" +
+			"| `SOURCE` | source of resources |\n" +
+			"| `[EXTRA[, ...]]` |  |\n" +
+			"\n" +
+			"## Subcommands\n" +
+			"\n" +
+			"| Subcommand | Description |\n" +
+			"| ---------- | ----------- |\n" +
+			"| [instances](my-app-list-instances.md) | list instances of resources, including ones in stopped or terminated states |\n" +
+			"| [versions](my-app-list-versions.md) | list versions of resources |\n" +
+			"\n" +
+			"## See also\n" +
+			"\n" +
+			"- [my-app](my-app.md)\n" +
+			"- [my-app show](my-app-show.md)\n",
+	).Match(sb.String()).OrError(t)
+}
@@ -0,0 +1,144 @@
+package help
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultWidth is the width which help texts are wrapped in, when the width of the terminal is unknown.
+const DefaultWidth = 80
+
+// WidthFromEnv returns the width of the terminal, from the environment variable COLUMNS.
+//
+// If COLUMNS is not set or not a positive integer, it returns DefaultWidth.
+func WidthFromEnv(lookupEnv func(string) (string, bool)) int {
+	cols, ok := lookupEnv("COLUMNS")
+	if !ok {
+		return DefaultWidth
+	}
+	width, err := strconv.Atoi(strings.TrimSpace(cols))
+	if err != nil || width <= 0 {
+		return DefaultWidth
+	}
+	return width
+}
+
+const (
+	// indent is the indentation of contents in sections.
+	indent = "    "
+
+	// minGutter and maxGutter are the range of widths of the column of names, including spaces after names.
+	minGutter = 12
+	maxGutter = 32
+
+	// minTextWidth is the least width of wrapped help texts.
+	// On too narrow terminals, texts overflow rather than be wrapped word by word.
+	minTextWidth = 20
+)
+
+// row is an item of sections listing names with their help.
+type row struct {
+	name string
+	help string
+}
+
+// writeRows writes rows in two columns, names and their help wrapped in width.
+//
+// The column of names fits the longest name having help.
+// Names too long for the column are placed on their own lines, and their help begins on the next line.
+func writeRows(w io.Writer, rows []row, width int) {
+	limit := maxGutter
+	if 0 < width {
+		limit = max(minGutter, min(maxGutter, width-len(indent)-minTextWidth))
+	}
+	gutter := minGutter
+	for _, r := range rows {
+		if r.help == "" {
+			continue
+		}
+		if g := textWidth(r.name) + 2; gutter < g {
+			gutter = min(g, limit)
+		}
+	}
+
+	pad := strings.Repeat(" ", len(indent)+gutter)
+	for _, r := range rows {
+		if r.help == "" {
+			fmt.Fprintln(w, indent+r.name)
+			continue
+		}
+
+		if n := textWidth(r.name); n+2 <= gutter {
+			fmt.Fprint(w, indent+r.name+strings.Repeat(" ", gutter-n))
+		} else {
+			fmt.Fprintln(w, indent+r.name)
+			fmt.Fprint(w, pad)
+		}
+
+		lines := wrap(r.help, textWidthIn(width, len(pad)))
+		fmt.Fprintln(w, lines[0])
+		for _, l := range lines[1:] {
+			if l == "" {
+				fmt.Fprintln(w)
+			} else {
+				fmt.Fprintln(w, pad+l)
+			}
+		}
+	}
+}
+
+// writeIndented writes text indented, wrapped in width.
+func writeIndented(w io.Writer, text string, width int) {
+	for _, l := range wrap(text, textWidthIn(width, len(indent))) {
+		fmt.Fprintln(w, indent+l)
+	}
+}
+
+// textWidthIn returns the width for texts after offset on lines of width.
+//
+// If width is 0 or less, texts are not wrapped, and so is the result.
+func textWidthIn(width int, offset int) int {
+	if width <= 0 {
+		return width
+	}
+	return max(width-offset, minTextWidth)
+}
+
+// wrap splits text into lines, and wraps lines longer than width at spaces.
+//
+// Wrapped lines keep the indentation of their original line.
+// Words longer than width are not broken. If width is 0 or less, lines are not wrapped.
+func wrap(text string, width int) []string {
+	lines := []string{}
+	for _, l := range strings.Split(text, "\n") {
+		if width <= 0 || textWidth(l) <= width {
+			lines = append(lines, l)
+			continue
+		}
+
+		body := strings.TrimLeft(l, " \t")
+		lead := l[:len(l)-len(body)]
+		line := lead
+		for _, word := range strings.Fields(body) {
+			if line == lead {
+				line += word
+				continue
+			}
+			if width < textWidth(line)+1+textWidth(word) {
+				lines = append(lines, line)
+				line = lead + word
+				continue
+			}
+			line += " " + word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func textWidth(s string) int {
+	return utf8.RuneCountInString(s)
+}
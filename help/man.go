@@ -0,0 +1,131 @@
+package help
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func (h *help) WriteMan(w io.Writer) error {
+	m, err := h.Model()
+	if err != nil {
+		return err
+	}
+	name := PageName(m.Name)
+
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, ".TH %s 1\n", roffQuote(strings.ToUpper(name)))
+
+	sb.WriteString(".SH NAME\n")
+	sb.WriteString(roffEscape(name))
+	if sd, _, _ := strings.Cut(m.ShortDescription, "\n"); sd != "" {
+		sb.WriteString(` \- ` + roffEscape(sd))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(".SH SYNOPSIS\n")
+	sb.WriteString(`\fB` + roffEscape(m.Name) + `\fR`)
+	for _, f := range m.Flags {
+		sb.WriteString(" " + roffEscape(f.Usage))
+	}
+	for _, a := range m.Args {
+		sb.WriteString(" " + roffEscape(a.Usage))
+	}
+	sb.WriteString("\n")
+
+	if m.HasDescription {
+		sb.WriteString(".SH DESCRIPTION\n")
+		writeRoffParagraphs(sb, m.Description)
+	}
+
+	if 0 < len(m.Flags) {
+		sb.WriteString(".SH OPTIONS\n")
+		for _, f := range m.Flags {
+			names := make([]string, len(f.Names))
+			for i, n := range f.Names {
+				names[i] = `\fB` + roffEscape(n) + `\fR`
+			}
+			// the first name is shown with its value, like "--name=VALUE".
+			if f.Metavar != "" {
+				names[0] += "=" + `\fI` + roffEscape(f.Metavar) + `\fR`
+			}
+
+			sb.WriteString(".TP\n")
+			sb.WriteString(strings.Join(names, ", ") + "\n")
+			writeRoffParagraphs(sb, f.AnnotatedHelp)
+		}
+	}
+
+	if 0 < len(m.Constraints) {
+		sb.WriteString(".SS Constraints\n")
+		for _, c := range m.Constraints {
+			sb.WriteString(".IP \\(bu 2\n")
+			sb.WriteString(roffLine(c) + "\n")
+		}
+	}
+
+	if 0 < len(m.Args) {
+		sb.WriteString(".SH ARGUMENTS\n")
+		for _, a := range m.Args {
+			sb.WriteString(".TP\n")
+			sb.WriteString(`\fB` + roffEscape(a.Usage) + `\fR` + "\n")
+			writeRoffParagraphs(sb, a.AnnotatedHelp)
+		}
+	}
+
+	if 0 < len(m.Subcommands) {
+		sb.WriteString(".SH COMMANDS\n")
+		for _, s := range m.Subcommands {
+			sb.WriteString(".TP\n")
+			fmt.Fprintf(sb, `\fB%s\fR(1)`+"\n", roffEscape(PageName(m.Name+" "+s.Name)))
+			writeRoffParagraphs(sb, s.ShortDescription)
+		}
+	}
+
+	if 0 < len(m.SeeAlso) {
+		sb.WriteString(".SH SEE ALSO\n")
+		refs := make([]string, len(m.SeeAlso))
+		for i, n := range m.SeeAlso {
+			refs[i] = `\fB` + roffEscape(PageName(n)) + `\fR(1)`
+		}
+		sb.WriteString(strings.Join(refs, ", ") + "\n")
+	}
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// writeRoffParagraphs writes text as roff. Blank lines separate paragraphs.
+func writeRoffParagraphs(sb *strings.Builder, text string) {
+	lines := strings.Split(strings.TrimRight(strings.TrimLeft(text, "\n"), "\n \t"), "\n")
+	blank := false
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			blank = true
+			continue
+		}
+		if blank {
+			sb.WriteString(".PP\n")
+			blank = false
+		}
+		sb.WriteString(roffLine(l) + "\n")
+	}
+}
+
+// roffLine escapes l as a text line of roff.
+func roffLine(l string) string {
+	l = roffEscape(l)
+	if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+		// not to be a request.
+		l = `\&` + l
+	}
+	return l
+}
+
+func roffEscape(s string) string {
+	return strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+}
+
+func roffQuote(s string) string {
+	return `"` + strings.ReplaceAll(roffEscape(s), `"`, `""`) + `"`
+}
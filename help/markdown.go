@@ -0,0 +1,90 @@
+package help
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func (h *help) WriteMarkdown(w io.Writer) error {
+	m, err := h.Model()
+	if err != nil {
+		return err
+	}
+
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "# %s\n", m.Name)
+	if m.ShortDescription != "" {
+		fmt.Fprintf(sb, "\n%s\n", m.ShortDescription)
+	}
+
+	sb.WriteString("\n## Usage\n\n```\n")
+	sb.WriteString(m.Usage)
+	sb.WriteString("\n```\n")
+
+	if d := strings.Trim(m.Description, "\n"); d != "" {
+		sb.WriteString("\n## Description\n\n")
+		sb.WriteString(d + "\n")
+	}
+
+	if 0 < len(m.Flags) {
+		sb.WriteString("\n## Flags\n\n")
+		sb.WriteString("| Flag | Value | Description |\n")
+		sb.WriteString("| ---- | ----- | ----------- |\n")
+		for _, f := range m.Flags {
+			names := make([]string, len(f.Names))
+			for i, n := range f.Names {
+				names[i] = "`" + n + "`"
+			}
+			value := ""
+			if f.Metavar != "" {
+				value = "`" + f.Metavar + "`"
+			}
+			fmt.Fprintf(
+				sb, "| %s | %s | %s |\n",
+				markdownCell(strings.Join(names, ", ")), markdownCell(value), markdownCell(f.AnnotatedHelp),
+			)
+		}
+	}
+
+	if 0 < len(m.Constraints) {
+		sb.WriteString("\n### Constraints\n\n")
+		for _, c := range m.Constraints {
+			fmt.Fprintf(sb, "- %s\n", c)
+		}
+	}
+
+	if 0 < len(m.Args) {
+		sb.WriteString("\n## Args\n\n")
+		sb.WriteString("| Arg | Description |\n")
+		sb.WriteString("| --- | ----------- |\n")
+		for _, a := range m.Args {
+			fmt.Fprintf(sb, "| %s | %s |\n", markdownCell("`"+a.Usage+"`"), markdownCell(a.AnnotatedHelp))
+		}
+	}
+
+	if 0 < len(m.Subcommands) {
+		sb.WriteString("\n## Subcommands\n\n")
+		sb.WriteString("| Subcommand | Description |\n")
+		sb.WriteString("| ---------- | ----------- |\n")
+		for _, s := range m.Subcommands {
+			link := fmt.Sprintf("[%s](%s.md)", s.Name, PageName(m.Name+" "+s.Name))
+			fmt.Fprintf(sb, "| %s | %s |\n", markdownCell(link), markdownCell(s.ShortDescription))
+		}
+	}
+
+	if 0 < len(m.SeeAlso) {
+		sb.WriteString("\n## See also\n\n")
+		for _, n := range m.SeeAlso {
+			fmt.Fprintf(sb, "- [%s](%s.md)\n", n, PageName(n))
+		}
+	}
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// markdownCell escapes s as a cell of Markdown tables.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", "<br>").Replace(strings.TrimSpace(s))
+}
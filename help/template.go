@@ -0,0 +1,266 @@
+package help
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Model is the data which help templates are executed with.
+type Model struct {
+	// Name is the full name of the command, like "app sub".
+	Name string
+
+	ShortDescription string
+
+	// Description is the long description, with its template executed.
+	Description string
+
+	// HasDescription is true if the command has the long description, even if it is empty.
+	HasDescription bool
+
+	// Usage is the synopsis of the command, like "app sub --name=NAME SOURCE".
+	Usage string
+
+	Flags []FlagModel
+
+	// Constraints are descriptions of constraints among flags.
+	Constraints []string
+
+	Args []ArgModel
+
+	// Subcommands are sorted by name.
+	Subcommands []SubcommandModel
+
+	// SeeAlso are full names of related commands, for man pages and Markdown documents.
+	SeeAlso []string
+
+	// Width is the width which help texts are wrapped in. If it is 0 or less, texts are not wrapped.
+	Width int
+}
+
+// FlagModel describes a flag in Model.
+type FlagModel struct {
+	// Name is the name of the flag, with leading hyphens, like "--name".
+	Name string
+
+	// Aliases are other names of the flag, with leading hyphens.
+	Aliases []string
+
+	// Names are the name and aliases, as shown in help. Negatable ones are like "--[no-]name".
+	Names []string
+
+	// Usage is the flag in the synopsis, like "--name=NAME".
+	Usage string
+
+	// Metavar is the value shown in usage, like "--name=METAVAR".
+	Metavar string
+
+	// Type is the Go type of the field declaring the flag, like "int" or "[]string".
+	Type string
+
+	// Default is the default value in text. If no default value is set, it is "".
+	Default string
+
+	Help string
+
+	// AnnotatedHelp is Help with annotations, like "[required]" or "[env: NAME]".
+	AnnotatedHelp string
+
+	// Env is the name of environment variable bound to the flag, or "".
+	Env string
+
+	Required bool
+
+	// Negatable is true if the flag can be negated with "--no-" prefix.
+	Negatable bool
+
+	// Choices are values the flag accepts. If it accepts any values, it is empty.
+	Choices []string
+}
+
+// ArgModel describes a positional argument in Model.
+type ArgModel struct {
+	Name string
+
+	// Usage is the arg in the synopsis, like "[SOURCE[, ...]]".
+	Usage string
+
+	Help string
+
+	// AnnotatedHelp is Help with annotations.
+	AnnotatedHelp string
+
+	Required   bool
+	Repeatable bool
+}
+
+// SubcommandModel describes a subcommand in Model.
+type SubcommandModel struct {
+	Name             string
+	ShortDescription string
+}
+
+// Funcs returns functions for help templates:
+//
+//   - columns WIDTH ITEMS: lays out []FlagModel, []ArgModel or []SubcommandModel in two columns,
+//     names and their help wrapped in WIDTH, as the default template does.
+//   - indented WIDTH TEXT: indents TEXT as contents of sections, wrapped in WIDTH.
+//   - wrap WIDTH TEXT: wraps TEXT in WIDTH.
+//   - join SEP ELEMS: joins ELEMS with SEP, as strings.Join.
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"columns":  columns,
+		"indented": indented,
+		"wrap": func(width int, text string) string {
+			return strings.Join(wrap(text, width), "\n")
+		},
+		"join": func(sep string, elems []string) string {
+			return strings.Join(elems, sep)
+		},
+	}
+}
+
+// DefaultTemplate returns a new copy of the template which Write uses by default.
+//
+// Each section is a block named "title", "usage", "description", "flags", "constraints", "args" or "subcommands".
+// Parsing templates defining them on the copy replaces sections.
+func DefaultTemplate() *template.Template {
+	return template.Must(defaultTemplate.Clone())
+}
+
+var defaultTemplate = template.Must(template.New("help").Funcs(Funcs()).Parse(
+	`{{- block "title" . }}{{ .Name }}{{ if .ShortDescription }} -- {{ .ShortDescription }}{{ end }}
+{{ end }}
+{{- block "usage" . }}
+Usage:
+
+    {{ .Usage }}
+{{ end }}
+{{- block "description" . }}{{ if .HasDescription }}
+Description:
+
+{{ indented .Width .Description }}{{ end }}{{ end }}
+{{- block "flags" . }}{{ if .Flags }}
+Flags:
+
+{{ columns .Width .Flags }}{{ end }}{{ end }}
+{{- block "constraints" . }}{{ if .Constraints }}
+Constraints:
+
+{{ range .Constraints }}{{ indented $.Width . }}{{ end }}{{ end }}{{ end }}
+{{- block "args" . }}{{ if .Args }}
+Args:
+
+{{ columns .Width .Args }}{{ end }}{{ end }}
+{{- block "subcommands" . }}{{ if .Subcommands }}
+Subcommands:
+
+{{ columns .Width .Subcommands }}
+{{ end }}{{ end }}`,
+))
+
+func columns(width int, items any) (string, error) {
+	var rows []row
+	switch it := items.(type) {
+	case []FlagModel:
+		for _, f := range it {
+			rows = append(rows, row{name: strings.Join(f.Names, ", "), help: f.AnnotatedHelp})
+		}
+	case []ArgModel:
+		for _, a := range it {
+			rows = append(rows, row{name: a.Name, help: a.AnnotatedHelp})
+		}
+	case []SubcommandModel:
+		for _, s := range it {
+			rows = append(rows, row{name: s.Name, help: s.ShortDescription})
+		}
+	default:
+		return "", fmt.Errorf("columns: unsupported items: %T", items)
+	}
+
+	sb := new(strings.Builder)
+	writeRows(sb, rows, width)
+	return sb.String(), nil
+}
+
+func indented(width int, text string) string {
+	sb := new(strings.Builder)
+	writeIndented(sb, text, width)
+	return sb.String()
+}
+
+// Model returns the data which the template of Write is executed with.
+func (h *help) Model() (Model, error) {
+	m := Model{
+		Name:             h.fullname,
+		ShortDescription: h.shortDescription,
+		Constraints:      append([]string{}, h.constraints...),
+		SeeAlso:          append([]string{}, h.seeAlso...),
+		Width:            h.width,
+	}
+
+	if h.description != nil {
+		sb := new(strings.Builder)
+		err := h.description.Execute(sb, struct{ Command string }{Command: h.fullname})
+		if err != nil {
+			return Model{}, err
+		}
+		m.Description = sb.String()
+		m.HasDescription = true
+	}
+
+	usage := new(strings.Builder)
+	usage.WriteString(h.fullname)
+	h.flags.WriteUsage(usage)
+	h.args.WriteUsage(usage)
+	m.Usage = usage.String()
+
+	for _, f := range h.flags.content {
+		metavar := ""
+		if _, v, ok := strings.Cut(f.Usage(), "="); ok {
+			metavar = v
+		}
+		m.Flags = append(m.Flags, FlagModel{
+			Name:          f.Name(),
+			Aliases:       append([]string{}, f.Alias()...),
+			Names:         paramNames(f),
+			Usage:         f.Usage(),
+			Metavar:       metavar,
+			Type:          f.Type().String(),
+			Default:       f.Default(),
+			Help:          f.Help(),
+			AnnotatedHelp: annotatedHelp(f),
+			Env:           f.Env(),
+			Required:      f.Required(),
+			Negatable:     f.Negatable(),
+			Choices:       append([]string{}, f.Choices()...),
+		})
+	}
+
+	for _, a := range h.args.content {
+		m.Args = append(m.Args, ArgModel{
+			Name:          a.Name(),
+			Usage:         a.Usage(),
+			Help:          a.Help(),
+			AnnotatedHelp: annotatedHelp(a),
+			Required:      a.Required(),
+			Repeatable:    a.Repeatable(),
+		})
+	}
+
+	names := make([]string, 0, h.subcommands.Len())
+	for n := range h.subcommands.cmds {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	for _, n := range names {
+		m.Subcommands = append(m.Subcommands, SubcommandModel{
+			Name:             n,
+			ShortDescription: h.subcommands.cmds[n].ShortDescription(),
+		})
+	}
+
+	return m, nil
+}
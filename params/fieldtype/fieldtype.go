@@ -0,0 +1,114 @@
+// Package fieldtype tells which types of struct fields flarc can handle, with go/types.
+//
+// It is for tools inspecting sources, like flarcvet and flarcgen,
+// and follows what params/internal/flags does with reflection.
+package fieldtype
+
+import (
+	"go/types"
+)
+
+// Supported reports flarc can handle fields typed t.
+//
+// Supported types are:
+//
+// - func(string) error
+//
+// - types implementing flag.Value
+//
+// - string, bool, numbers, time.Duration, time.Time,
+// types implementing encoding.TextUnmarshaler with pointer receivers, named string types,
+// and pointers or slices of them.
+func Supported(t types.Type) bool {
+	if IsCallback(t) {
+		return true
+	}
+	leaf := Elem(t)
+	return IsBasicLeaf(leaf) || IsTextUnmarshaler(leaf) || IsString(leaf)
+}
+
+// Elem strips pointers and slices from t.
+func Elem(t types.Type) types.Type {
+	for {
+		switch u := t.(type) {
+		case *types.Pointer:
+			t = u.Elem()
+			continue
+		case *types.Slice:
+			t = u.Elem()
+			continue
+		}
+		return t
+	}
+}
+
+// IsCallback reports fields typed t pass values to themselves,
+// that is, t is func(string) error, not named one, or implements flag.Value.
+func IsCallback(t types.Type) bool {
+	if sig, ok := t.(*types.Signature); ok {
+		return isParamsAndResults(sig, types.Typ[types.String], errorType)
+	}
+	return hasMethod(t, "String", func(sig *types.Signature) bool {
+		return isParamsAndResults(sig, nil, types.Typ[types.String])
+	}) && hasMethod(t, "Set", func(sig *types.Signature) bool {
+		return isParamsAndResults(sig, types.Typ[types.String], errorType)
+	})
+}
+
+// IsBasicLeaf reports t is one of types which flags handle specially:
+// string, bool, numbers, time.Duration and time.Time.
+func IsBasicLeaf(t types.Type) bool {
+	switch l := t.(type) {
+	case *types.Basic:
+		switch l.Kind() {
+		case types.String, types.Bool,
+			types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
+			types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64,
+			types.Float32, types.Float64:
+			return true
+		}
+	case *types.Named:
+		if obj := l.Obj(); obj.Pkg() != nil && obj.Pkg().Path() == "time" {
+			return obj.Name() == "Duration" || obj.Name() == "Time"
+		}
+	}
+	return false
+}
+
+// IsTextUnmarshaler reports the pointer to t implements encoding.TextUnmarshaler.
+func IsTextUnmarshaler(t types.Type) bool {
+	return hasMethod(types.NewPointer(t), "UnmarshalText", func(sig *types.Signature) bool {
+		return isParamsAndResults(sig, types.NewSlice(types.Typ[types.Byte]), errorType)
+	})
+}
+
+// IsString reports the underlying type of t is string.
+func IsString(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Kind() == types.String
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// isParamsAndResults reports sig takes param and returns result.
+//
+// If param is nil, sig should take nothing.
+func isParamsAndResults(sig *types.Signature, param types.Type, result types.Type) bool {
+	if sig.Variadic() || sig.Results().Len() != 1 || !types.Identical(sig.Results().At(0).Type(), result) {
+		return false
+	}
+	if param == nil {
+		return sig.Params().Len() == 0
+	}
+	return sig.Params().Len() == 1 && types.Identical(sig.Params().At(0).Type(), param)
+}
+
+// hasMethod reports t has the method name, whose signature satisfies match.
+func hasMethod(t types.Type, name string, match func(*types.Signature) bool) bool {
+	sel := types.NewMethodSet(t).Lookup(nil, name)
+	if sel == nil {
+		return false
+	}
+	fn, ok := sel.Obj().(*types.Func)
+	return ok && match(fn.Type().(*types.Signature))
+}
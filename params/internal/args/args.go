@@ -1,5 +1,11 @@
 package args
 
+import (
+	"context"
+
+	"github.com/youta-t/flarc/completion"
+)
+
 // ArgDef holds configuration of positional arguments
 type ArgDef struct {
 	// Name of this arg
@@ -13,6 +19,14 @@ type ArgDef struct {
 
 	// Help message for this arg.
 	Help string
+
+	// Complete returns candidates of this arg, for shell completion.
+	//
+	// If nil, shells complete file names.
+	Complete completion.Completer
+
+	// CompleteDirective tells shells how to complete this arg.
+	CompleteDirective completion.Directive
 }
 
 func (p ArgDef) Freeze() Arg {
@@ -21,6 +35,8 @@ func (p ArgDef) Freeze() Arg {
 		required:   p.Required,
 		repeatable: p.Repeatable,
 		help:       p.Help,
+		complete:   p.Complete,
+		directive:  p.CompleteDirective,
 	}
 }
 
@@ -30,6 +46,9 @@ type Arg interface {
 	Repeatable() bool
 	Help() string
 	Usage() string
+
+	// Complete returns candidates for partial, and how shells complete.
+	Complete(ctx context.Context, partial string) ([]completion.Candidate, completion.Directive)
 }
 
 type arg struct {
@@ -37,6 +56,8 @@ type arg struct {
 	required   bool
 	repeatable bool
 	help       string
+	complete   completion.Completer
+	directive  completion.Directive
 }
 
 func (p arg) Name() string {
@@ -65,3 +86,10 @@ func (pos arg) Usage() string {
 	}
 	return s
 }
+
+func (p arg) Complete(ctx context.Context, partial string) ([]completion.Candidate, completion.Directive) {
+	if p.complete == nil {
+		return nil, p.directive
+	}
+	return p.complete(ctx, partial), p.directive
+}
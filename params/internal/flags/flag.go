@@ -1,10 +1,12 @@
 package flags
 
 import (
+	"encoding"
 	"errors"
 	goflag "flag"
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -31,11 +33,58 @@ type Flag interface {
 	// Found this flag but no value is given
 	Found() error
 
+	// Negatable returns true when this flag can be negated with "--no-" prefix.
+	Negatable() bool
+
+	// MatchNegation returns true when s is "no-" prefixed Name() or Alias().
+	//
+	// Single letter names cannot be negated.
+	MatchNegation(s string) bool
+
+	// Negate sets negative value to this flag.
+	Negate() error
+
+	// NeedsValue returns true when this flag cannot be set without value.
+	//
+	// In other words, Found() fails.
+	NeedsValue() bool
+
 	// Help message
 	Help() string
 
+	// Required returns true when this flag should be given explicitly.
+	Required() bool
+
+	// Type returns the type of the field which this flag is created from.
+	Type() reflect.Type
+
+	// Choices returns values which this flag accepts.
+	//
+	// If this flag accepts any values, it returns empty.
+	Choices() []string
+
+	// Env returns the name of environment variable bound to this flag.
+	//
+	// If no environment variables are bound, it returns "".
+	Env() string
+
+	// Default returns the default value of this flag, in text.
+	//
+	// Values in slices are joined with ",", each in the form which this flag accepts.
+	// If the field has no values, like nil pointers or empty slices, it returns "".
+	Default() string
+
+	// Field returns the name of the struct field which this flag is created from.
+	Field() string
+
 	// usage of this flag
 	Usage() string
+
+	// Bind returns a copy of this flag which stores values into dest.
+	//
+	// dest should be a settable value having the type of the field
+	// which this flag is created from.
+	Bind(dest reflect.Value) Flag
 }
 
 func setfn[T any](dest reflect.Value) func(T) {
@@ -71,7 +120,7 @@ func setfn[T any](dest reflect.Value) func(T) {
 	}
 
 	return func(t T) {
-		dest.Set(wrap(reflect.ValueOf(t)))
+		dest.Set(wrap(reflect.ValueOf(t).Convert(next)))
 	}
 }
 
@@ -79,17 +128,33 @@ type flag[T any] struct {
 	name       string
 	alias      []string
 	set        func(T)
+	bind       func(reflect.Value) func(T)
 	translator func(string) (T, error)
-	action     func() (T, error)
+
+	// callback receives values instead of set, for fields typed func(string) error or flag.Value.
+	callback func(T) error
+	// bindCallback returns callback for the field dest.
+	bindCallback func(dest reflect.Value) func(T) error
+
+	action  func() (T, error)
+	negated func() T
 
 	help      string
 	metaValue string
+	env       string
+	typ       reflect.Type
+	required  bool
+	choices   []string
+	def       string
+	field     string
 }
 
 func (f flag[T]) Usage() string {
 	flgname := f.name
 	if len(flgname) == 1 {
 		flgname = "-" + flgname
+	} else if f.Negatable() {
+		flgname = "--[no-]" + flgname
 	} else {
 		flgname = "--" + flgname
 	}
@@ -132,11 +197,34 @@ func (f flag[T]) Match(given string) bool {
 	return false
 }
 
+func (f flag[T]) Negatable() bool {
+	return f.negated != nil
+}
+
+func (f flag[T]) MatchNegation(given string) bool {
+	if !f.Negatable() {
+		return false
+	}
+	n, ok := strings.CutPrefix(given, "no-")
+	return ok && 1 < len(n) && f.Match(n)
+}
+
+func (f flag[T]) Negate() error {
+	if !f.Negatable() {
+		return fmt.Errorf("%w: %s", ErrNotNegatable, f.name)
+	}
+	f.set(f.negated())
+	return nil
+}
+
 func (f flag[T]) Set(s string) error {
 	val, err := f.translator(s)
 	if err != nil {
 		return err
 	}
+	if f.callback != nil {
+		return f.callback(val)
+	}
 	f.set(val)
 	return nil
 }
@@ -153,10 +241,112 @@ func (f flag[T]) Found() error {
 	return nil
 }
 
+func (f flag[T]) NeedsValue() bool {
+	if f.action == nil {
+		return true
+	}
+	_, err := f.action()
+	return err != nil
+}
+
 func (f flag[T]) Help() string {
 	return f.help
 }
 
+func (f flag[T]) Required() bool {
+	return f.required
+}
+
+func (f flag[T]) Type() reflect.Type {
+	return f.typ
+}
+
+func (f flag[T]) Choices() []string {
+	return f.choices
+}
+
+func (f flag[T]) Env() string {
+	return f.env
+}
+
+func (f flag[T]) Default() string {
+	return f.def
+}
+
+func (f flag[T]) Field() string {
+	return f.field
+}
+
+// withField returns a copy of this flag created from the field named field.
+func (f flag[T]) withField(field string) Flag {
+	f.field = field
+	return f
+}
+
+// withDefault returns a copy of this flag having def as its default value in text.
+func (f flag[T]) withDefault(def string) Flag {
+	f.def = def
+	return f
+}
+
+// withChoices returns a copy of this flag which accepts only choices.
+func (f flag[T]) withChoices(choices []string, metavar bool) Flag {
+	f.choices = choices
+	if metavar {
+		f.metaValue = strings.Join(choices, "|")
+	}
+	translator := f.translator
+	f.translator = func(s string) (T, error) {
+		if !slices.Contains(choices, s) {
+			return *new(T), fmt.Errorf(
+				"%w: %s is not one of %s", ErrNotInChoices, s, strings.Join(choices, ", "),
+			)
+		}
+		return translator(s)
+	}
+	return f
+}
+
+func (f flag[T]) Bind(dest reflect.Value) Flag {
+	if f.bind != nil {
+		f.set = f.bind(dest)
+	}
+	if f.bindCallback != nil {
+		f.callback = f.bindCallback(dest)
+	}
+	return f
+}
+
+// callFunc returns a callback calling the func(string) error in dest.
+func callFunc(dest reflect.Value) func(string) error {
+	return func(s string) error {
+		rets := dest.Call([]reflect.Value{reflect.ValueOf(s)})
+		// nil error is nil interface, which cannot be asserted.
+		e, _ := rets[0].Interface().(error)
+		return e
+	}
+}
+
+// setValue returns a callback setting values to the flag.Value in dest.
+//
+// If the value is a pointer, a shallow copy of its pointee is stored into dest at first,
+// not to share values with other destinations, including the flagdef.
+// For nil pointers, a new zero value is stored.
+func setValue(dest reflect.Value) func(string) error {
+	v := dest
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Pointer && dest.CanSet() {
+		c := reflect.New(v.Type().Elem())
+		if !v.IsNil() {
+			c.Elem().Set(v.Elem())
+		}
+		dest.Set(c)
+	}
+	return dest.Interface().(goflag.Value).Set
+}
+
 func elem(t reflect.Type) reflect.Type {
 	next := t
 	for {
@@ -169,330 +359,494 @@ func elem(t reflect.Type) reflect.Type {
 	}
 }
 
-func New(tfld reflect.StructField, dest reflect.Value) (Flag, error) {
+// isRepeatable reports the field typed t takes many values, that is, t has slices.
+func isRepeatable(t reflect.Type) bool {
+	for next := t; ; next = next.Elem() {
+		switch next.Kind() {
+		case reflect.Slice:
+			return true
+		case reflect.Pointer:
+		default:
+			return false
+		}
+	}
+}
+
+// formatDefault renders values in dest, the field for a flag, with format.
+//
+// Pointers are dereferenced and values in slices are joined with ",".
+// Nil pointers have no values.
+func formatDefault[T any](dest reflect.Value, format func(T) string) string {
+	vals := []string{}
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Pointer:
+			if !v.IsNil() {
+				walk(v.Elem())
+			}
+		case reflect.Slice:
+			for i := 0; i < v.Len(); i += 1 {
+				walk(v.Index(i))
+			}
+		default:
+			vals = append(vals, format(v.Convert(typeOf[T]()).Interface().(T)))
+		}
+	}
+	walk(dest)
+	return strings.Join(vals, ",")
+}
+
+// Option configures New.
+type Option func(*option) *option
+
+type option struct {
+	envPrefix string
+}
+
+// WithEnvPrefix binds environment variables to flags without env tag.
+//
+// The name of the variable is the prefix and the flag name in upper snake case,
+// joined with "_". For example, the flag "dry-run" with prefix "APP" is bound to "APP_DRY_RUN".
+func WithEnvPrefix(prefix string) Option {
+	return func(o *option) *option {
+		o.envPrefix = prefix
+		return o
+	}
+}
+
+// New creates a flag from the struct field tfld, storing values into dest.
+func New(tfld reflect.StructField, dest reflect.Value, options ...Option) (Flag, error) {
+	f, err := newFlag(tfld, dest, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	var choices []string
+	if c, ok := reflect.New(elem(tfld.Type)).Interface().(interface{ Choices() []string }); ok {
+		choices = c.Choices()
+	}
+	return complete(f, tfld.Name, tfld.Tag, f.Default(), choices)
+}
+
+// complete sets the field name, the default value in text and choices to f.
+//
+// choices are ones declared by the type of the field. The tag choices overrides them.
+func complete(f Flag, field string, tag reflect.StructTag, def string, choices []string) (Flag, error) {
+	f = f.(interface{ withField(string) Flag }).withField(field)
+	f = f.(interface{ withDefault(string) Flag }).withDefault(def)
 
-	name := tfld.Tag.Get("flag")
+	if s, ok := tag.Lookup("choices"); ok {
+		choices = strings.Split(s, ",")
+	}
+	if len(choices) == 0 {
+		return f, nil
+	}
+
+	c, ok := f.(interface {
+		withChoices([]string, bool) Flag
+	})
+	if !ok {
+		return nil, fmt.Errorf("choices are not supported: %s", field)
+	}
+	_, metavar := tag.Lookup("metavar")
+	return c.withChoices(choices, !metavar), nil
+}
+
+// decl is a declaration of a flag, in tags of a struct field.
+type decl struct {
+	name      string
+	alias     []string
+	help      string
+	metavar   string
+	env       string
+	required  bool
+	negatable bool
+}
+
+// parseDecl reads tags of the struct field named field.
+func parseDecl(field string, tag reflect.StructTag, options ...Option) (decl, error) {
+	opt := &option{}
+	for _, o := range options {
+		opt = o(opt)
+	}
+
+	name := tag.Get("flag")
 	if name == "" {
-		name = utils.ToKebab(tfld.Name)
+		name = utils.ToKebab(field)
 	}
 	alias := []string{}
-	if s, ok := tfld.Tag.Lookup("alias"); ok {
+	if s, ok := tag.Lookup("alias"); ok {
 		alias = append(alias, strings.Split(s, ",")...)
 	}
-	help := tfld.Tag.Get("help")
+	help := tag.Get("help")
 
 	metavar := ""
-	if mv, ok := tfld.Tag.Lookup("metavar"); ok {
+	if mv, ok := tag.Lookup("metavar"); ok {
 		metavar = mv
 	}
 
+	env := ""
+	if e, ok := tag.Lookup("env"); ok {
+		if e != "-" {
+			env = e
+		}
+	} else if opt.envPrefix != "" {
+		env = strings.TrimSuffix(opt.envPrefix, "_") + "_" +
+			strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	}
+
+	required := false
+	if s, ok := tag.Lookup("required"); ok {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return decl{}, fmt.Errorf("tag required should be bool, but %s: %s", s, field)
+		}
+		required = b
+	}
+
+	negatable := true
+	if s, ok := tag.Lookup("negatable"); ok {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return decl{}, fmt.Errorf("tag negatable should be bool, but %s: %s", s, field)
+		}
+		negatable = b
+	}
+
+	return decl{
+		name: name, alias: alias, help: help, metavar: metavar, env: env,
+		required: required, negatable: negatable,
+	}, nil
+}
+
+func newFlag(tfld reflect.StructField, dest reflect.Value, options ...Option) (Flag, error) {
+	d, err := parseDecl(tfld.Name, tfld.Tag, options...)
+	if err != nil {
+		return nil, err
+	}
+	name, alias, help, metavar, env := d.name, d.alias, d.help, d.metavar, d.env
+	required, negatable := d.required, d.negatable
+	// values of repeatable flags are given one by one, so their defaults are not metavars.
+	repeatable := isRepeatable(tfld.Type)
+
 	defaultValue := dest.Interface()
 	switch d := defaultValue.(type) {
 	case func(string) error:
 		return flag[string]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set: func(s string) {
-				// do nothing.
-			},
-			translator: func(s string) (string, error) {
-				rets := dest.Call([]reflect.Value{reflect.ValueOf(s)})
-				e := rets[0].Interface().(error)
-				if e != nil {
-					return "", e
-				}
-				return "", nil
-			},
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ: tfld.Type, required: required,
+			set:          func(string) {},
+			translator:   readString,
+			callback:     callFunc(dest),
+			bindCallback: callFunc,
 		}, nil
 	case goflag.Value:
+		def := d.String()
 		if metavar == "" {
-			metavar = d.String()
+			metavar = def
 		}
-		return flag[goflag.Value]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set: func(v goflag.Value) {},
-			translator: func(s string) (goflag.Value, error) {
-				err := d.Set(s)
-				return d, err
-			},
+		return flag[string]{
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ: tfld.Type, required: required, def: def,
+			set:          func(string) {},
+			translator:   readString,
+			callback:     d.Set,
+			bindCallback: setValue,
 		}, nil
 	}
 
 	switch reflect.New(elem(tfld.Type)).Elem().Interface().(type) {
 	case string:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case string:
-				metavar = d
-			case *string:
-				if d != nil {
-					metavar = *d
-				}
-			}
+		def := formatDefault(dest, func(d string) string { return d })
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[string]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[string](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[string],
 			action:     func() (string, error) { return "", ErrValueRequired },
 			translator: readString,
-		}, nil
+		}.Bind(dest), nil
 	case bool:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case bool:
-				metavar = fmt.Sprintf("%#v", d)
-			case *bool:
-				if d != nil {
-					metavar = fmt.Sprintf("%#v", *d)
-				}
-			}
+		def := formatDefault(dest, func(d bool) string { return fmt.Sprintf("%#v", d) })
+		if metavar == "" && !repeatable {
+			metavar = def
+		}
+		var negated func() bool
+		if negatable {
+			negated = func() bool { return false }
 		}
 		return flag[bool]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[bool](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[bool],
 			action:     func() (bool, error) { return true, nil },
+			negated:    negated,
 			translator: readBool,
-		}, nil
+		}.Bind(dest), nil
 	case int:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case int:
-				metavar = fmt.Sprintf("%d", d)
-			case *int:
-				if d != nil {
-					metavar = fmt.Sprintf("%d", *d)
-				}
-			}
+		def := formatDefault(dest, formatInt[int])
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[int]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[int](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[int],
 			action:     func() (int, error) { return 0, ErrValueRequired },
 			translator: readInt[int],
-		}, nil
+		}.Bind(dest), nil
 	case int8:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case int8:
-				metavar = fmt.Sprintf("%d", d)
-			case *int8:
-				if d != nil {
-					metavar = fmt.Sprintf("%d", *d)
-				}
-			}
+		def := formatDefault(dest, formatInt[int8])
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[int8]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[int8](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[int8],
 			action:     func() (int8, error) { return 0, ErrValueRequired },
 			translator: readInt[int8],
-		}, nil
+		}.Bind(dest), nil
 	case int16:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case int16:
-				metavar = fmt.Sprintf("%d", d)
-			case *int16:
-				if d != nil {
-					metavar = fmt.Sprintf("%d", *d)
-				}
-			}
+		def := formatDefault(dest, formatInt[int16])
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[int16]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[int16](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[int16],
 			action:     func() (int16, error) { return 0, ErrValueRequired },
 			translator: readInt[int16],
-		}, nil
+		}.Bind(dest), nil
 	case int32:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case int32:
-				metavar = fmt.Sprintf("%d", d)
-			case *int32:
-				if d != nil {
-					metavar = fmt.Sprintf("%d", *d)
-				}
-			}
+		def := formatDefault(dest, formatInt[int32])
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[int32]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[int32](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[int32],
 			action:     func() (int32, error) { return 0, ErrValueRequired },
 			translator: readInt[int32],
-		}, nil
+		}.Bind(dest), nil
 	case int64:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case int64:
-				metavar = fmt.Sprintf("%d", d)
-			case *int64:
-				if d != nil {
-					metavar = fmt.Sprintf("%d", *d)
-				}
-			}
+		def := formatDefault(dest, formatInt[int64])
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[int64]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[int64](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[int64],
 			action:     func() (int64, error) { return 0, ErrValueRequired },
 			translator: readInt[int64],
-		}, nil
+		}.Bind(dest), nil
 	case uint:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case uint:
-				metavar = fmt.Sprintf("%d", d)
-			case *uint:
-				if d != nil {
-					metavar = fmt.Sprintf("%d", *d)
-				}
-			}
+		def := formatDefault(dest, formatInt[uint])
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[uint]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[uint](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[uint],
 			action:     func() (uint, error) { return 0, ErrValueRequired },
 			translator: readUint[uint],
-		}, nil
+		}.Bind(dest), nil
 	case uint8:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case uint8:
-				metavar = fmt.Sprintf("%d", d)
-			case *uint8:
-				if d != nil {
-					metavar = fmt.Sprintf("%d", *d)
-				}
-			}
+		def := formatDefault(dest, formatInt[uint8])
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[uint8]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[uint8](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[uint8],
 			action:     func() (uint8, error) { return 0, ErrValueRequired },
 			translator: readUint[uint8],
-		}, nil
+		}.Bind(dest), nil
 	case uint16:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case uint16:
-				metavar = fmt.Sprintf("%d", d)
-			case *uint16:
-				if d != nil {
-					metavar = fmt.Sprintf("%d", *d)
-				}
-			}
+		def := formatDefault(dest, formatInt[uint16])
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[uint16]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[uint16](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[uint16],
 			action:     func() (uint16, error) { return 0, ErrValueRequired },
 			translator: readUint[uint16],
-		}, nil
+		}.Bind(dest), nil
 	case uint32:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case uint32:
-				metavar = fmt.Sprintf("%d", d)
-			case *uint32:
-				if d != nil {
-					metavar = fmt.Sprintf("%d", *d)
-				}
-			}
+		def := formatDefault(dest, formatInt[uint32])
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[uint32]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[uint32](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[uint32],
 			action:     func() (uint32, error) { return 0, ErrValueRequired },
 			translator: readUint[uint32],
-		}, nil
+		}.Bind(dest), nil
 	case uint64:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case uint64:
-				metavar = fmt.Sprintf("%d", d)
-			case *uint64:
-				if d != nil {
-					metavar = fmt.Sprintf("%d", *d)
-				}
-			}
+		def := formatDefault(dest, formatInt[uint64])
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[uint64]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[uint64](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[uint64],
 			action:     func() (uint64, error) { return 0, ErrValueRequired },
 			translator: readUint[uint64],
-		}, nil
+		}.Bind(dest), nil
 	case float32:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case float32:
-				metavar = strconv.FormatFloat(float64(d), 'f', -1, 32)
-			case *float32:
-				if d != nil {
-					metavar = strconv.FormatFloat(float64(*d), 'f', -1, 32)
-				}
-			}
+		def := formatDefault(dest, func(d float32) string { return strconv.FormatFloat(float64(d), 'f', -1, 32) })
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[float32]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[float32](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[float32],
 			action:     func() (float32, error) { return 0, ErrValueRequired },
 			translator: readFloat[float32],
-		}, nil
+		}.Bind(dest), nil
 	case float64:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case float64:
-				metavar = strconv.FormatFloat(d, 'f', -1, 64)
-			case *float64:
-				if d != nil {
-					metavar = strconv.FormatFloat(*d, 'f', -1, 64)
-				}
-			}
+		def := formatDefault(dest, func(d float64) string { return strconv.FormatFloat(d, 'f', -1, 64) })
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[float64]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[float64](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[float64],
 			action:     func() (float64, error) { return 0, ErrValueRequired },
 			translator: readFloat[float64],
-		}, nil
+		}.Bind(dest), nil
 	case time.Duration:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case time.Duration:
-				metavar = d.String()
-			case *time.Duration:
-				if d != nil {
-					metavar = d.String()
-				}
-			}
+		def := formatDefault(dest, time.Duration.String)
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[time.Duration]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[time.Duration](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[time.Duration],
 			action:     func() (time.Duration, error) { return 0, ErrValueRequired },
 			translator: readDuration,
-		}, nil
+		}.Bind(dest), nil
 	case time.Time:
-		if metavar == "" {
-			switch d := defaultValue.(type) {
-			case time.Time:
-				metavar = d.Format(time.RFC3339Nano)
-			case *time.Time:
-				if d != nil {
-					metavar = d.Format(time.RFC3339Nano)
-				}
-			}
+		def := formatDefault(dest, func(d time.Time) string { return d.Format(time.RFC3339Nano) })
+		if metavar == "" && !repeatable {
+			metavar = def
 		}
 		return flag[time.Time]{
-			name: name, alias: alias, help: help, metaValue: metavar,
-			set:        setfn[time.Time](dest),
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[time.Time],
 			action:     func() (time.Time, error) { return time.Time{}, ErrValueRequired },
 			translator: readTime(time.RFC3339Nano),
-		}, nil
+		}.Bind(dest), nil
+	}
+
+	if leaf := elem(tfld.Type); reflect.PointerTo(leaf).Implements(textUnmarshaler) {
+		def := formatDefault(dest, func(d any) string { return marshalText(reflect.ValueOf(d)) })
+		if metavar == "" && !repeatable {
+			metavar = def
+		}
+		return flag[any]{
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[any],
+			action:     func() (any, error) { return nil, ErrValueRequired },
+			translator: readText(leaf),
+		}.Bind(dest), nil
+	}
+
+	if elem(tfld.Type).Kind() == reflect.String {
+		// named string types, like `type Format string`
+		def := formatDefault(dest, func(d string) string { return d })
+		if metavar == "" && !repeatable {
+			metavar = def
+		}
+		return flag[string]{
+			name: name, alias: alias, help: help, metaValue: metavar, env: env,
+			typ:        tfld.Type,
+			required:   required,
+			def:        def,
+			bind:       setfn[string],
+			action:     func() (string, error) { return "", ErrValueRequired },
+			translator: readString,
+		}.Bind(dest), nil
 	}
 
 	return nil, errors.New("unsupported type")
 }
 
 var ErrValueRequired = fmt.Errorf("%w: value required", flarcerror.ErrUsage)
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// marshalText renders v, a value of a leaf type of a field, with MarshalText.
+//
+// If v is not a encoding.TextMarshaler, it returns "".
+func marshalText(v reflect.Value) string {
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	m, ok := p.Interface().(encoding.TextMarshaler)
+	if !ok {
+		return ""
+	}
+	b, err := m.MarshalText()
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// ErrNotInChoices is returned when a value not in choices is given.
+var ErrNotInChoices = fmt.Errorf("%w: not in choices", ErrParse)
+
+// ErrNotNegatable is returned when a flag which cannot be negated is negated.
+var ErrNotNegatable = fmt.Errorf("%w: cannot be negated", flarcerror.ErrUsage)
@@ -1,6 +1,8 @@
 package flags_test
 
 import (
+	"log/slog"
+	"net/netip"
 	"reflect"
 	"testing"
 	"time"
@@ -58,7 +60,35 @@ func TestFlag_bool(t *testing.T) {
 	}
 
 	its.EqEq("help message").Match(testee.Help()).OrError(t)
+	its.EqEq("--[no-]f1=true").Match(testee.Usage()).OrError(t)
+	its.EqEq(true).Match(testee.Negatable()).OrError(t)
+}
+
+func TestFlag_bool_notNegatable(t *testing.T) {
+	type F struct {
+		F1 bool `help:"help message" negatable:"false"`
+	}
+
+	flg := F{
+		F1: true,
+	}
+
+	rflg := reflect.ValueOf(flg)
+
+	rf1, ok := rflg.Type().FieldByName("F1")
+	if !ok {
+		t.Fatal("field F1 is not found")
+	}
+	rf1Field := rflg.FieldByName("F1")
+
+	testee, err := flags.New(rf1, rf1Field)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	its.EqEq("--f1=true").Match(testee.Usage()).OrError(t)
+	its.EqEq(false).Match(testee.Negatable()).OrError(t)
+	its.EqEq(false).Match(testee.MatchNegation("no-f1")).OrError(t)
 }
 
 func TestFlag_int(t *testing.T) {
@@ -427,3 +457,258 @@ func TestFlag_Time(t *testing.T) {
 	its.EqEq("help message").Match(testee.Help()).OrError(t)
 	its.EqEq("--f1=2024-10-31T20:25:30+02:00").Match(testee.Usage()).OrError(t)
 }
+
+func TestFlag_env(t *testing.T) {
+	type F struct {
+		Tagged   int `env:"TAGGED_ENV"`
+		Untagged int
+		OptOut   int `env:"-"`
+	}
+
+	rflg := reflect.ValueOf(F{})
+	rtype := rflg.Type()
+
+	theory := func(field string, options []flags.Option, want string) func(*testing.T) {
+		return func(t *testing.T) {
+			rf, ok := rtype.FieldByName(field)
+			if !ok {
+				t.Fatalf("field %s is not found", field)
+			}
+			testee, err := flags.New(rf, rflg.FieldByName(field), options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			its.EqEq(want).Match(testee.Env()).OrError(t)
+		}
+	}
+
+	t.Run("tagged", theory("Tagged", nil, "TAGGED_ENV"))
+	t.Run("tagged, with prefix", theory(
+		"Tagged", []flags.Option{flags.WithEnvPrefix("APP")}, "TAGGED_ENV",
+	))
+	t.Run("untagged", theory("Untagged", nil, ""))
+	t.Run("untagged, with prefix", theory(
+		"Untagged", []flags.Option{flags.WithEnvPrefix("APP")}, "APP_UNTAGGED",
+	))
+	t.Run("opt out, with prefix", theory(
+		"OptOut", []flags.Option{flags.WithEnvPrefix("APP")}, "",
+	))
+}
+
+type format string
+
+func (format) Choices() []string {
+	return []string{"json", "yaml", "table"}
+}
+
+func TestFlag_choices(t *testing.T) {
+	type F struct {
+		Tagged   string   `choices:"json,yaml,table"`
+		Typed    format   `help:"output format"`
+		Slice    []format `metavar:"FORMAT"`
+		Override format   `choices:"csv,tsv"`
+	}
+
+	flg := &F{Typed: "json"}
+	rflg := reflect.ValueOf(flg).Elem()
+	rtype := rflg.Type()
+
+	newFlag := func(t *testing.T, field string) flags.Flag {
+		rf, ok := rtype.FieldByName(field)
+		if !ok {
+			t.Fatalf("field %s is not found", field)
+		}
+		testee, err := flags.New(rf, rflg.FieldByName(field))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return testee
+	}
+
+	t.Run("tagged", func(t *testing.T) {
+		testee := newFlag(t, "Tagged")
+		its.Slice(its.EqEq("json"), its.EqEq("yaml"), its.EqEq("table")).
+			Match(testee.Choices()).OrError(t)
+		its.EqEq("--tagged=json|yaml|table").Match(testee.Usage()).OrError(t)
+
+		its.Nil[error]().Match(testee.Set("yaml")).OrError(t)
+		its.EqEq("yaml").Match(flg.Tagged).OrError(t)
+
+		its.All(
+			its.Error(flags.ErrNotInChoices),
+			its.Error(flags.ErrParse),
+		).Match(testee.Set("xml")).OrError(t)
+		its.EqEq("yaml").Match(flg.Tagged).OrError(t)
+	})
+
+	t.Run("typed", func(t *testing.T) {
+		testee := newFlag(t, "Typed")
+		its.Slice(its.EqEq("json"), its.EqEq("yaml"), its.EqEq("table")).
+			Match(testee.Choices()).OrError(t)
+		its.EqEq("--typed=json|yaml|table").Match(testee.Usage()).OrError(t)
+
+		its.Nil[error]().Match(testee.Set("table")).OrError(t)
+		its.EqEq(format("table")).Match(flg.Typed).OrError(t)
+
+		its.Error(flags.ErrNotInChoices).Match(testee.Set("xml")).OrError(t)
+	})
+
+	t.Run("slice of typed", func(t *testing.T) {
+		testee := newFlag(t, "Slice")
+		its.EqEq("--slice=FORMAT").Match(testee.Usage()).OrError(t)
+
+		its.Nil[error]().Match(testee.Set("json")).OrError(t)
+		its.Nil[error]().Match(testee.Set("yaml")).OrError(t)
+		its.Slice(its.EqEq(format("json")), its.EqEq(format("yaml"))).
+			Match(flg.Slice).OrError(t)
+	})
+
+	t.Run("tag overrides type", func(t *testing.T) {
+		testee := newFlag(t, "Override")
+		its.Slice(its.EqEq("csv"), its.EqEq("tsv")).
+			Match(testee.Choices()).OrError(t)
+		its.Error(flags.ErrNotInChoices).Match(testee.Set("json")).OrError(t)
+	})
+}
+
+func TestFlag_textUnmarshaler(t *testing.T) {
+	type F struct {
+		Addr   netip.Addr `help:"address"`
+		Level  *slog.Level
+		Addrs  []netip.Addr
+		NoAddr *netip.Addr
+	}
+
+	level := slog.LevelWarn
+	flg := &F{
+		Addr:  netip.MustParseAddr("127.0.0.1"),
+		Level: &level,
+	}
+	rflg := reflect.ValueOf(flg).Elem()
+	rtype := rflg.Type()
+
+	newFlag := func(t *testing.T, field string) flags.Flag {
+		rf, ok := rtype.FieldByName(field)
+		if !ok {
+			t.Fatalf("field %s is not found", field)
+		}
+		testee, err := flags.New(rf, rflg.FieldByName(field))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return testee
+	}
+
+	t.Run("value", func(t *testing.T) {
+		testee := newFlag(t, "Addr")
+		its.EqEq("--addr=127.0.0.1").Match(testee.Usage()).OrError(t)
+
+		its.Nil[error]().Match(testee.Set("192.0.2.1")).OrError(t)
+		its.EqEq(netip.MustParseAddr("192.0.2.1")).Match(flg.Addr).OrError(t)
+
+		its.Error(flags.ErrParse).Match(testee.Set("not-an-address")).OrError(t)
+		its.Error(flags.ErrValueRequired).Match(testee.Found()).OrError(t)
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		testee := newFlag(t, "Level")
+		its.EqEq("--level=WARN").Match(testee.Usage()).OrError(t)
+
+		its.Nil[error]().Match(testee.Set("debug")).OrError(t)
+		its.Pointer(its.EqEq(slog.LevelDebug)).Match(flg.Level).OrError(t)
+	})
+
+	t.Run("nil pointer", func(t *testing.T) {
+		testee := newFlag(t, "NoAddr")
+		its.EqEq("--no-addr").Match(testee.Usage()).OrError(t)
+	})
+
+	t.Run("slice", func(t *testing.T) {
+		testee := newFlag(t, "Addrs")
+		its.EqEq("--addrs").Match(testee.Usage()).OrError(t)
+
+		its.Nil[error]().Match(testee.Set("192.0.2.1")).OrError(t)
+		its.Nil[error]().Match(testee.Set("::1")).OrError(t)
+		its.Slice(
+			its.EqEq(netip.MustParseAddr("192.0.2.1")),
+			its.EqEq(netip.MustParseAddr("::1")),
+		).Match(flg.Addrs).OrError(t)
+	})
+}
+
+func TestFlag_func(t *testing.T) {
+	type F struct {
+		Callback func(string) error
+	}
+
+	given := []string{}
+	flg := F{
+		Callback: func(s string) error {
+			given = append(given, s)
+			if s == "bad" {
+				return flags.ErrParse
+			}
+			return nil
+		},
+	}
+	rflg := reflect.ValueOf(flg)
+	rf, ok := rflg.Type().FieldByName("Callback")
+	if !ok {
+		t.Fatal("field Callback is not found")
+	}
+
+	testee, err := flags.New(rf, rflg.FieldByName("Callback"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	its.Nil[error]().Match(testee.Set("good")).OrError(t)
+	its.Error(flags.ErrParse).Match(testee.Set("bad")).OrError(t)
+	its.Slice(its.EqEq("good"), its.EqEq("bad")).Match(given).OrError(t)
+}
+
+func TestFlag_default(t *testing.T) {
+	type F struct {
+		Timeout time.Duration `metavar:"DURATION"`
+		Format  format        `help:"output format"`
+		Count   int
+		Name    *string
+		Names   []string
+		Counts  *[]int
+		Formats []format `choices:"json,yaml"`
+		Empty   []string
+	}
+
+	flg := &F{
+		Timeout: 3 * time.Second, Format: "yaml", Count: 4,
+		Names: []string{"x", "y"}, Counts: &[]int{1, 2}, Formats: []format{"json", "yaml"},
+		Empty: []string{},
+	}
+	rflg := reflect.ValueOf(flg).Elem()
+	rtype := rflg.Type()
+
+	theory := func(field string, wantUsage string, wantDefault string) func(*testing.T) {
+		return func(t *testing.T) {
+			rf, ok := rtype.FieldByName(field)
+			if !ok {
+				t.Fatalf("field %s is not found", field)
+			}
+			testee, err := flags.New(rf, rflg.FieldByName(field))
+			if err != nil {
+				t.Fatal(err)
+			}
+			its.EqEq(wantUsage).Match(testee.Usage()).OrError(t)
+			its.EqEq(wantDefault).Match(testee.Default()).OrError(t)
+			its.EqEq(field).Match(testee.Field()).OrError(t)
+		}
+	}
+
+	t.Run("with metavar", theory("Timeout", "--timeout=DURATION", "3s"))
+	t.Run("with choices", theory("Format", "--format=json|yaml|table", "yaml"))
+	t.Run("plain", theory("Count", "--count=4", "4"))
+	t.Run("nil pointer", theory("Name", "--name", ""))
+	t.Run("slice", theory("Names", "--names", "x,y"))
+	t.Run("pointer to slice", theory("Counts", "--counts", "1,2"))
+	t.Run("slice with choices", theory("Formats", "--formats=json|yaml", "json,yaml"))
+	t.Run("empty slice", theory("Empty", "--empty", ""))
+}
@@ -1,7 +1,9 @@
 package flags
 
 import (
+	"encoding"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -70,3 +72,14 @@ func readTime(format string) func(s string) (time.Time, error) {
 		return t, fmt.Errorf("%w: %s is not timestamp", ErrParse, s)
 	}
 }
+
+func readText(typ reflect.Type) func(s string) (any, error) {
+	return func(s string) (any, error) {
+		p := reflect.New(typ)
+		err := p.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		if err == nil {
+			return p.Elem().Interface(), nil
+		}
+		return nil, fmt.Errorf("%w: %s is not %s: %w", ErrParse, s, typ, err)
+	}
+}
@@ -0,0 +1,248 @@
+package flags
+
+import (
+	"encoding"
+	"errors"
+	goflag "flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewTyped creates a flag from the struct field named field with tag, as New does, without reflection.
+//
+// F is the type of the field, and L is its leaf type, that is, F without pointers and slices.
+// L should be string, bool, numbers, time.Duration, time.Time,
+// or a type implementing encoding.TextUnmarshaler with a pointer receiver.
+// For types whose underlying type is string, use NewTypedString.
+// For fields typed func(string) error or flag.Value, L is ignored.
+//
+// # Args
+//
+// - def: the value of the field in flagdef.
+//
+// - set: returns a function storing a value into dest.
+//
+// - get: returns values of the field for the default value,
+// that is, values pointed by pointers and values in slices. Nil pointers have no values.
+// If get is nil, the flag has no default values.
+//
+// # Returns
+//
+// - Flag: the flag.
+//
+// - func(*F) Flag: returns a copy of the flag storing values into dest.
+//
+// - error: if the tag is invalid or L is not supported.
+func NewTyped[F, L any](
+	field string, tag reflect.StructTag, def F,
+	set func(dest *F) func(L), get func(F) []L,
+	options ...Option,
+) (Flag, func(*F) Flag, error) {
+	if f, ok, err := newCallbackFlag(field, tag, def, options...); ok {
+		if err != nil {
+			return nil, nil, err
+		}
+		// callbacks are bound as New does, for the same behavior.
+		return f, func(dest *F) Flag { return f.Bind(reflect.ValueOf(dest).Elem()) }, nil
+	}
+
+	read, format, ok := leafOf[L]()
+	if !ok {
+		return nil, nil, errors.New("unsupported type")
+	}
+	return newTyped(field, tag, def, set, get, read, format, options...)
+}
+
+// NewTypedString is NewTyped for fields whose leaf type L has string as its underlying type,
+// like `type Format string`.
+func NewTypedString[F any, L ~string](
+	field string, tag reflect.StructTag, def F,
+	set func(dest *F) func(L), get func(F) []L,
+	options ...Option,
+) (Flag, func(*F) Flag, error) {
+	read := func(s string) (L, error) { return L(s), nil }
+	format := func(v L) string { return string(v) }
+	return newTyped(field, tag, def, set, get, read, format, options...)
+}
+
+// newCallbackFlag creates a flag for fields typed func(string) error or flag.Value.
+//
+// Such flags pass values to def, instead of storing them into fields.
+// If def is not one of them, it returns false.
+func newCallbackFlag[F any](field string, tag reflect.StructTag, def F, options ...Option) (Flag, bool, error) {
+	f := flag[string]{set: func(string) {}, translator: readString}
+	metavar := ""
+	switch d := any(def).(type) {
+	case func(string) error:
+		f.callback, f.bindCallback = d, callFunc
+	case goflag.Value:
+		metavar = d.String()
+		f.callback, f.bindCallback = d.Set, setValue
+	default:
+		return nil, false, nil
+	}
+
+	d, err := parseDecl(field, tag, options...)
+	if err != nil {
+		return nil, true, err
+	}
+	if d.metavar == "" {
+		d.metavar = metavar
+	}
+	completed, err := complete(f.withDecl(d, typeOf[F]()), field, tag, metavar, nil)
+	return completed, true, err
+}
+
+func newTyped[F, L any](
+	field string, tag reflect.StructTag, def F,
+	set func(dest *F) func(L), get func(F) []L,
+	read func(string) (L, error), format func(L) string,
+	options ...Option,
+) (Flag, func(*F) Flag, error) {
+	d, err := parseDecl(field, tag, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defText := ""
+	if get != nil {
+		vals := []string{}
+		for _, v := range get(def) {
+			vals = append(vals, format(v))
+		}
+		defText = strings.Join(vals, ",")
+	}
+	// without tags, metavar is the default value, except for repeatable flags taking values one by one.
+	if d.metavar == "" && !isRepeatable(typeOf[F]()) {
+		d.metavar = defText
+	}
+
+	f := flag[L]{
+		set: set(&def),
+		bind: func(dest reflect.Value) func(L) {
+			return set(dest.Addr().Interface().(*F))
+		},
+		translator: read,
+		action:     func() (L, error) { return *new(L), ErrValueRequired },
+	}
+	if _, ok := any(*new(L)).(bool); ok {
+		f.action = func() (L, error) { return any(true).(L), nil }
+		if d.negatable {
+			f.negated = func() L { return any(false).(L) }
+		}
+	}
+	f = f.withDecl(d, typeOf[F]())
+
+	var choices []string
+	if c, ok := any(new(L)).(interface{ Choices() []string }); ok {
+		choices = c.Choices()
+	}
+	completed, err := complete(f, field, tag, defText, choices)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return completed, func(dest *F) Flag {
+		f := completed.(flag[L])
+		f.set = set(dest)
+		return f
+	}, nil
+}
+
+// withDecl returns a copy of this flag declared by d, for a field typed typ.
+//
+// d.negatable is not applied. Negatable flags should have negated.
+func (f flag[T]) withDecl(d decl, typ reflect.Type) flag[T] {
+	f.name = d.name
+	f.alias = d.alias
+	f.help = d.help
+	f.metaValue = d.metavar
+	f.env = d.env
+	f.required = d.required
+	f.typ = typ
+	return f
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// leafOf returns functions reading and formatting values of L, as New does for leaf types of fields.
+//
+// If L is not supported, it returns false.
+func leafOf[L any]() (func(string) (L, error), func(L) string, bool) {
+	var read, format any
+	switch any(*new(L)).(type) {
+	case string:
+		read, format = readString, func(d string) string { return d }
+	case bool:
+		read, format = readBool, func(d bool) string { return fmt.Sprintf("%#v", d) }
+	case int:
+		read, format = readInt[int], formatInt[int]
+	case int8:
+		read, format = readInt[int8], formatInt[int8]
+	case int16:
+		read, format = readInt[int16], formatInt[int16]
+	case int32:
+		read, format = readInt[int32], formatInt[int32]
+	case int64:
+		read, format = readInt[int64], formatInt[int64]
+	case uint:
+		read, format = readUint[uint], formatInt[uint]
+	case uint8:
+		read, format = readUint[uint8], formatInt[uint8]
+	case uint16:
+		read, format = readUint[uint16], formatInt[uint16]
+	case uint32:
+		read, format = readUint[uint32], formatInt[uint32]
+	case uint64:
+		read, format = readUint[uint64], formatInt[uint64]
+	case float32:
+		read = readFloat[float32]
+		format = func(d float32) string { return strconv.FormatFloat(float64(d), 'f', -1, 32) }
+	case float64:
+		read = readFloat[float64]
+		format = func(d float64) string { return strconv.FormatFloat(d, 'f', -1, 64) }
+	case time.Duration:
+		read, format = readDuration, time.Duration.String
+	case time.Time:
+		read = readTime(time.RFC3339Nano)
+		format = func(d time.Time) string { return d.Format(time.RFC3339Nano) }
+	default:
+		if _, ok := any(new(L)).(encoding.TextUnmarshaler); !ok {
+			return nil, nil, false
+		}
+		return readTypedText[L], formatTypedText[L], true
+	}
+	return read.(func(string) (L, error)), format.(func(L) string), true
+}
+
+func formatInt[I int | int8 | int16 | int32 | int64 | uint | uint8 | uint16 | uint32 | uint64](d I) string {
+	return fmt.Sprintf("%d", d)
+}
+
+// readTypedText is readText for L, without reflection.
+func readTypedText[L any](s string) (L, error) {
+	v := new(L)
+	err := any(v).(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	if err == nil {
+		return *v, nil
+	}
+	return *new(L), fmt.Errorf("%w: %s is not %s: %w", ErrParse, s, typeOf[L](), err)
+}
+
+// formatTypedText is marshalText for L, without reflection.
+func formatTypedText[L any](d L) string {
+	m, ok := any(&d).(encoding.TextMarshaler)
+	if !ok {
+		return ""
+	}
+	b, err := m.MarshalText()
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
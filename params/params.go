@@ -18,7 +18,54 @@ func (a ArgDef) Freeze() Arg {
 var ErrParse = flags.ErrParse
 var ErrPushBack = flags.ErrPushBack
 var ErrValueRequired = flags.ErrValueRequired
+var ErrNotNegatable = flags.ErrNotNegatable
+var ErrNotInChoices = flags.ErrNotInChoices
 
-func NewFlag(tfld reflect.StructField, dest reflect.Value) (Flag, error) {
-	return flags.New(tfld, dest)
+type FlagOption = flags.Option
+
+// WithEnvPrefix binds environment variables to flags without env tag.
+//
+// See WithEnvPrefix in params/internal/flags for names of variables.
+func WithEnvPrefix(prefix string) FlagOption {
+	return flags.WithEnvPrefix(prefix)
+}
+
+func NewFlag(tfld reflect.StructField, dest reflect.Value, options ...FlagOption) (Flag, error) {
+	return flags.New(tfld, dest, options...)
+}
+
+// NewTypedFlag creates a flag as NewFlag does, without reflection.
+//
+// This is for parsers generated by flarcgen.
+// F is the type of the field, and L is its leaf type, that is, F without pointers and slices.
+//
+// def is the value of the field in flagdef.
+// set returns a function storing a value into dest.
+// get returns values of the field for the default value, that is, values pointed by pointers and values in slices.
+//
+// It returns the flag and a function returning a copy of the flag storing values into dest.
+func NewTypedFlag[F, L any](
+	field string, tag reflect.StructTag, def F,
+	set func(dest *F) func(L), get func(F) []L,
+	options ...FlagOption,
+) (Flag, func(*F) Flag, error) {
+	f, bind, err := flags.NewTyped(field, tag, def, set, get, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func(dest *F) Flag { return bind(dest) }, nil
+}
+
+// NewTypedStringFlag is NewTypedFlag for fields whose leaf type has string as its underlying type,
+// like `type Format string`.
+func NewTypedStringFlag[F any, L ~string](
+	field string, tag reflect.StructTag, def F,
+	set func(dest *F) func(L), get func(F) []L,
+	options ...FlagOption,
+) (Flag, func(*F) Flag, error) {
+	f, bind, err := flags.NewTypedString(field, tag, def, set, get, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func(dest *F) Flag { return bind(dest) }, nil
 }
@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/youta-t/flarc/params"
+	"github.com/youta-t/flarc/utils"
+)
+
+// TypedArgs binds positional arguments to fields of struct A.
+type TypedArgs[A any] interface {
+	// Defs returns declarations of positional arguments, in order of fields.
+	Defs() []params.ArgDef
+
+	// Bind converts args, returned from Parser.Parse, into a new A.
+	//
+	// Each call starts from a copy of argdef passed to NewTypedArgs.
+	// Args not given keep values in argdef.
+	//
+	// Values which cannot be converted are reported as ParseError, without its index.
+	// To report them with indices, and with other usage errors, convert args in Parse with BindArgs.
+	Bind(args map[string][]string) (*A, error)
+}
+
+// BindArgs makes Parse to convert positional args into a new A, as TypedArgs.Bind does.
+//
+// If Parse succeeds, the converted value is stored into dest.
+// Values which cannot be converted are reported as ParseError locating them in args of Parse,
+// joined with other usage errors.
+func BindArgs[A any](ta TypedArgs[A], dest **A) ParseOption {
+	return func(po *parseOption) *parseOption {
+		po.bindArgs = func(args map[string][]string, locate func(name string, i int) int) error {
+			var bound *A
+			var err error
+			if t, ok := ta.(*typedArgs[A]); ok {
+				bound, err = t.bind(args, locate)
+			} else {
+				bound, err = ta.Bind(args)
+			}
+			if err != nil {
+				return err
+			}
+			*dest = bound
+			return nil
+		}
+		return po
+	}
+}
+
+// NewTypedArgs declares positional arguments with struct fields.
+//
+// Fields are converted in the same way as flags.
+// Slice fields are repeatable.
+//
+// Recognized tag keys are:
+//
+// - arg: name of the arg. By default, the field name in upper snake case.
+// Set "-" to ignore the field.
+//
+// - required: set "true" if the arg is required.
+//
+// - help: help message.
+//
+// - choices: comma separated values which the arg accepts.
+func NewTypedArgs[A any](argdef *A) (TypedArgs[A], error) {
+	refd := reflect.ValueOf(argdef).Elem()
+	trefd := refd.Type()
+	if trefd.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("args should be struct, but %s", trefd)
+	}
+
+	ta := &typedArgs[A]{defaults: *argdef}
+	names := map[string]struct{}{}
+	for i := 0; i < trefd.NumField(); i += 1 {
+		ref := trefd.Field(i)
+
+		name := ref.Tag.Get("arg")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToUpper(strings.ReplaceAll(utils.ToKebab(ref.Name), "-", "_"))
+		}
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("duplicated arg name: %s", name)
+		}
+		names[name] = struct{}{}
+
+		required := false
+		if s, ok := ref.Tag.Lookup("required"); ok {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, fmt.Errorf("tag required should be bool, but %s: %s", s, ref.Name)
+			}
+			required = b
+		}
+
+		conv, err := params.NewFlag(ref, refd.Field(i))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, ref.Name)
+		}
+
+		ta.defs = append(ta.defs, params.ArgDef{
+			Name:       name,
+			Required:   required,
+			Repeatable: repeatable(ref.Type),
+			Help:       ref.Tag.Get("help"),
+		})
+		ta.convs = append(ta.convs, conv)
+		ta.fields = append(ta.fields, i)
+	}
+
+	return ta, nil
+}
+
+type typedArgs[A any] struct {
+	// defaults is a copy of argdef. It is never modified after NewTypedArgs.
+	defaults A
+
+	defs []params.ArgDef
+
+	// convs[i] converts values of defs[i].
+	convs []params.Flag
+
+	// fields[i] is the index of the struct field for defs[i]
+	fields []int
+}
+
+func (ta *typedArgs[A]) Defs() []params.ArgDef {
+	return ta.defs
+}
+
+func (ta *typedArgs[A]) Bind(args map[string][]string) (*A, error) {
+	return ta.bind(args, func(string, int) int { return -1 })
+}
+
+// bind converts args into a new A.
+//
+// locate returns the index of args[name][i] for ParseError.
+// All values which cannot be converted are reported, joined with errors.Join.
+func (ta *typedArgs[A]) bind(args map[string][]string, locate func(name string, i int) int) (*A, error) {
+	dest := new(A)
+	*dest = ta.defaults
+
+	errs := []error{}
+	rdest := reflect.ValueOf(dest).Elem()
+	for i, d := range ta.defs {
+		vals := args[d.Name]
+		if len(vals) == 0 {
+			continue
+		}
+		field := rdest.Field(ta.fields[i])
+		conv := ta.convs[i].Bind(field)
+		for j, v := range vals {
+			if err := conv.Set(v); err != nil {
+				errs = append(errs, &ParseError{
+					Index: locate(d.Name, j), Token: v, Given: d.Name,
+					Arg: d.Freeze(), Type: field.Type(), Err: err,
+				})
+			}
+		}
+	}
+	if 0 < len(errs) {
+		return nil, errors.Join(errs...)
+	}
+	return dest, nil
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/youta-t/flarc/params"
+)
+
+// ErrFlagConflict is returned when flags share a name.
+var ErrFlagConflict = errors.New("flag name conflicts")
+
+// flagNames maps names of flags, with leading hyphens, to flags declaring them.
+type flagNames map[string]params.Flag
+
+// namesOf returns names and aliases of f, with leading hyphens.
+//
+// Negations, like "--no-name", are included for negatable flags.
+func namesOf(f params.Flag) []string {
+	names := append([]string{f.Name()}, f.Alias()...)
+	if !f.Negatable() {
+		return names
+	}
+	for _, n := range names {
+		if l, ok := strings.CutPrefix(n, "--"); ok {
+			names = append(names, "--no-"+l)
+		}
+	}
+	return names
+}
+
+// declaration describes where name of f comes from, for error messages.
+func declaration(f params.Flag, name string) string {
+	for _, n := range append([]string{f.Name()}, f.Alias()...) {
+		if n == name {
+			return "field " + f.Field()
+		}
+	}
+	return "negation of field " + f.Field()
+}
+
+// declare adds names of f.
+//
+// If some of them are already declared, it returns ErrFlagConflict naming both fields.
+func (fn flagNames) declare(f params.Flag) error {
+	for _, n := range namesOf(f) {
+		if prev, ok := fn[n]; ok {
+			return fmt.Errorf(
+				"%w: %s is declared by %s and %s",
+				ErrFlagConflict, n, declaration(prev, n), declaration(f, n),
+			)
+		}
+	}
+	for _, n := range namesOf(f) {
+		fn[n] = f
+	}
+	return nil
+}
+
+// CheckFlagConflicts reports ErrFlagConflict if flags in inner share names with ones in outer.
+//
+// outer are flags parsed before inner, like flags of command groups for ones of their subcommands.
+// Flags in inner sharing names with outer cannot be given, because outer takes them.
+func CheckFlagConflicts(outer, inner []params.Flag) error {
+	names := flagNames{}
+	for _, f := range outer {
+		if err := names.declare(f); err != nil {
+			return err
+		}
+	}
+	for _, f := range inner {
+		if err := names.declare(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
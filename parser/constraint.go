@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/youta-t/flarc/flarcerror"
+	"github.com/youta-t/flarc/params"
+)
+
+// Constraint is a relationship among flags, checked after parsing.
+//
+// A flag is treated as "given" when it is set by the commandline,
+// environment variables or configuration files.
+type Constraint struct {
+	kind  constraintKind
+	flags []string
+}
+
+type constraintKind int
+
+const (
+	kindRequires constraintKind = iota
+	kindMutuallyExclusive
+	kindAtLeastOneOf
+)
+
+// Requires declares that when flag is given, all of requirements should be given too.
+//
+// Flags can be specified by name or alias, with or without leading "-".
+//
+// This is also declared with tag, like `requires:"cert,ca"`.
+func Requires(flag string, requirements ...string) Constraint {
+	return Constraint{kind: kindRequires, flags: append([]string{flag}, requirements...)}
+}
+
+// MutuallyExclusive declares that at most one of flags can be given.
+//
+// Flags can be specified by name or alias, with or without leading "-".
+func MutuallyExclusive(flags ...string) Constraint {
+	return Constraint{kind: kindMutuallyExclusive, flags: flags}
+}
+
+// AtLeastOneOf declares that one or more of flags should be given.
+//
+// Flags can be specified by name or alias, with or without leading "-".
+func AtLeastOneOf(flags ...string) Constraint {
+	return Constraint{kind: kindAtLeastOneOf, flags: flags}
+}
+
+// WithConstraints adds constraints among flags.
+func WithConstraints(cs ...Constraint) Option {
+	return func(o *option) *option {
+		o.constraints = append(o.constraints, cs...)
+		return o
+	}
+}
+
+func (c Constraint) String() string {
+	switch c.kind {
+	case kindRequires:
+		return fmt.Sprintf("%s requires %s", c.flags[0], strings.Join(c.flags[1:], ", "))
+	case kindMutuallyExclusive:
+		return fmt.Sprintf("%s are mutually exclusive", strings.Join(c.flags, ", "))
+	case kindAtLeastOneOf:
+		return fmt.Sprintf("at least one of %s is required", strings.Join(c.flags, ", "))
+	}
+	return fmt.Sprintf("Constraint(%d)", c.kind)
+}
+
+// resolve replaces flag names in c with Name() of flags.
+func (c Constraint) resolve(flags []params.Flag) (Constraint, error) {
+	if len(c.flags) < 2 {
+		return c, fmt.Errorf("constraint should have 2 or more flags: %s", strings.Join(c.flags, ", "))
+	}
+
+	resolved := make([]string, len(c.flags))
+	for i, name := range c.flags {
+		f := findFlag(flags, strings.TrimLeft(name, "-"))
+		if f == nil {
+			return c, fmt.Errorf("constraint for unknown flag: %s", name)
+		}
+		resolved[i] = f.Name()
+	}
+	return Constraint{kind: c.kind, flags: resolved}, nil
+}
+
+// check returns ErrConstraint wrapped error when c is violated.
+func (c Constraint) check(given func(name string) bool) error {
+	switch c.kind {
+	case kindRequires:
+		if !given(c.flags[0]) {
+			return nil
+		}
+		missing := []string{}
+		for _, f := range c.flags[1:] {
+			if !given(f) {
+				missing = append(missing, f)
+			}
+		}
+		if 0 < len(missing) {
+			return fmt.Errorf("%w: %s requires %s", ErrConstraint, c.flags[0], strings.Join(missing, ", "))
+		}
+	case kindMutuallyExclusive:
+		found := []string{}
+		for _, f := range c.flags {
+			if given(f) {
+				found = append(found, f)
+			}
+		}
+		if 1 < len(found) {
+			return fmt.Errorf("%w: %s are mutually exclusive", ErrConstraint, strings.Join(found, ", "))
+		}
+	case kindAtLeastOneOf:
+		for _, f := range c.flags {
+			if given(f) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrConstraint, c)
+	}
+	return nil
+}
+
+// validate checks required flags and constraints.
+//
+// It returns all violations joined with errors.Join, or nil if no violations.
+func validate(flags []params.Flag, constraints []Constraint, sources Sources) error {
+	given := func(name string) bool {
+		return sources[strings.TrimLeft(name, "-")] != SourceDefault
+	}
+
+	errs := []error{}
+	for _, f := range flags {
+		if f.Required() && !given(f.Name()) {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrRequiredFlag, f.Name()))
+		}
+	}
+	for _, c := range constraints {
+		if err := c.check(given); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ErrRequiredFlag is returned when required flags are not given.
+var ErrRequiredFlag = fmt.Errorf("%w: required flag is not given", flarcerror.ErrUsage)
+
+// ErrConstraint is returned when given flags violate constraints.
+var ErrConstraint = fmt.Errorf("%w: constraint violated", flarcerror.ErrUsage)
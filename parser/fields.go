@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"reflect"
+
+	"github.com/youta-t/flarc/params"
+)
+
+// Field declares a flag for a field of the struct T.
+//
+// Parsers generated by flarcgen declare fields with TypedField and StringField,
+// instead of reflection.
+type Field[T any] struct {
+	tag reflect.StructTag
+
+	// newFlag creates the flag from flagdef, and a function binding it to the field in dest.
+	newFlag func(flagdef *T, options ...params.FlagOption) (params.Flag, func(dest *T) params.Flag, error)
+}
+
+// TypedField declares a flag for the field name of T, typed F.
+//
+// L is the leaf type of F, that is, F without pointers and slices.
+// field returns the pointer to the field in T.
+// For set and get, see params.NewTypedFlag.
+func TypedField[T, F, L any](
+	name string, tag reflect.StructTag,
+	field func(*T) *F, set func(dest *F) func(L), get func(F) []L,
+) Field[T] {
+	return Field[T]{
+		tag: tag,
+		newFlag: func(flagdef *T, options ...params.FlagOption) (params.Flag, func(*T) params.Flag, error) {
+			f, bind, err := params.NewTypedFlag(name, tag, *field(flagdef), set, get, options...)
+			if err != nil {
+				return nil, nil, err
+			}
+			return f, func(dest *T) params.Flag { return bind(field(dest)) }, nil
+		},
+	}
+}
+
+// StringField is TypedField for fields whose leaf type L has string as its underlying type,
+// like `type Format string`.
+func StringField[T, F any, L ~string](
+	name string, tag reflect.StructTag,
+	field func(*T) *F, set func(dest *F) func(L), get func(F) []L,
+) Field[T] {
+	return Field[T]{
+		tag: tag,
+		newFlag: func(flagdef *T, options ...params.FlagOption) (params.Flag, func(*T) params.Flag, error) {
+			f, bind, err := params.NewTypedStringFlag(name, tag, *field(flagdef), set, get, options...)
+			if err != nil {
+				return nil, nil, err
+			}
+			return f, func(dest *T) params.Flag { return bind(field(dest)) }, nil
+		},
+	}
+}
+
+// reflectFields declares flags for all fields of T, with reflection.
+func reflectFields[T any]() []Field[T] {
+	trefd := reflect.TypeOf((*T)(nil)).Elem()
+	fields := make([]Field[T], trefd.NumField())
+	for i := range fields {
+		i, ref := i, trefd.Field(i)
+		fields[i] = Field[T]{
+			tag: ref.Tag,
+			newFlag: func(flagdef *T, options ...params.FlagOption) (params.Flag, func(*T) params.Flag, error) {
+				f, err := params.NewFlag(ref, reflect.ValueOf(flagdef).Elem().Field(i), options...)
+				if err != nil {
+					return nil, nil, err
+				}
+				return f, func(dest *T) params.Flag {
+					return f.Bind(reflect.ValueOf(dest).Elem().Field(i))
+				}, nil
+			},
+		}
+	}
+	return fields
+}
@@ -0,0 +1,120 @@
+package parser_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/youta-t/flarc/params"
+	"github.com/youta-t/flarc/parser"
+	"github.com/youta-t/flarc/parser/internal"
+	"github.com/youta-t/its"
+)
+
+// constructor creates a parser for T, like parser.New and ones generated by flarcgen.
+type constructor[T any] func(*T, []params.ArgDef, ...parser.Option) (parser.Parser[T], error)
+
+// forEachConstructor runs test with parser.New and the parser generated by flarcgen,
+// to assert that they behave in the same way.
+func forEachConstructor[T any](t *testing.T, generated constructor[T], test func(t *testing.T, newParser constructor[T])) {
+	t.Helper()
+	t.Run("parser.New", func(t *testing.T) { test(t, parser.New[T]) })
+	t.Run("generated", func(t *testing.T) { test(t, generated) })
+}
+
+// testDeclarations asserts that the parser generated by flarcgen declares flags as the one created by parser.New.
+func testDeclarations[T any](
+	t *testing.T, flagdef func() *T, generated constructor[T], options ...parser.Option,
+) {
+	t.Helper()
+
+	want, err := parser.New(flagdef(), []params.ArgDef{}, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generated(flagdef(), []params.ArgDef{}, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	its.EqEq(want.String()).Match(got.String()).OrError(t)
+
+	wantFlags, gotFlags := []its.Matcher[string]{}, []string{}
+	for _, f := range want.Flags() {
+		wantFlags = append(wantFlags, its.EqEq(describeFlag(f)))
+	}
+	for _, f := range got.Flags() {
+		gotFlags = append(gotFlags, describeFlag(f))
+	}
+	its.Slice(wantFlags...).Match(gotFlags).OrError(t)
+
+	wantConstraints, gotConstraints := []its.Matcher[string]{}, []string{}
+	for _, c := range want.Constraints() {
+		wantConstraints = append(wantConstraints, its.EqEq(c.String()))
+	}
+	for _, c := range got.Constraints() {
+		gotConstraints = append(gotConstraints, c.String())
+	}
+	its.Slice(wantConstraints...).Match(gotConstraints).OrError(t)
+}
+
+func describeFlag(f params.Flag) string {
+	return fmt.Sprintf(
+		"name=%s alias=%v usage=%s help=%q required=%t negatable=%t needs-value=%t "+
+			"type=%v choices=%v env=%s default=%q field=%s",
+		f.Name(), f.Alias(), f.Usage(), f.Help(), f.Required(), f.Negatable(), f.NeedsValue(),
+		f.Type(), f.Choices(), f.Env(), f.Default(), f.Field(),
+	)
+}
+
+func TestNewFromFields_declarations(t *testing.T) {
+	t.Run("basic types", func(t *testing.T) {
+		testDeclarations(
+			t,
+			func() *internal.Flag {
+				return &internal.Flag{
+					StringFlag:   "default",
+					IntFlag:      1,
+					Float64Flag:  12.25,
+					DulationFlag: 13 * time.Second,
+					TimeFlag:     MustTime(t, "2024-01-02T03:04:05+00:00"),
+					VarFlag:      &SimpleVar{Value: "var flag"},
+				}
+			},
+			internal.NewFlagParser,
+		)
+	})
+
+	t.Run("pointers and slices", func(t *testing.T) {
+		testDeclarations(
+			t,
+			func() *internal.Shapes {
+				return &internal.Shapes{
+					IntpFlag: ptr(3), IntsFlag: []int{1, 2}, IntpsFlag: []*int{ptr(3), nil, ptr(4)},
+					IntspFlag: &[]int{5, 6}, Names: []string{"x", "y"},
+				}
+			},
+			internal.NewShapesParser,
+		)
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		testDeclarations(
+			t,
+			func() *internal.Tagged { return &internal.Tagged{Timeout: time.Second, Format: "json"} },
+			internal.NewTaggedParser,
+			parser.WithEnvPrefix("APP"),
+			parser.WithConstraints(parser.MutuallyExclusive("json", "color")),
+		)
+	})
+
+	t.Run("other kinds", func(t *testing.T) {
+		testDeclarations(
+			t,
+			func() *internal.Kinds {
+				return &internal.Kinds{Level: "info", Addr: internal.Addr{Host: "localhost", Port: 8080}}
+			},
+			internal.NewKindsParser,
+		)
+	})
+}
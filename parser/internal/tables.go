@@ -0,0 +1,79 @@
+//go:generate go run github.com/youta-t/flarc/flarcgen -type Cluster,Negation,Env,Config,Sources,Constraints,Unknown,Located,Collected
+
+package internal
+
+import "time"
+
+// Cluster has flags given as clustered short flags.
+type Cluster struct {
+	A bool
+	B bool
+	N int
+	O string
+}
+
+// Negation has negatable and not negatable flags.
+type Negation struct {
+	Color   bool  `alias:"c,colour"`
+	Verbose *bool `alias:"v"`
+	Force   bool  `negatable:"false"`
+}
+
+// Env has flags read from environment variables.
+type Env struct {
+	Timeout time.Duration `env:"TIMEOUT"`
+	Names   []string
+	DryRun  bool
+	Secret  string `env:"-"`
+}
+
+// Config has flags read from configurations.
+type Config struct {
+	Count   int
+	Timeout time.Duration
+	Names   []string
+	Verbose bool
+	Name    string `env:"NAME"`
+}
+
+// Sources has flags given from several sources.
+type Sources struct {
+	Count   int `alias:"c"`
+	Names   []string
+	Verbose bool `alias:"v"`
+	Color   bool
+	Name    string `env:"NAME"`
+	Region  string
+}
+
+// Constraints has flags constrained with each other.
+type Constraints struct {
+	Name  string `required:"true" env:"NAME"`
+	Key   string `requires:"cert"`
+	Cert  string
+	JSON  bool `flag:"json"`
+	YAML  bool `flag:"yaml"`
+	Token string
+	Pass  string `alias:"p"`
+}
+
+// Unknown has flags which unknown flags are mistyped ones of.
+type Unknown struct {
+	Format string `alias:"f"`
+	Force  bool
+	Limit  int
+}
+
+// Located has flags whose errors locate tokens.
+type Located struct {
+	Count int  `alias:"c"`
+	Force bool `alias:"f"`
+}
+
+// Collected has flags whose errors are collected.
+type Collected struct {
+	Count int  `alias:"c"`
+	Limit int  `required:"true"`
+	Json  bool `alias:"j"`
+	Yaml  bool `alias:"y"`
+}
@@ -0,0 +1,484 @@
+// Code generated by flarcgen. DO NOT EDIT.
+
+package internal
+
+import (
+	"time"
+
+	"github.com/youta-t/flarc/params"
+	"github.com/youta-t/flarc/parser"
+)
+
+// NewClusterParser creates a parser for Cluster, as parser.New does, without reflection.
+func NewClusterParser(flagdef *Cluster, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Cluster], error) {
+	fields := []parser.Field[Cluster]{
+		parser.TypedField[Cluster, bool, bool](
+			"A", "",
+			func(t *Cluster) *bool { return &t.A },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Cluster, bool, bool](
+			"B", "",
+			func(t *Cluster) *bool { return &t.B },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Cluster, int, int](
+			"N", "",
+			func(t *Cluster) *int { return &t.N },
+			func(dest *int) func(int) {
+				return func(v int) {
+					*dest = v
+				}
+			},
+			func(f int) []int { return []int{f} },
+		),
+		parser.TypedField[Cluster, string, string](
+			"O", "",
+			func(t *Cluster) *string { return &t.O },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
+
+// NewNegationParser creates a parser for Negation, as parser.New does, without reflection.
+func NewNegationParser(flagdef *Negation, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Negation], error) {
+	fields := []parser.Field[Negation]{
+		parser.TypedField[Negation, bool, bool](
+			"Color", `alias:"c,colour"`,
+			func(t *Negation) *bool { return &t.Color },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Negation, *bool, bool](
+			"Verbose", `alias:"v"`,
+			func(t *Negation) **bool { return &t.Verbose },
+			func(dest **bool) func(bool) {
+				return func(v bool) {
+					p0 := v
+					*dest = &p0
+				}
+			},
+			func(f *bool) []bool {
+				vs := []bool{}
+				if f != nil {
+					vs = append(vs, *f)
+				}
+				return vs
+			},
+		),
+		parser.TypedField[Negation, bool, bool](
+			"Force", `negatable:"false"`,
+			func(t *Negation) *bool { return &t.Force },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
+
+// NewEnvParser creates a parser for Env, as parser.New does, without reflection.
+func NewEnvParser(flagdef *Env, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Env], error) {
+	fields := []parser.Field[Env]{
+		parser.TypedField[Env, time.Duration, time.Duration](
+			"Timeout", `env:"TIMEOUT"`,
+			func(t *Env) *time.Duration { return &t.Timeout },
+			func(dest *time.Duration) func(time.Duration) {
+				return func(v time.Duration) {
+					*dest = v
+				}
+			},
+			func(f time.Duration) []time.Duration { return []time.Duration{f} },
+		),
+		parser.TypedField[Env, []string, string](
+			"Names", "",
+			func(t *Env) *[]string { return &t.Names },
+			func(dest *[]string) func(string) {
+				s0 := []string{}
+				return func(v string) {
+					s0 = append(s0, v)
+					*dest = s0
+				}
+			},
+			func(f []string) []string {
+				vs := []string{}
+				for _, v0 := range f {
+					vs = append(vs, v0)
+				}
+				return vs
+			},
+		),
+		parser.TypedField[Env, bool, bool](
+			"DryRun", "",
+			func(t *Env) *bool { return &t.DryRun },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Env, string, string](
+			"Secret", `env:"-"`,
+			func(t *Env) *string { return &t.Secret },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
+
+// NewConfigParser creates a parser for Config, as parser.New does, without reflection.
+func NewConfigParser(flagdef *Config, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Config], error) {
+	fields := []parser.Field[Config]{
+		parser.TypedField[Config, int, int](
+			"Count", "",
+			func(t *Config) *int { return &t.Count },
+			func(dest *int) func(int) {
+				return func(v int) {
+					*dest = v
+				}
+			},
+			func(f int) []int { return []int{f} },
+		),
+		parser.TypedField[Config, time.Duration, time.Duration](
+			"Timeout", "",
+			func(t *Config) *time.Duration { return &t.Timeout },
+			func(dest *time.Duration) func(time.Duration) {
+				return func(v time.Duration) {
+					*dest = v
+				}
+			},
+			func(f time.Duration) []time.Duration { return []time.Duration{f} },
+		),
+		parser.TypedField[Config, []string, string](
+			"Names", "",
+			func(t *Config) *[]string { return &t.Names },
+			func(dest *[]string) func(string) {
+				s0 := []string{}
+				return func(v string) {
+					s0 = append(s0, v)
+					*dest = s0
+				}
+			},
+			func(f []string) []string {
+				vs := []string{}
+				for _, v0 := range f {
+					vs = append(vs, v0)
+				}
+				return vs
+			},
+		),
+		parser.TypedField[Config, bool, bool](
+			"Verbose", "",
+			func(t *Config) *bool { return &t.Verbose },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Config, string, string](
+			"Name", `env:"NAME"`,
+			func(t *Config) *string { return &t.Name },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
+
+// NewSourcesParser creates a parser for Sources, as parser.New does, without reflection.
+func NewSourcesParser(flagdef *Sources, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Sources], error) {
+	fields := []parser.Field[Sources]{
+		parser.TypedField[Sources, int, int](
+			"Count", `alias:"c"`,
+			func(t *Sources) *int { return &t.Count },
+			func(dest *int) func(int) {
+				return func(v int) {
+					*dest = v
+				}
+			},
+			func(f int) []int { return []int{f} },
+		),
+		parser.TypedField[Sources, []string, string](
+			"Names", "",
+			func(t *Sources) *[]string { return &t.Names },
+			func(dest *[]string) func(string) {
+				s0 := []string{}
+				return func(v string) {
+					s0 = append(s0, v)
+					*dest = s0
+				}
+			},
+			func(f []string) []string {
+				vs := []string{}
+				for _, v0 := range f {
+					vs = append(vs, v0)
+				}
+				return vs
+			},
+		),
+		parser.TypedField[Sources, bool, bool](
+			"Verbose", `alias:"v"`,
+			func(t *Sources) *bool { return &t.Verbose },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Sources, bool, bool](
+			"Color", "",
+			func(t *Sources) *bool { return &t.Color },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Sources, string, string](
+			"Name", `env:"NAME"`,
+			func(t *Sources) *string { return &t.Name },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+		parser.TypedField[Sources, string, string](
+			"Region", "",
+			func(t *Sources) *string { return &t.Region },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
+
+// NewConstraintsParser creates a parser for Constraints, as parser.New does, without reflection.
+func NewConstraintsParser(flagdef *Constraints, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Constraints], error) {
+	fields := []parser.Field[Constraints]{
+		parser.TypedField[Constraints, string, string](
+			"Name", `required:"true" env:"NAME"`,
+			func(t *Constraints) *string { return &t.Name },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+		parser.TypedField[Constraints, string, string](
+			"Key", `requires:"cert"`,
+			func(t *Constraints) *string { return &t.Key },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+		parser.TypedField[Constraints, string, string](
+			"Cert", "",
+			func(t *Constraints) *string { return &t.Cert },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+		parser.TypedField[Constraints, bool, bool](
+			"JSON", `flag:"json"`,
+			func(t *Constraints) *bool { return &t.JSON },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Constraints, bool, bool](
+			"YAML", `flag:"yaml"`,
+			func(t *Constraints) *bool { return &t.YAML },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Constraints, string, string](
+			"Token", "",
+			func(t *Constraints) *string { return &t.Token },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+		parser.TypedField[Constraints, string, string](
+			"Pass", `alias:"p"`,
+			func(t *Constraints) *string { return &t.Pass },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
+
+// NewUnknownParser creates a parser for Unknown, as parser.New does, without reflection.
+func NewUnknownParser(flagdef *Unknown, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Unknown], error) {
+	fields := []parser.Field[Unknown]{
+		parser.TypedField[Unknown, string, string](
+			"Format", `alias:"f"`,
+			func(t *Unknown) *string { return &t.Format },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+		parser.TypedField[Unknown, bool, bool](
+			"Force", "",
+			func(t *Unknown) *bool { return &t.Force },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Unknown, int, int](
+			"Limit", "",
+			func(t *Unknown) *int { return &t.Limit },
+			func(dest *int) func(int) {
+				return func(v int) {
+					*dest = v
+				}
+			},
+			func(f int) []int { return []int{f} },
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
+
+// NewLocatedParser creates a parser for Located, as parser.New does, without reflection.
+func NewLocatedParser(flagdef *Located, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Located], error) {
+	fields := []parser.Field[Located]{
+		parser.TypedField[Located, int, int](
+			"Count", `alias:"c"`,
+			func(t *Located) *int { return &t.Count },
+			func(dest *int) func(int) {
+				return func(v int) {
+					*dest = v
+				}
+			},
+			func(f int) []int { return []int{f} },
+		),
+		parser.TypedField[Located, bool, bool](
+			"Force", `alias:"f"`,
+			func(t *Located) *bool { return &t.Force },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
+
+// NewCollectedParser creates a parser for Collected, as parser.New does, without reflection.
+func NewCollectedParser(flagdef *Collected, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Collected], error) {
+	fields := []parser.Field[Collected]{
+		parser.TypedField[Collected, int, int](
+			"Count", `alias:"c"`,
+			func(t *Collected) *int { return &t.Count },
+			func(dest *int) func(int) {
+				return func(v int) {
+					*dest = v
+				}
+			},
+			func(f int) []int { return []int{f} },
+		),
+		parser.TypedField[Collected, int, int](
+			"Limit", `required:"true"`,
+			func(t *Collected) *int { return &t.Limit },
+			func(dest *int) func(int) {
+				return func(v int) {
+					*dest = v
+				}
+			},
+			func(f int) []int { return []int{f} },
+		),
+		parser.TypedField[Collected, bool, bool](
+			"Json", `alias:"j"`,
+			func(t *Collected) *bool { return &t.Json },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Collected, bool, bool](
+			"Yaml", `alias:"y"`,
+			func(t *Collected) *bool { return &t.Yaml },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
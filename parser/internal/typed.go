@@ -0,0 +1,95 @@
+//go:generate go run github.com/youta-t/flarc/flarcgen -type Flag,Shapes,Tagged,Kinds
+
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shapes has flags of pointers and slices.
+type Shapes struct {
+	IntpFlag  *int
+	IntsFlag  []int
+	IntpsFlag []*int
+	IntspFlag *[]int
+	Names     []string
+	Verbose   *bool `alias:"v"`
+}
+
+// Tagged has flags declared with tags.
+type Tagged struct {
+	Name    string        `required:"true" env:"NAME" help:"your name"`
+	Key     string        `requires:"cert"`
+	Cert    string        `metavar:"FILE"`
+	JSON    bool          `flag:"json" alias:"j"`
+	Color   bool          `alias:"c,colour"`
+	Force   bool          `negatable:"false" alias:"f"`
+	Count   int           `alias:"n"`
+	Timeout time.Duration `env:"TIMEOUT" metavar:"DURATION"`
+	Secret  string        `env:"-"`
+	Format  string        `choices:"json,yaml"`
+}
+
+// Kinds has flags of types without special handlings.
+type Kinds struct {
+	Level    Level
+	Levels   []Level
+	Mode     *Mode `choices:"fast,slow"`
+	Addr     Addr
+	Addrs    []*Addr
+	Callback func(string) error
+	Counter  *Counter
+}
+
+// Level is a string type having choices.
+type Level string
+
+func (Level) Choices() []string {
+	return []string{"debug", "info", "error"}
+}
+
+// Mode is a string type.
+type Mode string
+
+// Addr is a pair of host and port, in text "host:port".
+type Addr struct {
+	Host string
+	Port int
+}
+
+func (a *Addr) UnmarshalText(b []byte) error {
+	host, port, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return fmt.Errorf("port is missing: %s", b)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return err
+	}
+	a.Host, a.Port = host, p
+	return nil
+}
+
+func (a Addr) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%s:%d", a.Host, a.Port)), nil
+}
+
+// Counter is a flag.Value counting how many times it is set.
+type Counter struct {
+	N int
+}
+
+func (c *Counter) String() string {
+	if c == nil {
+		return "0"
+	}
+	return strconv.Itoa(c.N)
+}
+
+func (c *Counter) Set(string) error {
+	c.N += 1
+	return nil
+}
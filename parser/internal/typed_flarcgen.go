@@ -0,0 +1,502 @@
+// Code generated by flarcgen. DO NOT EDIT.
+
+package internal
+
+import (
+	"flag"
+	"time"
+
+	"github.com/youta-t/flarc/params"
+	"github.com/youta-t/flarc/parser"
+)
+
+// NewFlagParser creates a parser for Flag, as parser.New does, without reflection.
+func NewFlagParser(flagdef *Flag, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Flag], error) {
+	fields := []parser.Field[Flag]{
+		parser.TypedField[Flag, string, string](
+			"StringFlag", "",
+			func(t *Flag) *string { return &t.StringFlag },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+		parser.TypedField[Flag, bool, bool](
+			"BoolFlag", "",
+			func(t *Flag) *bool { return &t.BoolFlag },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Flag, int, int](
+			"IntFlag", "",
+			func(t *Flag) *int { return &t.IntFlag },
+			func(dest *int) func(int) {
+				return func(v int) {
+					*dest = v
+				}
+			},
+			func(f int) []int { return []int{f} },
+		),
+		parser.TypedField[Flag, int8, int8](
+			"Int8Flag", "",
+			func(t *Flag) *int8 { return &t.Int8Flag },
+			func(dest *int8) func(int8) {
+				return func(v int8) {
+					*dest = v
+				}
+			},
+			func(f int8) []int8 { return []int8{f} },
+		),
+		parser.TypedField[Flag, int16, int16](
+			"Int16Flag", "",
+			func(t *Flag) *int16 { return &t.Int16Flag },
+			func(dest *int16) func(int16) {
+				return func(v int16) {
+					*dest = v
+				}
+			},
+			func(f int16) []int16 { return []int16{f} },
+		),
+		parser.TypedField[Flag, int32, int32](
+			"Int32Flag", "",
+			func(t *Flag) *int32 { return &t.Int32Flag },
+			func(dest *int32) func(int32) {
+				return func(v int32) {
+					*dest = v
+				}
+			},
+			func(f int32) []int32 { return []int32{f} },
+		),
+		parser.TypedField[Flag, int64, int64](
+			"Int64Flag", "",
+			func(t *Flag) *int64 { return &t.Int64Flag },
+			func(dest *int64) func(int64) {
+				return func(v int64) {
+					*dest = v
+				}
+			},
+			func(f int64) []int64 { return []int64{f} },
+		),
+		parser.TypedField[Flag, uint, uint](
+			"UintFlag", "",
+			func(t *Flag) *uint { return &t.UintFlag },
+			func(dest *uint) func(uint) {
+				return func(v uint) {
+					*dest = v
+				}
+			},
+			func(f uint) []uint { return []uint{f} },
+		),
+		parser.TypedField[Flag, uint8, uint8](
+			"Uint8Flag", "",
+			func(t *Flag) *uint8 { return &t.Uint8Flag },
+			func(dest *uint8) func(uint8) {
+				return func(v uint8) {
+					*dest = v
+				}
+			},
+			func(f uint8) []uint8 { return []uint8{f} },
+		),
+		parser.TypedField[Flag, uint16, uint16](
+			"Uint16Flag", "",
+			func(t *Flag) *uint16 { return &t.Uint16Flag },
+			func(dest *uint16) func(uint16) {
+				return func(v uint16) {
+					*dest = v
+				}
+			},
+			func(f uint16) []uint16 { return []uint16{f} },
+		),
+		parser.TypedField[Flag, uint32, uint32](
+			"Uint32Flag", "",
+			func(t *Flag) *uint32 { return &t.Uint32Flag },
+			func(dest *uint32) func(uint32) {
+				return func(v uint32) {
+					*dest = v
+				}
+			},
+			func(f uint32) []uint32 { return []uint32{f} },
+		),
+		parser.TypedField[Flag, uint64, uint64](
+			"Uint64Flag", "",
+			func(t *Flag) *uint64 { return &t.Uint64Flag },
+			func(dest *uint64) func(uint64) {
+				return func(v uint64) {
+					*dest = v
+				}
+			},
+			func(f uint64) []uint64 { return []uint64{f} },
+		),
+		parser.TypedField[Flag, float32, float32](
+			"Float32Flag", "",
+			func(t *Flag) *float32 { return &t.Float32Flag },
+			func(dest *float32) func(float32) {
+				return func(v float32) {
+					*dest = v
+				}
+			},
+			func(f float32) []float32 { return []float32{f} },
+		),
+		parser.TypedField[Flag, float64, float64](
+			"Float64Flag", "",
+			func(t *Flag) *float64 { return &t.Float64Flag },
+			func(dest *float64) func(float64) {
+				return func(v float64) {
+					*dest = v
+				}
+			},
+			func(f float64) []float64 { return []float64{f} },
+		),
+		parser.TypedField[Flag, time.Duration, time.Duration](
+			"DulationFlag", "",
+			func(t *Flag) *time.Duration { return &t.DulationFlag },
+			func(dest *time.Duration) func(time.Duration) {
+				return func(v time.Duration) {
+					*dest = v
+				}
+			},
+			func(f time.Duration) []time.Duration { return []time.Duration{f} },
+		),
+		parser.TypedField[Flag, time.Time, time.Time](
+			"TimeFlag", "",
+			func(t *Flag) *time.Time { return &t.TimeFlag },
+			func(dest *time.Time) func(time.Time) {
+				return func(v time.Time) {
+					*dest = v
+				}
+			},
+			func(f time.Time) []time.Time { return []time.Time{f} },
+		),
+		parser.TypedField[Flag, flag.Value, flag.Value](
+			"VarFlag", "",
+			func(t *Flag) *flag.Value { return &t.VarFlag },
+			nil, nil,
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
+
+// NewShapesParser creates a parser for Shapes, as parser.New does, without reflection.
+func NewShapesParser(flagdef *Shapes, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Shapes], error) {
+	fields := []parser.Field[Shapes]{
+		parser.TypedField[Shapes, *int, int](
+			"IntpFlag", "",
+			func(t *Shapes) **int { return &t.IntpFlag },
+			func(dest **int) func(int) {
+				return func(v int) {
+					p0 := v
+					*dest = &p0
+				}
+			},
+			func(f *int) []int {
+				vs := []int{}
+				if f != nil {
+					vs = append(vs, *f)
+				}
+				return vs
+			},
+		),
+		parser.TypedField[Shapes, []int, int](
+			"IntsFlag", "",
+			func(t *Shapes) *[]int { return &t.IntsFlag },
+			func(dest *[]int) func(int) {
+				s0 := []int{}
+				return func(v int) {
+					s0 = append(s0, v)
+					*dest = s0
+				}
+			},
+			func(f []int) []int {
+				vs := []int{}
+				for _, v0 := range f {
+					vs = append(vs, v0)
+				}
+				return vs
+			},
+		),
+		parser.TypedField[Shapes, []*int, int](
+			"IntpsFlag", "",
+			func(t *Shapes) *[]*int { return &t.IntpsFlag },
+			func(dest *[]*int) func(int) {
+				s0 := []*int{}
+				return func(v int) {
+					p1 := v
+					s0 = append(s0, &p1)
+					*dest = s0
+				}
+			},
+			func(f []*int) []int {
+				vs := []int{}
+				for _, v0 := range f {
+					if v0 != nil {
+						vs = append(vs, *v0)
+					}
+				}
+				return vs
+			},
+		),
+		parser.TypedField[Shapes, *[]int, int](
+			"IntspFlag", "",
+			func(t *Shapes) **[]int { return &t.IntspFlag },
+			func(dest **[]int) func(int) {
+				s1 := []int{}
+				return func(v int) {
+					s1 = append(s1, v)
+					p0 := s1
+					*dest = &p0
+				}
+			},
+			func(f *[]int) []int {
+				vs := []int{}
+				if f != nil {
+					for _, v1 := range *f {
+						vs = append(vs, v1)
+					}
+				}
+				return vs
+			},
+		),
+		parser.TypedField[Shapes, []string, string](
+			"Names", "",
+			func(t *Shapes) *[]string { return &t.Names },
+			func(dest *[]string) func(string) {
+				s0 := []string{}
+				return func(v string) {
+					s0 = append(s0, v)
+					*dest = s0
+				}
+			},
+			func(f []string) []string {
+				vs := []string{}
+				for _, v0 := range f {
+					vs = append(vs, v0)
+				}
+				return vs
+			},
+		),
+		parser.TypedField[Shapes, *bool, bool](
+			"Verbose", `alias:"v"`,
+			func(t *Shapes) **bool { return &t.Verbose },
+			func(dest **bool) func(bool) {
+				return func(v bool) {
+					p0 := v
+					*dest = &p0
+				}
+			},
+			func(f *bool) []bool {
+				vs := []bool{}
+				if f != nil {
+					vs = append(vs, *f)
+				}
+				return vs
+			},
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
+
+// NewTaggedParser creates a parser for Tagged, as parser.New does, without reflection.
+func NewTaggedParser(flagdef *Tagged, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Tagged], error) {
+	fields := []parser.Field[Tagged]{
+		parser.TypedField[Tagged, string, string](
+			"Name", `required:"true" env:"NAME" help:"your name"`,
+			func(t *Tagged) *string { return &t.Name },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+		parser.TypedField[Tagged, string, string](
+			"Key", `requires:"cert"`,
+			func(t *Tagged) *string { return &t.Key },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+		parser.TypedField[Tagged, string, string](
+			"Cert", `metavar:"FILE"`,
+			func(t *Tagged) *string { return &t.Cert },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+		parser.TypedField[Tagged, bool, bool](
+			"JSON", `flag:"json" alias:"j"`,
+			func(t *Tagged) *bool { return &t.JSON },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Tagged, bool, bool](
+			"Color", `alias:"c,colour"`,
+			func(t *Tagged) *bool { return &t.Color },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Tagged, bool, bool](
+			"Force", `negatable:"false" alias:"f"`,
+			func(t *Tagged) *bool { return &t.Force },
+			func(dest *bool) func(bool) {
+				return func(v bool) {
+					*dest = v
+				}
+			},
+			func(f bool) []bool { return []bool{f} },
+		),
+		parser.TypedField[Tagged, int, int](
+			"Count", `alias:"n"`,
+			func(t *Tagged) *int { return &t.Count },
+			func(dest *int) func(int) {
+				return func(v int) {
+					*dest = v
+				}
+			},
+			func(f int) []int { return []int{f} },
+		),
+		parser.TypedField[Tagged, time.Duration, time.Duration](
+			"Timeout", `env:"TIMEOUT" metavar:"DURATION"`,
+			func(t *Tagged) *time.Duration { return &t.Timeout },
+			func(dest *time.Duration) func(time.Duration) {
+				return func(v time.Duration) {
+					*dest = v
+				}
+			},
+			func(f time.Duration) []time.Duration { return []time.Duration{f} },
+		),
+		parser.TypedField[Tagged, string, string](
+			"Secret", `env:"-"`,
+			func(t *Tagged) *string { return &t.Secret },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+		parser.TypedField[Tagged, string, string](
+			"Format", `choices:"json,yaml"`,
+			func(t *Tagged) *string { return &t.Format },
+			func(dest *string) func(string) {
+				return func(v string) {
+					*dest = v
+				}
+			},
+			func(f string) []string { return []string{f} },
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
+
+// NewKindsParser creates a parser for Kinds, as parser.New does, without reflection.
+func NewKindsParser(flagdef *Kinds, pos []params.ArgDef, options ...parser.Option) (parser.Parser[Kinds], error) {
+	fields := []parser.Field[Kinds]{
+		parser.StringField[Kinds, Level, Level](
+			"Level", "",
+			func(t *Kinds) *Level { return &t.Level },
+			func(dest *Level) func(Level) {
+				return func(v Level) {
+					*dest = v
+				}
+			},
+			func(f Level) []Level { return []Level{f} },
+		),
+		parser.StringField[Kinds, []Level, Level](
+			"Levels", "",
+			func(t *Kinds) *[]Level { return &t.Levels },
+			func(dest *[]Level) func(Level) {
+				s0 := []Level{}
+				return func(v Level) {
+					s0 = append(s0, v)
+					*dest = s0
+				}
+			},
+			func(f []Level) []Level {
+				vs := []Level{}
+				for _, v0 := range f {
+					vs = append(vs, v0)
+				}
+				return vs
+			},
+		),
+		parser.StringField[Kinds, *Mode, Mode](
+			"Mode", `choices:"fast,slow"`,
+			func(t *Kinds) **Mode { return &t.Mode },
+			func(dest **Mode) func(Mode) {
+				return func(v Mode) {
+					p0 := v
+					*dest = &p0
+				}
+			},
+			func(f *Mode) []Mode {
+				vs := []Mode{}
+				if f != nil {
+					vs = append(vs, *f)
+				}
+				return vs
+			},
+		),
+		parser.TypedField[Kinds, Addr, Addr](
+			"Addr", "",
+			func(t *Kinds) *Addr { return &t.Addr },
+			func(dest *Addr) func(Addr) {
+				return func(v Addr) {
+					*dest = v
+				}
+			},
+			func(f Addr) []Addr { return []Addr{f} },
+		),
+		parser.TypedField[Kinds, []*Addr, Addr](
+			"Addrs", "",
+			func(t *Kinds) *[]*Addr { return &t.Addrs },
+			func(dest *[]*Addr) func(Addr) {
+				s0 := []*Addr{}
+				return func(v Addr) {
+					p1 := v
+					s0 = append(s0, &p1)
+					*dest = s0
+				}
+			},
+			func(f []*Addr) []Addr {
+				vs := []Addr{}
+				for _, v0 := range f {
+					if v0 != nil {
+						vs = append(vs, *v0)
+					}
+				}
+				return vs
+			},
+		),
+		parser.TypedField[Kinds, func(string) error, func(string) error](
+			"Callback", "",
+			func(t *Kinds) *func(string) error { return &t.Callback },
+			nil, nil,
+		),
+		parser.TypedField[Kinds, *Counter, *Counter](
+			"Counter", "",
+			func(t *Kinds) **Counter { return &t.Counter },
+			nil, nil,
+		),
+	}
+	return parser.NewFromFields(flagdef, fields, pos, options...)
+}
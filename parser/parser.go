@@ -1,17 +1,32 @@
 package parser
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/youta-t/flarc/flarcerror"
 	"github.com/youta-t/flarc/params"
+	"github.com/youta-t/flarc/utils"
 )
 
 type Parser[T any] interface {
-	Parse([]string) (
+	// Parse parses commandline.
+	//
+	// Each call starts from a copy of flagdef passed to New and returns a new T,
+	// so Parse can be called many times, also concurrently.
+	//
+	// Flags bound to environment variables are set from them before the commandline.
+	//
+	// Parse does not stop at the first usage error.
+	// If it finds many, they are joined with errors.Join.
+	Parse([]string, ...ParseOption) (
 		flags *T,
 		args map[string][]string,
 		rem []string,
@@ -20,15 +35,225 @@ type Parser[T any] interface {
 
 	String() string
 
+	// Flags returns a copy of declarations of flags.
 	Flags() []params.Flag
+
+	// Args returns a copy of declarations of positional args.
 	Args() []params.Arg
+
+	// Constraints returns a copy of constraints among flags, including ones declared with tags.
+	Constraints() []Constraint
+}
+
+// Option configures New.
+type Option func(*option) *option
+
+type option struct {
+	flagOptions []params.FlagOption
+	constraints []Constraint
+}
+
+// WithEnvPrefix binds environment variables to flags without env tag.
+//
+// See params.WithEnvPrefix.
+func WithEnvPrefix(prefix string) Option {
+	return func(o *option) *option {
+		o.flagOptions = append(o.flagOptions, params.WithEnvPrefix(prefix))
+		return o
+	}
+}
+
+// ParseOption configures Parse.
+type ParseOption func(*parseOption) *parseOption
+
+type parseOption struct {
+	lookupEnv      func(string) (string, bool)
+	config         Config
+	sources        Sources
+	allowUnknown   bool
+	skipValidation bool
+	rejectRest     bool
+
+	// bindArgs converts positional args. locate returns the index of args[name][i] in args of Parse.
+	bindArgs    func(args map[string][]string, locate func(name string, i int) int) error
+	restIndices *[]int
+
+	// argIndices[i] is the index of args[i] of Parse in the whole commandline. If nil, they are same.
+	argIndices []int
+
+	// suggesting are flags suggested for unknown flags, other than flags of the parser.
+	suggesting []params.Flag
+}
+
+// Source represents where the value of a flag comes from.
+type Source int
+
+const (
+	// SourceDefault means the flag is not given. The value is the default.
+	SourceDefault Source = iota
+
+	// SourceConfig means the flag is set from a configuration file.
+	SourceConfig
+
+	// SourceEnv means the flag is set from an environment variable.
+	SourceEnv
+
+	// SourceCommandline means the flag is given on the commandline.
+	SourceCommandline
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceConfig:
+		return "config"
+	case SourceEnv:
+		return "env"
+	case SourceCommandline:
+		return "commandline"
+	}
+	return fmt.Sprintf("Source(%d)", int(s))
+}
+
+// Sources maps flag names (without leading "-") to where their values come from.
+//
+// Flags not in Sources are SourceDefault.
+type Sources map[string]Source
+
+// RecordSources makes Parse to record where each flag values come from into sources.
+func RecordSources(sources Sources) ParseOption {
+	return func(po *parseOption) *parseOption {
+		po.sources = sources
+		return po
+	}
+}
+
+// Config is flag values loaded from a configuration file.
+type Config struct {
+	// Key is the location of Values in the configuration file.
+	//
+	// This is used in error messages.
+	Key []string
+
+	// Values maps flag names to values decoded from JSON.
+	//
+	// Values should be string, bool, json.Number (or float64) or slice of them.
+	// Slices are accepted only for repeatable (slice) flags.
+	Values map[string]any
+}
+
+// WithConfig sets flags from cfg.
+//
+// Precedence of values is: defaults < cfg < environment variables < commandline.
+func WithConfig(cfg Config) ParseOption {
+	return func(po *parseOption) *parseOption {
+		po.config = cfg
+		return po
+	}
 }
 
+// AllowUnknownFlags makes Parse to leave unknown flags in args, instead of failing with ErrUnknownFlag.
+//
+// This is for parsers which pass rest of args to other parsers, like ones of command groups,
+// or commands forwarding unknown flags to other tools.
+//
+// Clustered short flags containing unknown ones, like "-xv" with unknown "-x", are left as they are,
+// and none of flags in them are set.
+func AllowUnknownFlags() ParseOption {
+	return func(po *parseOption) *parseOption {
+		po.allowUnknown = true
+		return po
+	}
+}
+
+// SkipValidation makes Parse not to check required flags and constraints among flags.
+//
+// This is for commandlines being typed, like ones in shell completion.
+func SkipValidation() ParseOption {
+	return func(po *parseOption) *parseOption {
+		po.skipValidation = true
+		return po
+	}
+}
+
+// RejectRestArgs makes Parse to fail with ErrTooMuchArgs, when args are left after positional args are assigned.
+//
+// This is for parsers taking all of args, like ones of commands.
+func RejectRestArgs() ParseOption {
+	return func(po *parseOption) *parseOption {
+		po.rejectRest = true
+		return po
+	}
+}
+
+// RecordRestIndices makes Parse to store indices of each rest of args (rem) into indices.
+//
+// Indices are in args of Parse, or in the commandline given with WithArgIndices.
+// This is for parsers passing rest of args to other parsers, to pass them with WithArgIndices.
+func RecordRestIndices(indices *[]int) ParseOption {
+	return func(po *parseOption) *parseOption {
+		po.restIndices = indices
+		return po
+	}
+}
+
+// WithArgIndices tells Parse where args come from.
+//
+// indices[i] is the index of args[i] in the whole commandline, and
+// ParseError from Parse locates tokens with them, instead of indices in args.
+// This is for parsers taking rest of args of other parsers, recorded by RecordRestIndices.
+func WithArgIndices(indices []int) ParseOption {
+	return func(po *parseOption) *parseOption {
+		po.argIndices = indices
+		return po
+	}
+}
+
+// SuggestingFlags makes Parse to suggest flags in UnknownFlagError from flags, with flags of the parser.
+//
+// This is for flags taken by other parsers before, like ones of command groups.
+func SuggestingFlags(flags ...params.Flag) ParseOption {
+	return func(po *parseOption) *parseOption {
+		po.suggesting = append(po.suggesting, flags...)
+		return po
+	}
+}
+
+// WithLookupEnv replaces the source of environment variables.
+//
+// By default, os.LookupEnv is used.
+func WithLookupEnv(lookup func(string) (string, bool)) ParseOption {
+	return func(po *parseOption) *parseOption {
+		po.lookupEnv = lookup
+		return po
+	}
+}
+
+// New creates a parser for flags declared by fields of flagdef, and positional args pos.
+//
+// Default values of flags are values in flagdef.
+//
+// Each Parse stores values into a fresh copy of flagdef.
+// flag.Value fields which are pointers are copied shallowly for each Parse, and nil ones get new zero values.
+// Other flag.Value fields and func(string) error fields are shared with flagdef,
+// so they should be safe for concurrent use if Parse is called concurrently.
 func New[T any](
-	flagdef *T, pos []params.ArgDef,
+	flagdef *T, pos []params.ArgDef, options ...Option,
+) (Parser[T], error) {
+	return NewFromFields(flagdef, reflectFields[T](), pos, options...)
+}
+
+// NewFromFields creates a parser as New does, for flags declared by fields.
+//
+// This is for parsers generated by flarcgen, which declare fields without reflection.
+func NewFromFields[T any](
+	flagdef *T, fields []Field[T], pos []params.ArgDef, options ...Option,
 ) (Parser[T], error) {
-	refd := reflect.ValueOf(flagdef)
-	trefd := refd.Elem().Type()
+	opt := &option{}
+	for _, o := range options {
+		opt = o(opt)
+	}
 
 	_pos := make([]params.Arg, len(pos))
 	for i := range pos {
@@ -36,33 +261,254 @@ func New[T any](
 	}
 
 	psr := &parser[T]{
-		dest: flagdef,
-		args: _pos,
+		defaults: *flagdef,
+		args:     _pos,
 	}
 
-	names := map[string]struct{}{}
-	for i := 0; i < trefd.NumField(); i += 1 {
-		ref := trefd.Field(i)
-
-		flg, err := params.NewFlag(ref, refd.Elem().Field(i))
+	names := flagNames{}
+	for _, fld := range fields {
+		flg, bind, err := fld.newFlag(flagdef, opt.flagOptions...)
 		if err != nil {
 			return nil, err
 		}
 
-		if _, ok := names[flg.Name()]; ok {
-			return nil, errors.New("")
+		if err := names.declare(flg); err != nil {
+			return nil, err
 		}
 
 		psr.flags = append(psr.flags, flg)
+		psr.binds = append(psr.binds, bind)
+
+		if r, ok := fld.tag.Lookup("requires"); ok {
+			opt.constraints = append(
+				opt.constraints, Requires(flg.Name(), strings.Split(r, ",")...),
+			)
+		}
+	}
+
+	for _, c := range opt.constraints {
+		c, err := c.resolve(psr.flags)
+		if err != nil {
+			return nil, err
+		}
+		psr.constraints = append(psr.constraints, c)
 	}
 
 	return psr, nil
 }
 
 type parser[T any] struct {
-	dest  *T
+	// defaults is a copy of flagdef. It is never modified after New.
+	defaults T
+
 	flags []params.Flag
-	args  []params.Arg
+
+	// binds[i] returns flags[i] storing values into the field of dest.
+	binds []func(dest *T) params.Flag
+
+	args []params.Arg
+
+	constraints []Constraint
+}
+
+// newDest prepares a fresh destination initialized with defaults.
+func (p *parser[T]) newDest() *T {
+	dest := new(T)
+	*dest = p.defaults
+	return dest
+}
+
+// bind returns flags storing into dest.
+//
+// Slice flags bound freshly overwrite values set before, instead of appending to them.
+//
+// Flags record src into sources on they are set.
+func (p *parser[T]) bind(dest *T, src Source, sources Sources) []params.Flag {
+	flags := make([]params.Flag, len(p.flags))
+	for i, bind := range p.binds {
+		flags[i] = recorder{Flag: bind(dest), src: src, sources: sources}
+	}
+	return flags
+}
+
+// recorder records the source of value into sources when the flag is set.
+type recorder struct {
+	params.Flag
+	src     Source
+	sources Sources
+}
+
+func (r recorder) record() {
+	r.sources[strings.TrimLeft(r.Name(), "-")] = r.src
+}
+
+func (r recorder) Set(s string) error {
+	if err := r.Flag.Set(s); err != nil {
+		return err
+	}
+	r.record()
+	return nil
+}
+
+func (r recorder) Found() error {
+	if err := r.Flag.Found(); err != nil {
+		return err
+	}
+	r.record()
+	return nil
+}
+
+func (r recorder) Negate() error {
+	if err := r.Flag.Negate(); err != nil {
+		return err
+	}
+	r.record()
+	return nil
+}
+
+// appendErrors appends err to errs. Errors joined in err are appended one by one.
+func appendErrors(errs []error, err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return append(errs, joined.Unwrap()...)
+	}
+	return append(errs, err)
+}
+
+func fromConfig(flags []params.Flag, cfg Config) error {
+	keys := make([]string, 0, len(cfg.Values))
+	for k := range cfg.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	errs := []error{}
+KEYS:
+	for _, k := range keys {
+		key := strings.Join(append(cfg.Key[:len(cfg.Key):len(cfg.Key)], k), ".")
+		for _, f := range flags {
+			if strings.TrimLeft(f.Name(), "-") != k {
+				continue
+			}
+
+			vals, err := configValues(f.Type(), cfg.Values[k])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%w: %s", err, key))
+				continue KEYS
+			}
+			for _, v := range vals {
+				if err := f.Set(v); err != nil {
+					errs = append(errs, fmt.Errorf("%w: config %s", err, key))
+					continue KEYS
+				}
+			}
+			continue KEYS
+		}
+		errs = append(errs, fmt.Errorf("%w: unknown key: %s", ErrConfig, key))
+	}
+	return errors.Join(errs...)
+}
+
+// configValues converts a value in config into tokens for flag typed t.
+func configValues(t reflect.Type, v any) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if vs, ok := v.([]any); ok {
+		if !repeatable(t) {
+			return nil, fmt.Errorf("%w: %s is not repeatable, but got array", ErrConfig, t)
+		}
+		ret := make([]string, 0, len(vs))
+		for _, v := range vs {
+			s, err := configValue(t, v)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, s)
+		}
+		return ret, nil
+	}
+
+	s, err := configValue(t, v)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+func configValue(t reflect.Type, v any) (string, error) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	numeric := false
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		numeric = true
+	}
+
+	switch v := v.(type) {
+	case bool:
+		if t.Kind() == reflect.Bool {
+			return strconv.FormatBool(v), nil
+		}
+	case json.Number:
+		if numeric {
+			return v.String(), nil
+		}
+	case float64:
+		if numeric {
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+	case string:
+		// named numeric types, like time.Duration, are given as string.
+		if t.Kind() != reflect.Bool && (!numeric || t.PkgPath() != "") {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s is not acceptable for %s", ErrConfig, configTypeName(v), t)
+}
+
+func configTypeName(v any) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func repeatable(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice
+}
+
+func fromEnv(flags []params.Flag, lookupEnv func(string) (string, bool)) error {
+	errs := []error{}
+	for _, f := range flags {
+		name := f.Env()
+		if name == "" {
+			continue
+		}
+		val, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := f.Set(val); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s (from environment variable %s)", err, f.Name(), name))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (p *parser[T]) String() string {
@@ -79,22 +525,71 @@ func (p *parser[T]) String() string {
 }
 
 func (p *parser[T]) Flags() []params.Flag {
-	return p.flags
+	return slices.Clone(p.flags)
 }
 
 func (p *parser[T]) Args() []params.Arg {
-	return p.args
+	return slices.Clone(p.args)
+}
+
+func (p *parser[T]) Constraints() []Constraint {
+	return slices.Clone(p.constraints)
 }
 
-func (p *parser[T]) Parse(args []string) (*T, map[string][]string, []string, error) {
+func (p *parser[T]) Parse(args []string, options ...ParseOption) (*T, map[string][]string, []string, error) {
+	opt := &parseOption{lookupEnv: os.LookupEnv}
+	for _, o := range options {
+		opt = o(opt)
+	}
+
+	sources := opt.sources
+	if sources == nil {
+		sources = Sources{}
+	}
+
+	// errs are usage errors found. Parse continues as possible, to report them at once.
+	errs := []error{}
+	// failed are names of flags (without leading "-") which cannot be set.
+	failed := map[string]struct{}{}
+	fail := func(f params.Flag, err error) {
+		if f != nil {
+			failed[strings.TrimLeft(f.Name(), "-")] = struct{}{}
+		}
+		errs = appendErrors(errs, err)
+	}
+
+	dest := p.newDest()
+	if err := fromConfig(p.bind(dest, SourceConfig, sources), opt.config); err != nil {
+		errs = appendErrors(errs, err)
+	}
+	if err := fromEnv(p.bind(dest, SourceEnv, sources), opt.lookupEnv); err != nil {
+		errs = appendErrors(errs, err)
+	}
+	flags := p.bind(dest, SourceCommandline, sources)
+
+	// indices[i] is the index of args[i] in the commandline.
+	indices := opt.argIndices
+	if indices == nil {
+		indices = make([]int, len(args))
+		for i := range indices {
+			indices[i] = i
+		}
+	}
+	suggest := func(given string) []string {
+		return SuggestFlags(given, append(flags[:len(flags):len(flags)], opt.suggesting...))
+	}
+
 	argv := []string{}
+	// argvIndices[j] is the index of argv[j] in the commandline.
+	argvIndices := []int{}
 	// parse flags.
 ARGS:
 	for i := 0; i < len(args); i += 1 {
 		token := args[i]
 		if token == "--" {
-			if i+1 < len(args) {
-				argv = append(argv, args[i+1:]...)
+			for j := i + 1; j < len(args); j += 1 {
+				argv = append(argv, args[j])
+				argvIndices = append(argvIndices, indices[j])
 			}
 			break
 		}
@@ -102,11 +597,39 @@ ARGS:
 		token, val, eqok := strings.Cut(token, "=")
 		flagName, ok := seemsFlag(token)
 		if !ok {
-			argv = append(argv, args[i])
+			cluster, ok := seemsShortFlags(args[i])
+			if !ok {
+				argv = append(argv, args[i])
+				argvIndices = append(argvIndices, indices[i])
+				continue
+			}
+
+			if opt.allowUnknown && !knownShortFlags(flags, cluster) {
+				// the cluster may be for others. None of its flags are set here.
+				argv = append(argv, args[i])
+				argvIndices = append(argvIndices, indices[i])
+				continue
+			}
+
+			lookAhead, f, err := parseShortFlags(flags, cluster, args[i+1:])
+			if errors.Is(err, errNotFlag) {
+				if !opt.allowUnknown && !seemsNumber(cluster) {
+					fail(nil, &UnknownFlagError{
+						Index: indices[i], Flag: "-" + cluster[:1], Suggestions: suggest(cluster[:1]),
+					})
+					continue
+				}
+				argv = append(argv, args[i])
+				argvIndices = append(argvIndices, indices[i])
+				continue
+			} else if err != nil {
+				fail(f, newFlagParseError(indices[i+lookAhead], args[i+lookAhead], args[i], f, err))
+			}
+			i += lookAhead
 			continue
 		}
 
-		for _, f := range p.flags {
+		for _, f := range flags {
 			lookAhead := 0
 			if !f.Match(flagName) {
 				continue
@@ -116,7 +639,7 @@ ARGS:
 				lookAhead += 1
 				if len(args) <= i+lookAhead {
 					if err := f.Found(); err != nil {
-						return nil, nil, nil, fmt.Errorf("%w: %s", err, token)
+						fail(f, newFlagParseError(indices[i], args[i], token, f, err))
 					}
 					break ARGS
 				}
@@ -127,25 +650,81 @@ ARGS:
 				i += lookAhead
 			} else {
 				if eqok || !errors.Is(err, params.ErrPushBack) {
-					return nil, nil, nil, fmt.Errorf("%w: %s", err, token)
+					fail(f, newFlagParseError(indices[i+lookAhead], args[i+lookAhead], token, f, err))
+					// the value is for the flag, even if it is invalid.
+					i += lookAhead
 				} else if _err := f.Found(); _err != nil {
-					return nil, nil, nil, fmt.Errorf("%w: %s", err, token)
+					fail(f, newFlagParseError(indices[i], args[i], token, f, _err))
 				}
 			}
 
 			continue ARGS
 		}
 
+		for _, f := range flags {
+			if !f.MatchNegation(flagName) {
+				continue
+			}
+			if eqok {
+				fail(f, newFlagParseError(
+					indices[i], args[i], token, f,
+					fmt.Errorf("%w: negated flag does not take value", flarcerror.ErrUsage),
+				))
+			} else if err := f.Negate(); err != nil {
+				fail(f, newFlagParseError(indices[i], args[i], token, f, err))
+			}
+			continue ARGS
+		}
+
+		if !opt.allowUnknown && !seemsNumber(flagName) {
+			fail(nil, &UnknownFlagError{Index: indices[i], Flag: token, Suggestions: suggest(flagName)})
+			continue
+		}
+
 		argv = append(argv, args[i])
+		argvIndices = append(argvIndices, indices[i])
+	}
+
+	if !opt.skipValidation {
+		given := sources
+		if 0 < len(failed) {
+			// flags failed are given, even if their values are invalid.
+			given = Sources{}
+			for k, v := range sources {
+				given[k] = v
+			}
+			for k := range failed {
+				given[k] = SourceCommandline
+			}
+		}
+		if err := validate(flags, p.constraints, given); err != nil {
+			errs = appendErrors(errs, err)
+		}
 	}
 
 	if len(p.args) == 0 {
-		return p.dest, map[string][]string{}, argv, nil
+		if opt.bindArgs != nil {
+			if err := opt.bindArgs(map[string][]string{}, func(string, int) int { return -1 }); err != nil {
+				errs = appendErrors(errs, err)
+			}
+		}
+		if opt.rejectRest && 0 < len(argv) {
+			errs = append(errs, ErrTooMuchArgs)
+		}
+		if 0 < len(errs) {
+			return nil, nil, nil, errors.Join(errs...)
+		}
+		if opt.restIndices != nil {
+			*opt.restIndices = argvIndices
+		}
+		return dest, map[string][]string{}, argv, nil
 	}
 
 	// assign posargs
 	requiredPosArgs := 0
 	foundPosArgs := map[string][]string{}
+	// foundIndices[name][i] is the index of foundPosArgs[name][i] in the commandline.
+	foundIndices := map[string][]int{}
 	for _, pos := range p.args {
 		foundPosArgs[pos.Name()] = []string{}
 		if pos.Required() {
@@ -171,7 +750,9 @@ ARGS:
 
 			if p.Required() && 0 < restv {
 				foundPosArgs[p.Name()] = append(foundPosArgs[p.Name()], argv[0])
+				foundIndices[p.Name()] = append(foundIndices[p.Name()], argvIndices[0])
 				argv = argv[1:]
+				argvIndices = argvIndices[1:]
 				requiredPosArgs -= 1
 			}
 
@@ -182,7 +763,9 @@ ARGS:
 		}
 
 		foundPosArgs[p.Name()] = append(foundPosArgs[p.Name()], argv[0])
+		foundIndices[p.Name()] = append(foundIndices[p.Name()], argvIndices[0])
 		argv = argv[1:]
+		argvIndices = argvIndices[1:]
 		if !set && p.Required() {
 			requiredPosArgs -= 1
 		}
@@ -193,28 +776,257 @@ ARGS:
 		}
 	}
 
+	if opt.bindArgs != nil {
+		locate := func(name string, i int) int { return foundIndices[name][i] }
+		if err := opt.bindArgs(foundPosArgs, locate); err != nil {
+			errs = appendErrors(errs, err)
+		}
+	}
 	if 0 < requiredPosArgs {
-		return nil, nil, nil, ErrNotEnoughArgs
+		errs = append(errs, ErrNotEnoughArgs)
+	}
+	if opt.rejectRest && 0 < len(argv) {
+		errs = append(errs, ErrTooMuchArgs)
+	}
+	if 0 < len(errs) {
+		return nil, nil, nil, errors.Join(errs...)
 	}
 
-	return p.dest, foundPosArgs, argv, nil
+	if opt.restIndices != nil {
+		*opt.restIndices = argvIndices
+	}
+	return dest, foundPosArgs, argv, nil
 }
 
+// seemsFlag reports arg looks like a flag, like "--name" or "-n", and returns its name.
+//
+// A lone "-" is not a flag, but a positional arg, which usually means stdin.
 func seemsFlag(arg string) (name string, ok bool) {
-	if arg == "--" || arg == "" || arg[0] != '-' {
+	l := len(arg)
+	if l < 2 || arg == "--" || arg[0] != '-' {
 		return "", false
 	}
 
-	l := len(arg)
-
-	if arg[:2] == "--" && arg[:3] != "---" && 4 <= l {
+	if strings.HasPrefix(arg, "--") {
+		if strings.HasPrefix(arg, "---") || l < 4 {
+			return "", false
+		}
 		return arg[2:], true
 	}
-	if arg[0] == '-' && l == 2 {
+	if l == 2 {
 		return arg[1:], true
 	}
 
 	return "", false
 }
 
+// seemsShortFlags reports arg looks like clustered short flags, like "-abc" or "-ofile".
+func seemsShortFlags(arg string) (cluster string, ok bool) {
+	if len(arg) < 3 || arg[0] != '-' || arg[1] == '-' {
+		return "", false
+	}
+	return arg[1:], true
+}
+
+// errNotFlag is returned from parseShortFlags when the cluster does not start with known flag.
+var errNotFlag = errors.New("not a flag")
+
+// parseShortFlags parses clustered short flags (without leading "-").
+//
+// Flags in the cluster are processed from the left.
+// Flags not needing value (bool flags) are turned on.
+// For the first flag needing value, the rest of the cluster is its value.
+// If nothing remains in the cluster, the next token is used as its value.
+//
+// # Returns
+//
+// - int: how many tokens in next are consumed.
+//
+// - params.Flag: the flag causing error. For unknown flags, nil.
+//
+// - error: errNotFlag if the first flag is unknown.
+// ErrUnknownShortFlag if the other flag is unknown.
+// Or, errors from flag.
+func parseShortFlags(flags []params.Flag, cluster string, next []string) (int, params.Flag, error) {
+	for i := 0; i < len(cluster); i += 1 {
+		name := cluster[i : i+1]
+		f := findFlag(flags, name)
+		if f == nil {
+			if i == 0 {
+				return 0, nil, errNotFlag
+			}
+			return 0, nil, fmt.Errorf("%w: -%s", ErrUnknownShortFlag, name)
+		}
+
+		rest := cluster[i+1:]
+		if v, ok := strings.CutPrefix(rest, "="); ok {
+			return 0, f, f.Set(v)
+		}
+		if !f.NeedsValue() {
+			if err := f.Found(); err != nil {
+				return 0, f, err
+			}
+			continue
+		}
+		if rest != "" {
+			return 0, f, f.Set(rest)
+		}
+		if len(next) == 0 {
+			return 0, f, f.Found()
+		}
+		return 1, f, f.Set(next[0])
+	}
+	return 0, nil, nil
+}
+
+// knownShortFlags reports all flags in the cluster (without leading "-") are in flags.
+//
+// Letters after a flag needing value are its value, not flags.
+func knownShortFlags(flags []params.Flag, cluster string) bool {
+	for i := 0; i < len(cluster); i += 1 {
+		f := findFlag(flags, cluster[i:i+1])
+		if f == nil {
+			return false
+		}
+		if f.NeedsValue() || strings.HasPrefix(cluster[i+1:], "=") {
+			return true
+		}
+	}
+	return true
+}
+
+func findFlag(flags []params.Flag, name string) params.Flag {
+	for _, f := range flags {
+		if f.Match(name) {
+			return f
+		}
+	}
+	return nil
+}
+
+// ParseError is returned when a token in args cannot be parsed.
+//
+// This wraps Err.
+type ParseError struct {
+	// Index is the index of Token in args, or in the commandline given with WithArgIndices.
+	// If it is not known, -1.
+	Index int
+
+	// Token is the token in args which cannot be parsed,
+	// like "--count=x", "-cx", or "x" in "--count x".
+	Token string
+
+	// Given is how the flag is given, like "--count" or "-c".
+	// For positional args, it is the name of the arg.
+	Given string
+
+	// Flag is the definition of the flag.
+	// For positional args or unknown flags in clustered short flags, it is nil.
+	Flag params.Flag
+
+	// Arg is the definition of the positional arg. For flags, it is nil.
+	Arg params.Arg
+
+	// Type is the type of the field for the flag or the arg. If it is not known, nil.
+	Type reflect.Type
+
+	// Err is the cause.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Err, e.Given)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func newFlagParseError(index int, token string, given string, f params.Flag, err error) *ParseError {
+	pe := &ParseError{Index: index, Token: token, Given: given, Flag: f, Err: err}
+	if f != nil {
+		pe.Type = f.Type()
+	}
+	return pe
+}
+
+// UnknownFlagError is returned when an unknown flag is given.
+//
+// This wraps ErrUnknownFlag.
+type UnknownFlagError struct {
+	// Index is the index of the token giving Flag in args, or in the commandline given with WithArgIndices.
+	// If it is not known, -1.
+	Index int
+
+	// Flag is the given flag, like "--fromat".
+	Flag string
+
+	// Suggestions are names of known flags similar to Flag, most similar first.
+	Suggestions []string
+}
+
+func (e *UnknownFlagError) Error() string {
+	msg := fmt.Sprintf("%s: %s", ErrUnknownFlag, e.Flag)
+	if dym := utils.DidYouMean(e.Suggestions); dym != "" {
+		msg += " (" + dym + ")"
+	}
+	return msg
+}
+
+func (e *UnknownFlagError) Unwrap() error {
+	return ErrUnknownFlag
+}
+
+// ErrUnknownFlag is returned when an unknown flag is given.
+//
+// Errors from Parse are UnknownFlagError wrapping this.
+var ErrUnknownFlag = fmt.Errorf("%w: unknown flag", flarcerror.ErrUsage)
+
+// seemsNumber reports s (without leading "-") looks like a number, like "1" in "-1".
+//
+// Such tokens are treated as positional args, not as unknown flags.
+func seemsNumber(s string) bool {
+	return s != "" && (('0' <= s[0] && s[0] <= '9') || s[0] == '.')
+}
+
+// SuggestFlags returns names of flags similar to given, for "did you mean" messages, most similar first.
+//
+// given is a flag name without leading hyphens. Single letter names are not suggested.
+// For single letter given, flags having it as an alias are suggested.
+func SuggestFlags(given string, flags []params.Flag) []string {
+	given = strings.TrimLeft(given, "-")
+	names := []string{}
+	for _, f := range flags {
+		long := strings.TrimLeft(f.Name(), "-")
+		for _, n := range append([]string{f.Name()}, f.Alias()...) {
+			n = strings.TrimLeft(n, "-")
+			if len(given) == 1 {
+				if n == given && 1 < len(long) && !slices.Contains(names, long) {
+					names = append(names, long)
+				}
+			} else if 1 < len(n) {
+				names = append(names, n)
+			}
+		}
+	}
+
+	sg := names
+	if 1 < len(given) {
+		sg = utils.Suggest(given, names)
+	}
+	for i := range sg {
+		sg[i] = "--" + sg[i]
+	}
+	return sg
+}
+
+// ErrUnknownShortFlag is returned when clustered short flags contains unknown flag.
+var ErrUnknownShortFlag = fmt.Errorf("%w: unknown short flag", flarcerror.ErrUsage)
+
+// ErrConfig is returned when a configuration has unknown keys or values of unexpected type.
+var ErrConfig = fmt.Errorf("%w: invalid config", flarcerror.ErrUsage)
+
 var ErrNotEnoughArgs = fmt.Errorf("%w: not enough args", flarcerror.ErrUsage)
+
+// ErrTooMuchArgs is returned when args are left after positional args are assigned, with RejectRestArgs.
+var ErrTooMuchArgs = fmt.Errorf("%w: too much args", flarcerror.ErrUsage)
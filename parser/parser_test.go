@@ -1,11 +1,16 @@
 package parser_test
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/youta-t/flarc/flarcerror"
 	"github.com/youta-t/flarc/params"
 	"github.com/youta-t/flarc/parser"
 	"github.com/youta-t/flarc/parser/internal"
@@ -42,6 +47,12 @@ func ItsSimpleVar(want string) its.Matcher[flag.Value] {
 	)
 }
 
+func ItsDeepEqual[T any](want T) its.Matcher[T] {
+	return its.EquivWith(want, func(want, got T) bool {
+		return reflect.DeepEqual(want, got)
+	})
+}
+
 func MustTime(t *testing.T, s string) time.Time {
 	timestamp, err := time.Parse(time.RFC3339Nano, s)
 	if err != nil {
@@ -51,282 +62,72 @@ func MustTime(t *testing.T, s string) time.Time {
 }
 
 func TestParser(t *testing.T) {
+	forEachConstructor(t, internal.NewFlagParser, func(t *testing.T, newParser constructor[internal.Flag]) {
 
-	type When struct {
-		VarFlag *SimpleVar
-		posargs []params.ArgDef
-		argv    []string
-	}
+		type When struct {
+			VarFlag      *SimpleVar
+			posargs      []params.ArgDef
+			argv         []string
+			parseOptions []parser.ParseOption
+		}
 
-	type Then struct {
-		Flags    its.Matcher[*internal.Flag]
-		PosArgs  its.Matcher[map[string][]string]
-		Reminder its.Matcher[[]string]
-		Err      its.Matcher[error]
-	}
+		type Then struct {
+			Flags    its.Matcher[*internal.Flag]
+			PosArgs  its.Matcher[map[string][]string]
+			Reminder its.Matcher[[]string]
+			Err      its.Matcher[error]
+		}
 
-	theory := func(when When, then Then) func(*testing.T) {
-		return func(t *testing.T) {
-			testee, err := parser.New(
-				&internal.Flag{
-					StringFlag: "default",
-					BoolFlag:   true,
-					IntFlag:    1,
-					Int8Flag:   2,
-					Int16Flag:  3,
-					Int32Flag:  4,
-					Int64Flag:  5,
-
-					UintFlag:   6,
-					Uint8Flag:  7,
-					Uint16Flag: 8,
-					Uint32Flag: 9,
-					Uint64Flag: 10,
-
-					Float32Flag: 11.5,
-					Float64Flag: 12.25,
-
-					DulationFlag: 13 * time.Second,
-					TimeFlag:     MustTime(t, "2024-01-02T03:04:05+00:00"),
-
-					VarFlag: when.VarFlag,
-				},
-				when.posargs,
-			)
-			if err != nil {
-				t.Fatal(err)
+		theory := func(when When, then Then) func(*testing.T) {
+			return func(t *testing.T) {
+				testee, err := newParser(
+					&internal.Flag{
+						StringFlag: "default",
+						BoolFlag:   true,
+						IntFlag:    1,
+						Int8Flag:   2,
+						Int16Flag:  3,
+						Int32Flag:  4,
+						Int64Flag:  5,
+
+						UintFlag:   6,
+						Uint8Flag:  7,
+						Uint16Flag: 8,
+						Uint32Flag: 9,
+						Uint64Flag: 10,
+
+						Float32Flag: 11.5,
+						Float64Flag: 12.25,
+
+						DulationFlag: 13 * time.Second,
+						TimeFlag:     MustTime(t, "2024-01-02T03:04:05+00:00"),
+
+						VarFlag: when.VarFlag,
+					},
+					when.posargs,
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				flags, posargs, reminder, err := testee.Parse(when.argv, when.parseOptions...)
+
+				then.Flags.Match(flags).OrError(t)
+				then.PosArgs.Match(posargs).OrError(t)
+				then.Err.Match(err).OrError(t)
+				then.Reminder.Match(reminder).OrError(t)
 			}
-
-			flags, posargs, reminder, err := testee.Parse(when.argv)
-
-			then.Flags.Match(flags).OrError(t)
-			then.PosArgs.Match(posargs).OrError(t)
-			then.Err.Match(err).OrError(t)
-			then.Reminder.Match(reminder).OrError(t)
 		}
-	}
-
-	t.Run("pass nothing", theory(
-		When{
-			argv:    []string{},
-			VarFlag: &SimpleVar{Value: "var flag"},
-		},
-		Then{
-			Flags: its.Pointer(ItsFlag(FlagSpec{
-				StringFlag:   its.EqEq("default"),
-				BoolFlag:     its.EqEq(true),
-				IntFlag:      its.EqEq(1),
-				Int8Flag:     its.EqEq[int8](2),
-				Int16Flag:    its.EqEq[int16](3),
-				Int32Flag:    its.EqEq[int32](4),
-				Int64Flag:    its.EqEq[int64](5),
-				UintFlag:     its.EqEq[uint](6),
-				Uint8Flag:    its.EqEq[uint8](7),
-				Uint16Flag:   its.EqEq[uint16](8),
-				Uint32Flag:   its.EqEq[uint32](9),
-				Uint64Flag:   its.EqEq[uint64](10),
-				Float32Flag:  its.EqEq[float32](11.5),
-				Float64Flag:  its.EqEq(12.25),
-				DulationFlag: its.EqEq(13 * time.Second),
-				TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
-				VarFlag:      ItsSimpleVar("var flag"),
-			})),
-			PosArgs:  its.Map[string, []string](map[string]its.Matcher[[]string]{}),
-			Reminder: its.Slice[string](),
-			Err:      its.Nil[error](),
-		},
-	))
-
-	t.Run("no positional args", theory(
-		When{
-			argv: []string{
-				"--string-flag", "string flag",
-				"--bool-flag", "no",
-				"--int-flag=100",
-				"--int8-flag", "101",
-				"--int16-flag=102",
-				"--int32-flag", "103",
-				"--int64-flag=104",
-				"--uint-flag", "105",
-				"--uint8-flag=106",
-				"--uint16-flag", "107",
-				"--uint32-flag=108",
-				"--uint64-flag", "109",
-				"--float32-flag=1.125",
-				"--float64-flag", "1.25",
-				"--dulation-flag=10m",
-				"--time-flag", "2024-10-11T12:13:14.15+01:00",
-				"--var-flag=test value",
-			},
-			VarFlag: &SimpleVar{Value: "var flag"},
-		},
-		Then{
-			Flags: its.Pointer(ItsFlag(FlagSpec{
-				StringFlag:   its.EqEq("string flag"),
-				BoolFlag:     its.EqEq(false),
-				IntFlag:      its.EqEq(100),
-				Int8Flag:     its.EqEq[int8](101),
-				Int16Flag:    its.EqEq[int16](102),
-				Int32Flag:    its.EqEq[int32](103),
-				Int64Flag:    its.EqEq[int64](104),
-				UintFlag:     its.EqEq[uint](105),
-				Uint8Flag:    its.EqEq[uint8](106),
-				Uint16Flag:   its.EqEq[uint16](107),
-				Uint32Flag:   its.EqEq[uint32](108),
-				Uint64Flag:   its.EqEq[uint64](109),
-				Float32Flag:  its.EqEq[float32](1.125),
-				Float64Flag:  its.EqEq(1.25),
-				DulationFlag: its.EqEq(10 * time.Minute),
-				TimeFlag:     its.Equal(MustTime(t, "2024-10-11T12:13:14.15+01:00")),
-				VarFlag:      ItsSimpleVar("test value"),
-			})),
-			PosArgs:  its.Map[string, []string](map[string]its.Matcher[[]string]{}),
-			Reminder: its.Slice[string](),
-			Err:      its.Nil[error](),
-		},
-	))
-
-	t.Run("positional args are given, but not expected", theory(
-		When{
-			VarFlag: &SimpleVar{Value: "var flag"},
-			argv:    []string{"a", "b", "--unknown-flag", "c", "d"},
-		},
-		Then{
-			Flags: its.Pointer(ItsFlag(FlagSpec{
-				StringFlag:   its.EqEq("default"),
-				IntFlag:      its.EqEq(1),
-				Int8Flag:     its.EqEq[int8](2),
-				Int16Flag:    its.EqEq[int16](3),
-				Int32Flag:    its.EqEq[int32](4),
-				Int64Flag:    its.EqEq[int64](5),
-				UintFlag:     its.EqEq[uint](6),
-				Uint8Flag:    its.EqEq[uint8](7),
-				Uint16Flag:   its.EqEq[uint16](8),
-				Uint32Flag:   its.EqEq[uint32](9),
-				Uint64Flag:   its.EqEq[uint64](10),
-				Float32Flag:  its.EqEq[float32](11.5),
-				Float64Flag:  its.EqEq(12.25),
-				DulationFlag: its.EqEq(13 * time.Second),
-				TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
-				VarFlag:      ItsSimpleVar("var flag"),
-			})),
-			PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{}),
-			Reminder: its.Slice[string](
-				its.EqEq("a"),
-				its.EqEq("b"),
-				its.EqEq("--unknown-flag"),
-				its.EqEq("c"),
-				its.EqEq("d"),
-			),
-			Err: its.Nil[error](),
-		},
-	))
-
-	{
-		POS_ARG1 := "pos_arg_1"
-		POS_ARG2 := "pos_arg_2"
-		t.Run("positional args", theory(
-			When{
-				VarFlag: &SimpleVar{Value: "var flag"},
-				argv:    []string{"a", "b", "--unknown-flag", "c", "d"},
-				posargs: []params.ArgDef{
-					{Name: POS_ARG1, Required: true},
-					{Name: POS_ARG2},
-				},
-			},
-			Then{
-				Flags: its.Pointer(ItsFlag(FlagSpec{
-					StringFlag:   its.EqEq("default"),
-					IntFlag:      its.EqEq(1),
-					Int8Flag:     its.EqEq[int8](2),
-					Int16Flag:    its.EqEq[int16](3),
-					Int32Flag:    its.EqEq[int32](4),
-					Int64Flag:    its.EqEq[int64](5),
-					UintFlag:     its.EqEq[uint](6),
-					Uint8Flag:    its.EqEq[uint8](7),
-					Uint16Flag:   its.EqEq[uint16](8),
-					Uint32Flag:   its.EqEq[uint32](9),
-					Uint64Flag:   its.EqEq[uint64](10),
-					Float32Flag:  its.EqEq[float32](11.5),
-					Float64Flag:  its.EqEq(12.25),
-					DulationFlag: its.EqEq(13 * time.Second),
-					TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
-					VarFlag:      ItsSimpleVar("var flag"),
-				})),
-				PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
-					POS_ARG1: its.Slice(its.EqEq("a")),
-					POS_ARG2: its.Slice(its.EqEq("b")),
-				}),
-				Reminder: its.Slice[string](
-					its.EqEq("--unknown-flag"),
-					its.EqEq("c"),
-					its.EqEq("d"),
-				),
-				Err: its.Nil[error](),
-			},
-		))
-	}
-
-	{
-		POS_ARG1 := "pos_arg_1"
-		POS_ARG2 := "pos_arg_2"
-		t.Run("flags & positional args (unordered)", theory(
-			When{
-				VarFlag: &SimpleVar{Value: "var flag"},
-				argv:    []string{"--int-flag=100", "a", "b", "--unknown-flag", "--uint-flag", "200", "c", "-d"},
-				posargs: []params.ArgDef{
-					{Name: POS_ARG1, Required: true},
-					{Name: POS_ARG2},
-				},
-			},
-			Then{
-				Flags: its.Pointer(ItsFlag(FlagSpec{
-					StringFlag:   its.EqEq("default"),
-					IntFlag:      its.EqEq(100),
-					Int8Flag:     its.EqEq[int8](2),
-					Int16Flag:    its.EqEq[int16](3),
-					Int32Flag:    its.EqEq[int32](4),
-					Int64Flag:    its.EqEq[int64](5),
-					UintFlag:     its.EqEq[uint](200),
-					Uint8Flag:    its.EqEq[uint8](7),
-					Uint16Flag:   its.EqEq[uint16](8),
-					Uint32Flag:   its.EqEq[uint32](9),
-					Uint64Flag:   its.EqEq[uint64](10),
-					Float32Flag:  its.EqEq[float32](11.5),
-					Float64Flag:  its.EqEq(12.25),
-					DulationFlag: its.EqEq(13 * time.Second),
-					TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
-					VarFlag:      ItsSimpleVar("var flag"),
-				})),
-				PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
-					POS_ARG1: its.Slice(its.EqEq("a")),
-					POS_ARG2: its.Slice(its.EqEq("b")),
-				}),
-				Reminder: its.Slice[string](
-					its.EqEq("--unknown-flag"),
-					its.EqEq("c"),
-					its.EqEq("-d"),
-				),
-				Err: its.Nil[error](),
-			},
-		))
-	}
 
-	{
-		POS_ARG1 := "pos_arg_1"
-		POS_ARG2 := "pos_arg_2"
-		t.Run("positional args (repeatable)", theory(
+		t.Run("pass nothing", theory(
 			When{
+				argv:    []string{},
 				VarFlag: &SimpleVar{Value: "var flag"},
-				argv:    []string{"a", "b", "--unknown-flag", "c", "d"},
-				posargs: []params.ArgDef{
-					{Name: POS_ARG1, Repeatable: true},
-					{Name: POS_ARG2},
-				},
 			},
 			Then{
 				Flags: its.Pointer(ItsFlag(FlagSpec{
 					StringFlag:   its.EqEq("default"),
+					BoolFlag:     its.EqEq(true),
 					IntFlag:      its.EqEq(1),
 					Int8Flag:     its.EqEq[int8](2),
 					Int16Flag:    its.EqEq[int16](3),
@@ -343,128 +144,66 @@ func TestParser(t *testing.T) {
 					TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
 					VarFlag:      ItsSimpleVar("var flag"),
 				})),
-				PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
-					POS_ARG1: its.Slice(
-						its.EqEq("a"),
-						its.EqEq("b"),
-						its.EqEq("--unknown-flag"),
-						its.EqEq("c"),
-						its.EqEq("d"),
-					),
-					POS_ARG2: its.Slice[string](),
-				}),
+				PosArgs:  its.Map[string, []string](map[string]its.Matcher[[]string]{}),
 				Reminder: its.Slice[string](),
 				Err:      its.Nil[error](),
 			},
 		))
-	}
-	{
-		POS_ARG1 := "pos_arg_1"
-		POS_ARG2 := "pos_arg_2"
-		POS_ARG3 := "pos_arg_3"
-		t.Run("required are prefered (#1)", theory(
+
+		t.Run("no positional args", theory(
 			When{
-				VarFlag: &SimpleVar{Value: "var flag"},
-				argv:    []string{"a", "b"},
-				posargs: []params.ArgDef{
-					{Name: POS_ARG1, Required: true},
-					{Name: POS_ARG2},
-					{Name: POS_ARG3, Required: true},
+				argv: []string{
+					"--string-flag", "string flag",
+					"--bool-flag", "no",
+					"--int-flag=100",
+					"--int8-flag", "101",
+					"--int16-flag=102",
+					"--int32-flag", "103",
+					"--int64-flag=104",
+					"--uint-flag", "105",
+					"--uint8-flag=106",
+					"--uint16-flag", "107",
+					"--uint32-flag=108",
+					"--uint64-flag", "109",
+					"--float32-flag=1.125",
+					"--float64-flag", "1.25",
+					"--dulation-flag=10m",
+					"--time-flag", "2024-10-11T12:13:14.15+01:00",
+					"--var-flag=test value",
 				},
-			},
-			Then{
-				Flags: its.Pointer(ItsFlag(FlagSpec{
-					StringFlag:   its.EqEq("default"),
-					IntFlag:      its.EqEq(1),
-					Int8Flag:     its.EqEq[int8](2),
-					Int16Flag:    its.EqEq[int16](3),
-					Int32Flag:    its.EqEq[int32](4),
-					Int64Flag:    its.EqEq[int64](5),
-					UintFlag:     its.EqEq[uint](6),
-					Uint8Flag:    its.EqEq[uint8](7),
-					Uint16Flag:   its.EqEq[uint16](8),
-					Uint32Flag:   its.EqEq[uint32](9),
-					Uint64Flag:   its.EqEq[uint64](10),
-					Float32Flag:  its.EqEq[float32](11.5),
-					Float64Flag:  its.EqEq(12.25),
-					DulationFlag: its.EqEq(13 * time.Second),
-					TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
-					VarFlag:      ItsSimpleVar("var flag"),
-				})),
-				PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
-					POS_ARG1: its.Slice(
-						its.EqEq("a"),
-					),
-					POS_ARG2: its.Slice[string](),
-					POS_ARG3: its.Slice[string](
-						its.EqEq("b"),
-					),
-				}),
-				Reminder: its.Slice[string](),
-				Err:      its.Nil[error](),
-			},
-		))
-	}
-	{
-		POS_ARG1 := "pos_arg_1"
-		POS_ARG2 := "pos_arg_2"
-		POS_ARG3 := "pos_arg_3"
-		t.Run("required are prefered (#2)", theory(
-			When{
 				VarFlag: &SimpleVar{Value: "var flag"},
-				argv:    []string{"a", "b"},
-				posargs: []params.ArgDef{
-					{Name: POS_ARG1},
-					{Name: POS_ARG2, Required: true},
-					{Name: POS_ARG3, Required: true},
-				},
 			},
 			Then{
 				Flags: its.Pointer(ItsFlag(FlagSpec{
-					StringFlag:   its.EqEq("default"),
-					IntFlag:      its.EqEq(1),
-					Int8Flag:     its.EqEq[int8](2),
-					Int16Flag:    its.EqEq[int16](3),
-					Int32Flag:    its.EqEq[int32](4),
-					Int64Flag:    its.EqEq[int64](5),
-					UintFlag:     its.EqEq[uint](6),
-					Uint8Flag:    its.EqEq[uint8](7),
-					Uint16Flag:   its.EqEq[uint16](8),
-					Uint32Flag:   its.EqEq[uint32](9),
-					Uint64Flag:   its.EqEq[uint64](10),
-					Float32Flag:  its.EqEq[float32](11.5),
-					Float64Flag:  its.EqEq(12.25),
-					DulationFlag: its.EqEq(13 * time.Second),
-					TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
-					VarFlag:      ItsSimpleVar("var flag"),
+					StringFlag:   its.EqEq("string flag"),
+					BoolFlag:     its.EqEq(false),
+					IntFlag:      its.EqEq(100),
+					Int8Flag:     its.EqEq[int8](101),
+					Int16Flag:    its.EqEq[int16](102),
+					Int32Flag:    its.EqEq[int32](103),
+					Int64Flag:    its.EqEq[int64](104),
+					UintFlag:     its.EqEq[uint](105),
+					Uint8Flag:    its.EqEq[uint8](106),
+					Uint16Flag:   its.EqEq[uint16](107),
+					Uint32Flag:   its.EqEq[uint32](108),
+					Uint64Flag:   its.EqEq[uint64](109),
+					Float32Flag:  its.EqEq[float32](1.125),
+					Float64Flag:  its.EqEq(1.25),
+					DulationFlag: its.EqEq(10 * time.Minute),
+					TimeFlag:     its.Equal(MustTime(t, "2024-10-11T12:13:14.15+01:00")),
+					VarFlag:      ItsSimpleVar("test value"),
 				})),
-				PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
-					POS_ARG1: its.Slice[string](),
-					POS_ARG2: its.Slice(
-						its.EqEq("a"),
-					),
-					POS_ARG3: its.Slice(
-						its.EqEq("b"),
-					),
-				}),
+				PosArgs:  its.Map[string, []string](map[string]its.Matcher[[]string]{}),
 				Reminder: its.Slice[string](),
 				Err:      its.Nil[error](),
 			},
 		))
-	}
-	{
-		POS_ARG1 := "pos_arg_1"
-		POS_ARG2 := "pos_arg_2"
-		POS_ARG3 := "pos_arg_3"
-		t.Run("required are prefered (#3)", theory(
+
+		t.Run("positional args are given, but not expected", theory(
 			When{
-				VarFlag: &SimpleVar{Value: "var flag"},
-				argv:    []string{"a", "b"},
-				posargs: []params.ArgDef{
-					{Name: POS_ARG1, Required: true},
-					{Name: POS_ARG2, Required: true},
-					{Name: POS_ARG3},
-				},
+				VarFlag:      &SimpleVar{Value: "var flag"},
+				argv:         []string{"a", "b", "--unknown-flag", "c", "d"},
+				parseOptions: []parser.ParseOption{parser.AllowUnknownFlags()},
 			},
 			Then{
 				Flags: its.Pointer(ItsFlag(FlagSpec{
@@ -485,171 +224,450 @@ func TestParser(t *testing.T) {
 					TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
 					VarFlag:      ItsSimpleVar("var flag"),
 				})),
-				PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
-					POS_ARG1: its.Slice(
-						its.EqEq("a"),
-					),
-					POS_ARG2: its.Slice(
-						its.EqEq("b"),
-					),
-					POS_ARG3: its.Slice[string](),
-				}),
-				Reminder: its.Slice[string](),
-				Err:      its.Nil[error](),
+				PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{}),
+				Reminder: its.Slice[string](
+					its.EqEq("a"),
+					its.EqEq("b"),
+					its.EqEq("--unknown-flag"),
+					its.EqEq("c"),
+					its.EqEq("d"),
+				),
+				Err: its.Nil[error](),
 			},
 		))
-	}
-	{
-		POS_ARG1 := "pos_arg_1"
-		POS_ARG2 := "pos_arg_2"
-		POS_ARG3 := "pos_arg_3"
-		t.Run("required are prefered (#4)", theory(
-			When{
-				VarFlag: &SimpleVar{Value: "var flag"},
-				argv:    []string{"a", "b", "c", "d", "e"},
-				posargs: []params.ArgDef{
-					{Name: POS_ARG1, Required: true, Repeatable: true},
-					{Name: POS_ARG2, Required: true},
-					{Name: POS_ARG3},
+
+		{
+			POS_ARG1 := "pos_arg_1"
+			POS_ARG2 := "pos_arg_2"
+			t.Run("positional args", theory(
+				When{
+					VarFlag:      &SimpleVar{Value: "var flag"},
+					argv:         []string{"a", "b", "--unknown-flag", "c", "d"},
+					parseOptions: []parser.ParseOption{parser.AllowUnknownFlags()},
+					posargs: []params.ArgDef{
+						{Name: POS_ARG1, Required: true},
+						{Name: POS_ARG2},
+					},
 				},
-			},
-			Then{
-				Flags: its.Pointer(ItsFlag(FlagSpec{
-					StringFlag:   its.EqEq("default"),
-					IntFlag:      its.EqEq(1),
-					Int8Flag:     its.EqEq[int8](2),
-					Int16Flag:    its.EqEq[int16](3),
-					Int32Flag:    its.EqEq[int32](4),
-					Int64Flag:    its.EqEq[int64](5),
-					UintFlag:     its.EqEq[uint](6),
-					Uint8Flag:    its.EqEq[uint8](7),
-					Uint16Flag:   its.EqEq[uint16](8),
-					Uint32Flag:   its.EqEq[uint32](9),
-					Uint64Flag:   its.EqEq[uint64](10),
-					Float32Flag:  its.EqEq[float32](11.5),
-					Float64Flag:  its.EqEq(12.25),
-					DulationFlag: its.EqEq(13 * time.Second),
-					TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
-					VarFlag:      ItsSimpleVar("var flag"),
-				})),
-				PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
-					POS_ARG1: its.Slice(
-						its.EqEq("a"),
-						its.EqEq("b"),
+				Then{
+					Flags: its.Pointer(ItsFlag(FlagSpec{
+						StringFlag:   its.EqEq("default"),
+						IntFlag:      its.EqEq(1),
+						Int8Flag:     its.EqEq[int8](2),
+						Int16Flag:    its.EqEq[int16](3),
+						Int32Flag:    its.EqEq[int32](4),
+						Int64Flag:    its.EqEq[int64](5),
+						UintFlag:     its.EqEq[uint](6),
+						Uint8Flag:    its.EqEq[uint8](7),
+						Uint16Flag:   its.EqEq[uint16](8),
+						Uint32Flag:   its.EqEq[uint32](9),
+						Uint64Flag:   its.EqEq[uint64](10),
+						Float32Flag:  its.EqEq[float32](11.5),
+						Float64Flag:  its.EqEq(12.25),
+						DulationFlag: its.EqEq(13 * time.Second),
+						TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
+						VarFlag:      ItsSimpleVar("var flag"),
+					})),
+					PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
+						POS_ARG1: its.Slice(its.EqEq("a")),
+						POS_ARG2: its.Slice(its.EqEq("b")),
+					}),
+					Reminder: its.Slice[string](
+						its.EqEq("--unknown-flag"),
 						its.EqEq("c"),
 						its.EqEq("d"),
 					),
-					POS_ARG2: its.Slice(
-						its.EqEq("e"),
-					),
-					POS_ARG3: its.Slice[string](),
-				}),
-				Reminder: its.Slice[string](),
-				Err:      its.Nil[error](),
-			},
-		))
-	}
-	{
-		POS_ARG1 := "pos_arg_1"
-		POS_ARG2 := "pos_arg_2"
-		POS_ARG3 := "pos_arg_3"
-		t.Run("required are prefered (#5)", theory(
-			When{
-				VarFlag: &SimpleVar{Value: "var flag"},
-				argv:    []string{"a", "b", "c", "d", "e"},
-				posargs: []params.ArgDef{
-					{Name: POS_ARG1, Required: true},
-					{Name: POS_ARG2, Required: true, Repeatable: true},
-					{Name: POS_ARG3},
+					Err: its.Nil[error](),
 				},
-			},
-			Then{
-				Flags: its.Pointer(ItsFlag(FlagSpec{
-					StringFlag:   its.EqEq("default"),
-					IntFlag:      its.EqEq(1),
-					Int8Flag:     its.EqEq[int8](2),
-					Int16Flag:    its.EqEq[int16](3),
-					Int32Flag:    its.EqEq[int32](4),
-					Int64Flag:    its.EqEq[int64](5),
-					UintFlag:     its.EqEq[uint](6),
-					Uint8Flag:    its.EqEq[uint8](7),
-					Uint16Flag:   its.EqEq[uint16](8),
-					Uint32Flag:   its.EqEq[uint32](9),
-					Uint64Flag:   its.EqEq[uint64](10),
-					Float32Flag:  its.EqEq[float32](11.5),
-					Float64Flag:  its.EqEq(12.25),
-					DulationFlag: its.EqEq(13 * time.Second),
-					TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
-					VarFlag:      ItsSimpleVar("var flag"),
-				})),
-				PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
-					POS_ARG1: its.Slice(
-						its.EqEq("a"),
-					),
-					POS_ARG2: its.Slice(
-						its.EqEq("b"),
+			))
+		}
+
+		{
+			POS_ARG1 := "pos_arg_1"
+			POS_ARG2 := "pos_arg_2"
+			t.Run("flags & positional args (unordered)", theory(
+				When{
+					VarFlag:      &SimpleVar{Value: "var flag"},
+					argv:         []string{"--int-flag=100", "a", "b", "--unknown-flag", "--uint-flag", "200", "c", "-d"},
+					parseOptions: []parser.ParseOption{parser.AllowUnknownFlags()},
+					posargs: []params.ArgDef{
+						{Name: POS_ARG1, Required: true},
+						{Name: POS_ARG2},
+					},
+				},
+				Then{
+					Flags: its.Pointer(ItsFlag(FlagSpec{
+						StringFlag:   its.EqEq("default"),
+						IntFlag:      its.EqEq(100),
+						Int8Flag:     its.EqEq[int8](2),
+						Int16Flag:    its.EqEq[int16](3),
+						Int32Flag:    its.EqEq[int32](4),
+						Int64Flag:    its.EqEq[int64](5),
+						UintFlag:     its.EqEq[uint](200),
+						Uint8Flag:    its.EqEq[uint8](7),
+						Uint16Flag:   its.EqEq[uint16](8),
+						Uint32Flag:   its.EqEq[uint32](9),
+						Uint64Flag:   its.EqEq[uint64](10),
+						Float32Flag:  its.EqEq[float32](11.5),
+						Float64Flag:  its.EqEq(12.25),
+						DulationFlag: its.EqEq(13 * time.Second),
+						TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
+						VarFlag:      ItsSimpleVar("var flag"),
+					})),
+					PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
+						POS_ARG1: its.Slice(its.EqEq("a")),
+						POS_ARG2: its.Slice(its.EqEq("b")),
+					}),
+					Reminder: its.Slice[string](
+						its.EqEq("--unknown-flag"),
 						its.EqEq("c"),
-						its.EqEq("d"),
-						its.EqEq("e"),
+						its.EqEq("-d"),
 					),
-					POS_ARG3: its.Slice[string](),
-				}),
-				Reminder: its.Slice[string](),
-				Err:      its.Nil[error](),
-			},
-		))
-	}
-	{
-		POS_ARG1 := "pos_arg_1"
-		POS_ARG2 := "pos_arg_2"
-		POS_ARG3 := "pos_arg_3"
-		t.Run("required are prefered (#6)", theory(
-			When{
-				VarFlag: &SimpleVar{Value: "var flag"},
-				argv:    []string{"a", "b", "c", "d", "e"},
-				posargs: []params.ArgDef{
-					{Name: POS_ARG1, Required: true},
-					{Name: POS_ARG2, Required: true},
-					{Name: POS_ARG3, Repeatable: true},
+					Err: its.Nil[error](),
 				},
-			},
-			Then{
-				Flags: its.Pointer(ItsFlag(FlagSpec{
-					StringFlag:   its.EqEq("default"),
-					IntFlag:      its.EqEq(1),
-					Int8Flag:     its.EqEq[int8](2),
-					Int16Flag:    its.EqEq[int16](3),
-					Int32Flag:    its.EqEq[int32](4),
-					Int64Flag:    its.EqEq[int64](5),
-					UintFlag:     its.EqEq[uint](6),
-					Uint8Flag:    its.EqEq[uint8](7),
-					Uint16Flag:   its.EqEq[uint16](8),
-					Uint32Flag:   its.EqEq[uint32](9),
-					Uint64Flag:   its.EqEq[uint64](10),
-					Float32Flag:  its.EqEq[float32](11.5),
-					Float64Flag:  its.EqEq(12.25),
-					DulationFlag: its.EqEq(13 * time.Second),
-					TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
-					VarFlag:      ItsSimpleVar("var flag"),
-				})),
-				PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
-					POS_ARG1: its.Slice(
-						its.EqEq("a"),
-					),
-					POS_ARG2: its.Slice(
-						its.EqEq("b"),
-					),
-					POS_ARG3: its.Slice(
-						its.EqEq("c"),
-						its.EqEq("d"),
-						its.EqEq("e"),
-					),
-				}),
-				Reminder: its.Slice[string](),
-				Err:      its.Nil[error](),
-			},
-		))
-	}
+			))
+		}
+
+		{
+			POS_ARG1 := "pos_arg_1"
+			POS_ARG2 := "pos_arg_2"
+			t.Run("positional args (repeatable)", theory(
+				When{
+					VarFlag:      &SimpleVar{Value: "var flag"},
+					argv:         []string{"a", "b", "--unknown-flag", "c", "d"},
+					parseOptions: []parser.ParseOption{parser.AllowUnknownFlags()},
+					posargs: []params.ArgDef{
+						{Name: POS_ARG1, Repeatable: true},
+						{Name: POS_ARG2},
+					},
+				},
+				Then{
+					Flags: its.Pointer(ItsFlag(FlagSpec{
+						StringFlag:   its.EqEq("default"),
+						IntFlag:      its.EqEq(1),
+						Int8Flag:     its.EqEq[int8](2),
+						Int16Flag:    its.EqEq[int16](3),
+						Int32Flag:    its.EqEq[int32](4),
+						Int64Flag:    its.EqEq[int64](5),
+						UintFlag:     its.EqEq[uint](6),
+						Uint8Flag:    its.EqEq[uint8](7),
+						Uint16Flag:   its.EqEq[uint16](8),
+						Uint32Flag:   its.EqEq[uint32](9),
+						Uint64Flag:   its.EqEq[uint64](10),
+						Float32Flag:  its.EqEq[float32](11.5),
+						Float64Flag:  its.EqEq(12.25),
+						DulationFlag: its.EqEq(13 * time.Second),
+						TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
+						VarFlag:      ItsSimpleVar("var flag"),
+					})),
+					PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
+						POS_ARG1: its.Slice(
+							its.EqEq("a"),
+							its.EqEq("b"),
+							its.EqEq("--unknown-flag"),
+							its.EqEq("c"),
+							its.EqEq("d"),
+						),
+						POS_ARG2: its.Slice[string](),
+					}),
+					Reminder: its.Slice[string](),
+					Err:      its.Nil[error](),
+				},
+			))
+		}
+		{
+			POS_ARG1 := "pos_arg_1"
+			POS_ARG2 := "pos_arg_2"
+			POS_ARG3 := "pos_arg_3"
+			t.Run("required are prefered (#1)", theory(
+				When{
+					VarFlag: &SimpleVar{Value: "var flag"},
+					argv:    []string{"a", "b"},
+					posargs: []params.ArgDef{
+						{Name: POS_ARG1, Required: true},
+						{Name: POS_ARG2},
+						{Name: POS_ARG3, Required: true},
+					},
+				},
+				Then{
+					Flags: its.Pointer(ItsFlag(FlagSpec{
+						StringFlag:   its.EqEq("default"),
+						IntFlag:      its.EqEq(1),
+						Int8Flag:     its.EqEq[int8](2),
+						Int16Flag:    its.EqEq[int16](3),
+						Int32Flag:    its.EqEq[int32](4),
+						Int64Flag:    its.EqEq[int64](5),
+						UintFlag:     its.EqEq[uint](6),
+						Uint8Flag:    its.EqEq[uint8](7),
+						Uint16Flag:   its.EqEq[uint16](8),
+						Uint32Flag:   its.EqEq[uint32](9),
+						Uint64Flag:   its.EqEq[uint64](10),
+						Float32Flag:  its.EqEq[float32](11.5),
+						Float64Flag:  its.EqEq(12.25),
+						DulationFlag: its.EqEq(13 * time.Second),
+						TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
+						VarFlag:      ItsSimpleVar("var flag"),
+					})),
+					PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
+						POS_ARG1: its.Slice(
+							its.EqEq("a"),
+						),
+						POS_ARG2: its.Slice[string](),
+						POS_ARG3: its.Slice[string](
+							its.EqEq("b"),
+						),
+					}),
+					Reminder: its.Slice[string](),
+					Err:      its.Nil[error](),
+				},
+			))
+		}
+		{
+			POS_ARG1 := "pos_arg_1"
+			POS_ARG2 := "pos_arg_2"
+			POS_ARG3 := "pos_arg_3"
+			t.Run("required are prefered (#2)", theory(
+				When{
+					VarFlag: &SimpleVar{Value: "var flag"},
+					argv:    []string{"a", "b"},
+					posargs: []params.ArgDef{
+						{Name: POS_ARG1},
+						{Name: POS_ARG2, Required: true},
+						{Name: POS_ARG3, Required: true},
+					},
+				},
+				Then{
+					Flags: its.Pointer(ItsFlag(FlagSpec{
+						StringFlag:   its.EqEq("default"),
+						IntFlag:      its.EqEq(1),
+						Int8Flag:     its.EqEq[int8](2),
+						Int16Flag:    its.EqEq[int16](3),
+						Int32Flag:    its.EqEq[int32](4),
+						Int64Flag:    its.EqEq[int64](5),
+						UintFlag:     its.EqEq[uint](6),
+						Uint8Flag:    its.EqEq[uint8](7),
+						Uint16Flag:   its.EqEq[uint16](8),
+						Uint32Flag:   its.EqEq[uint32](9),
+						Uint64Flag:   its.EqEq[uint64](10),
+						Float32Flag:  its.EqEq[float32](11.5),
+						Float64Flag:  its.EqEq(12.25),
+						DulationFlag: its.EqEq(13 * time.Second),
+						TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
+						VarFlag:      ItsSimpleVar("var flag"),
+					})),
+					PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
+						POS_ARG1: its.Slice[string](),
+						POS_ARG2: its.Slice(
+							its.EqEq("a"),
+						),
+						POS_ARG3: its.Slice(
+							its.EqEq("b"),
+						),
+					}),
+					Reminder: its.Slice[string](),
+					Err:      its.Nil[error](),
+				},
+			))
+		}
+		{
+			POS_ARG1 := "pos_arg_1"
+			POS_ARG2 := "pos_arg_2"
+			POS_ARG3 := "pos_arg_3"
+			t.Run("required are prefered (#3)", theory(
+				When{
+					VarFlag: &SimpleVar{Value: "var flag"},
+					argv:    []string{"a", "b"},
+					posargs: []params.ArgDef{
+						{Name: POS_ARG1, Required: true},
+						{Name: POS_ARG2, Required: true},
+						{Name: POS_ARG3},
+					},
+				},
+				Then{
+					Flags: its.Pointer(ItsFlag(FlagSpec{
+						StringFlag:   its.EqEq("default"),
+						IntFlag:      its.EqEq(1),
+						Int8Flag:     its.EqEq[int8](2),
+						Int16Flag:    its.EqEq[int16](3),
+						Int32Flag:    its.EqEq[int32](4),
+						Int64Flag:    its.EqEq[int64](5),
+						UintFlag:     its.EqEq[uint](6),
+						Uint8Flag:    its.EqEq[uint8](7),
+						Uint16Flag:   its.EqEq[uint16](8),
+						Uint32Flag:   its.EqEq[uint32](9),
+						Uint64Flag:   its.EqEq[uint64](10),
+						Float32Flag:  its.EqEq[float32](11.5),
+						Float64Flag:  its.EqEq(12.25),
+						DulationFlag: its.EqEq(13 * time.Second),
+						TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
+						VarFlag:      ItsSimpleVar("var flag"),
+					})),
+					PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
+						POS_ARG1: its.Slice(
+							its.EqEq("a"),
+						),
+						POS_ARG2: its.Slice(
+							its.EqEq("b"),
+						),
+						POS_ARG3: its.Slice[string](),
+					}),
+					Reminder: its.Slice[string](),
+					Err:      its.Nil[error](),
+				},
+			))
+		}
+		{
+			POS_ARG1 := "pos_arg_1"
+			POS_ARG2 := "pos_arg_2"
+			POS_ARG3 := "pos_arg_3"
+			t.Run("required are prefered (#4)", theory(
+				When{
+					VarFlag: &SimpleVar{Value: "var flag"},
+					argv:    []string{"a", "b", "c", "d", "e"},
+					posargs: []params.ArgDef{
+						{Name: POS_ARG1, Required: true, Repeatable: true},
+						{Name: POS_ARG2, Required: true},
+						{Name: POS_ARG3},
+					},
+				},
+				Then{
+					Flags: its.Pointer(ItsFlag(FlagSpec{
+						StringFlag:   its.EqEq("default"),
+						IntFlag:      its.EqEq(1),
+						Int8Flag:     its.EqEq[int8](2),
+						Int16Flag:    its.EqEq[int16](3),
+						Int32Flag:    its.EqEq[int32](4),
+						Int64Flag:    its.EqEq[int64](5),
+						UintFlag:     its.EqEq[uint](6),
+						Uint8Flag:    its.EqEq[uint8](7),
+						Uint16Flag:   its.EqEq[uint16](8),
+						Uint32Flag:   its.EqEq[uint32](9),
+						Uint64Flag:   its.EqEq[uint64](10),
+						Float32Flag:  its.EqEq[float32](11.5),
+						Float64Flag:  its.EqEq(12.25),
+						DulationFlag: its.EqEq(13 * time.Second),
+						TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
+						VarFlag:      ItsSimpleVar("var flag"),
+					})),
+					PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
+						POS_ARG1: its.Slice(
+							its.EqEq("a"),
+							its.EqEq("b"),
+							its.EqEq("c"),
+							its.EqEq("d"),
+						),
+						POS_ARG2: its.Slice(
+							its.EqEq("e"),
+						),
+						POS_ARG3: its.Slice[string](),
+					}),
+					Reminder: its.Slice[string](),
+					Err:      its.Nil[error](),
+				},
+			))
+		}
+		{
+			POS_ARG1 := "pos_arg_1"
+			POS_ARG2 := "pos_arg_2"
+			POS_ARG3 := "pos_arg_3"
+			t.Run("required are prefered (#5)", theory(
+				When{
+					VarFlag: &SimpleVar{Value: "var flag"},
+					argv:    []string{"a", "b", "c", "d", "e"},
+					posargs: []params.ArgDef{
+						{Name: POS_ARG1, Required: true},
+						{Name: POS_ARG2, Required: true, Repeatable: true},
+						{Name: POS_ARG3},
+					},
+				},
+				Then{
+					Flags: its.Pointer(ItsFlag(FlagSpec{
+						StringFlag:   its.EqEq("default"),
+						IntFlag:      its.EqEq(1),
+						Int8Flag:     its.EqEq[int8](2),
+						Int16Flag:    its.EqEq[int16](3),
+						Int32Flag:    its.EqEq[int32](4),
+						Int64Flag:    its.EqEq[int64](5),
+						UintFlag:     its.EqEq[uint](6),
+						Uint8Flag:    its.EqEq[uint8](7),
+						Uint16Flag:   its.EqEq[uint16](8),
+						Uint32Flag:   its.EqEq[uint32](9),
+						Uint64Flag:   its.EqEq[uint64](10),
+						Float32Flag:  its.EqEq[float32](11.5),
+						Float64Flag:  its.EqEq(12.25),
+						DulationFlag: its.EqEq(13 * time.Second),
+						TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
+						VarFlag:      ItsSimpleVar("var flag"),
+					})),
+					PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
+						POS_ARG1: its.Slice(
+							its.EqEq("a"),
+						),
+						POS_ARG2: its.Slice(
+							its.EqEq("b"),
+							its.EqEq("c"),
+							its.EqEq("d"),
+							its.EqEq("e"),
+						),
+						POS_ARG3: its.Slice[string](),
+					}),
+					Reminder: its.Slice[string](),
+					Err:      its.Nil[error](),
+				},
+			))
+		}
+		{
+			POS_ARG1 := "pos_arg_1"
+			POS_ARG2 := "pos_arg_2"
+			POS_ARG3 := "pos_arg_3"
+			t.Run("required are prefered (#6)", theory(
+				When{
+					VarFlag: &SimpleVar{Value: "var flag"},
+					argv:    []string{"a", "b", "c", "d", "e"},
+					posargs: []params.ArgDef{
+						{Name: POS_ARG1, Required: true},
+						{Name: POS_ARG2, Required: true},
+						{Name: POS_ARG3, Repeatable: true},
+					},
+				},
+				Then{
+					Flags: its.Pointer(ItsFlag(FlagSpec{
+						StringFlag:   its.EqEq("default"),
+						IntFlag:      its.EqEq(1),
+						Int8Flag:     its.EqEq[int8](2),
+						Int16Flag:    its.EqEq[int16](3),
+						Int32Flag:    its.EqEq[int32](4),
+						Int64Flag:    its.EqEq[int64](5),
+						UintFlag:     its.EqEq[uint](6),
+						Uint8Flag:    its.EqEq[uint8](7),
+						Uint16Flag:   its.EqEq[uint16](8),
+						Uint32Flag:   its.EqEq[uint32](9),
+						Uint64Flag:   its.EqEq[uint64](10),
+						Float32Flag:  its.EqEq[float32](11.5),
+						Float64Flag:  its.EqEq(12.25),
+						DulationFlag: its.EqEq(13 * time.Second),
+						TimeFlag:     its.Equal(MustTime(t, "2024-01-02T03:04:05+00:00")),
+						VarFlag:      ItsSimpleVar("var flag"),
+					})),
+					PosArgs: its.Map[string, []string](map[string]its.Matcher[[]string]{
+						POS_ARG1: its.Slice(
+							its.EqEq("a"),
+						),
+						POS_ARG2: its.Slice(
+							its.EqEq("b"),
+						),
+						POS_ARG3: its.Slice(
+							its.EqEq("c"),
+							its.EqEq("d"),
+							its.EqEq("e"),
+						),
+					}),
+					Reminder: its.Slice[string](),
+					Err:      its.Nil[error](),
+				},
+			))
+		}
+	})
 }
 
 func TestPositionalArgs_notEnough(t *testing.T) {
@@ -1545,117 +1563,1507 @@ func TestTimeFlag_with_no_value(t *testing.T) {
 }
 
 func TestParser_pointerFlag(t *testing.T) {
-	type T struct {
-		IntpFlag *int
-	}
+	forEachConstructor(t, internal.NewShapesParser, func(t *testing.T, newParser constructor[internal.Shapes]) {
+		type T = internal.Shapes
 
-	testee, err := parser.New(&T{IntpFlag: ptr(3)}, []params.ArgDef{})
-	if err != nil {
-		t.Fatal(err)
-	}
-	flag, posarg, rem, err := testee.Parse([]string{"--intp-flag", "42"})
-	if err != nil {
-		t.Fatal(err)
-	}
-	its.EqEqPtr(ptr(42)).Match(flag.IntpFlag).OrError(t)
-	its.EqEq(0).Match(len(posarg)).OrError(t)
-	its.EqEq(0).Match(len(rem)).OrError(t)
+		testee, err := newParser(&T{IntpFlag: ptr(3)}, []params.ArgDef{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		flag, posarg, rem, err := testee.Parse([]string{"--intp-flag", "42"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		its.EqEqPtr(ptr(42)).Match(flag.IntpFlag).OrError(t)
+		its.EqEq(0).Match(len(posarg)).OrError(t)
+		its.EqEq(0).Match(len(rem)).OrError(t)
+	})
 }
 
 func TestParser_pointerFlag_with_no_value(t *testing.T) {
-	type T struct {
-		IntpFlag *int
-	}
+	forEachConstructor(t, internal.NewShapesParser, func(t *testing.T, newParser constructor[internal.Shapes]) {
+		type T = internal.Shapes
 
-	testee, err := parser.New(&T{IntpFlag: ptr(3)}, []params.ArgDef{})
-	if err != nil {
-		t.Fatal(err)
-	}
-	flag, posarg, rem, err := testee.Parse([]string{"--intp-flag"})
-	its.Nil[*T]().Match(flag).OrError(t)
-	its.Nil[map[string][]string]().Match(posarg).OrError(t)
-	its.Nil[[]string]().Match(rem).OrError(t)
-	its.Error(params.ErrValueRequired).Match(err).OrError(t)
+		testee, err := newParser(&T{IntpFlag: ptr(3)}, []params.ArgDef{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		flag, posarg, rem, err := testee.Parse([]string{"--intp-flag"})
+		its.Nil[*T]().Match(flag).OrError(t)
+		its.Nil[map[string][]string]().Match(posarg).OrError(t)
+		its.Nil[[]string]().Match(rem).OrError(t)
+		its.Error(params.ErrValueRequired).Match(err).OrError(t)
+	})
 }
 
 func TestParser_sliceFlag(t *testing.T) {
+	forEachConstructor(t, internal.NewShapesParser, func(t *testing.T, newParser constructor[internal.Shapes]) {
+		type T = internal.Shapes
+
+		testee, err := newParser(&T{IntsFlag: []int{}}, []params.ArgDef{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		flag, posarg, rem, err := testee.Parse([]string{
+			"--ints-flag", "1",
+			"--ints-flag", "2",
+			"--ints-flag", "3",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		its.Slice(
+			its.EqEq(1),
+			its.EqEq(2),
+			its.EqEq(3),
+		).Match(flag.IntsFlag).OrError(t)
+		its.EqEq(0).Match(len(posarg)).OrError(t)
+		its.EqEq(0).Match(len(rem)).OrError(t)
+	})
+}
+
+func TestParser_sliceOfPtrFlag(t *testing.T) {
+	forEachConstructor(t, internal.NewShapesParser, func(t *testing.T, newParser constructor[internal.Shapes]) {
+		type T = internal.Shapes
+
+		testee, err := newParser(&T{IntpsFlag: []*int{}}, []params.ArgDef{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		flag, posarg, rem, err := testee.Parse([]string{
+			"--intps-flag", "1",
+			"--intps-flag", "2",
+			"--intps-flag", "3",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		its.Slice(
+			its.EqEqPtr(ptr(1)),
+			its.EqEqPtr(ptr(2)),
+			its.EqEqPtr(ptr(3)),
+		).Match(flag.IntpsFlag).OrError(t)
+		its.EqEq(0).Match(len(posarg)).OrError(t)
+		its.EqEq(0).Match(len(rem)).OrError(t)
+	})
+}
+
+func TestParser_ptrOfSliceFlag(t *testing.T) {
+	forEachConstructor(t, internal.NewShapesParser, func(t *testing.T, newParser constructor[internal.Shapes]) {
+		type T = internal.Shapes
+
+		testee, err := newParser(&T{IntspFlag: &[]int{}}, []params.ArgDef{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		flag, posarg, rem, err := testee.Parse([]string{
+			"--intsp-flag", "1",
+			"--intsp-flag", "2",
+			"--intsp-flag", "3",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		its.Slice(
+			its.EqEq(1),
+			its.EqEq(2),
+			its.EqEq(3),
+		).Match(*flag.IntspFlag).OrError(t)
+		its.EqEq(0).Match(len(posarg)).OrError(t)
+		its.EqEq(0).Match(len(rem)).OrError(t)
+	})
+}
+
+func TestParser_sliceDefaults(t *testing.T) {
+	forEachConstructor(t, internal.NewShapesParser, func(t *testing.T, newParser constructor[internal.Shapes]) {
+		type T = internal.Shapes
+
+		testee, err := newParser(
+			&T{IntsFlag: []int{1, 2}, IntpsFlag: []*int{ptr(3), nil, ptr(4)}, IntspFlag: &[]int{5, 6}},
+			[]params.ArgDef{},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defaults := map[string]string{}
+		for _, f := range testee.Flags() {
+			defaults[f.Name()] = f.Default()
+		}
+		its.EqEq("1,2").Match(defaults["--ints-flag"]).OrError(t)
+		its.EqEq("3,4").Match(defaults["--intps-flag"]).OrError(t)
+		its.EqEq("5,6").Match(defaults["--intsp-flag"]).OrError(t)
+		its.EqEq("").Match(defaults["--names"]).OrError(t)
+	})
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestParser_reentrant(t *testing.T) {
 	type T struct {
+		IntFlag  int
 		IntsFlag []int
+		IntpFlag *int
 	}
 
-	testee, err := parser.New(&T{IntsFlag: []int{}}, []params.ArgDef{})
+	flagdef := &T{IntFlag: 1, IntsFlag: []int{10}, IntpFlag: ptr(100)}
+	testee, err := parser.New(flagdef, []params.ArgDef{})
 	if err != nil {
 		t.Fatal(err)
 	}
-	flag, posarg, rem, err := testee.Parse([]string{
-		"--ints-flag", "1",
-		"--ints-flag", "2",
-		"--ints-flag", "3",
+
+	first, _, _, err := testee.Parse([]string{
+		"--int-flag", "2", "--ints-flag", "20", "--intp-flag", "200",
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	its.Slice(
-		its.EqEq(1),
-		its.EqEq(2),
-		its.EqEq(3),
-	).Match(flag.IntsFlag).OrError(t)
-	its.EqEq(0).Match(len(posarg)).OrError(t)
-	its.EqEq(0).Match(len(rem)).OrError(t)
-}
-
-func TestParser_sliceOfPtrFlag(t *testing.T) {
-	type T struct {
-		IntpsFlag []*int
-	}
+	its.EqEq(2).Match(first.IntFlag).OrError(t)
+	its.Slice(its.EqEq(20)).Match(first.IntsFlag).OrError(t)
+	its.EqEqPtr(ptr(200)).Match(first.IntpFlag).OrError(t)
 
-	testee, err := parser.New(&T{IntpsFlag: []*int{}}, []params.ArgDef{})
+	second, _, _, err := testee.Parse([]string{"--ints-flag", "30"})
 	if err != nil {
 		t.Fatal(err)
 	}
-	flag, posarg, rem, err := testee.Parse([]string{
-		"--intps-flag", "1",
-		"--intps-flag", "2",
-		"--intps-flag", "3",
+	its.EqEq(1).Match(second.IntFlag).OrError(t)
+	its.Slice(its.EqEq(30)).Match(second.IntsFlag).OrError(t)
+	its.EqEqPtr(ptr(100)).Match(second.IntpFlag).OrError(t)
+
+	// results of the first run are not affected by the second one.
+	its.EqEq(2).Match(first.IntFlag).OrError(t)
+	its.Slice(its.EqEq(20)).Match(first.IntsFlag).OrError(t)
+
+	// flagdef is not modified.
+	its.EqEq(1).Match(flagdef.IntFlag).OrError(t)
+	its.Slice(its.EqEq(10)).Match(flagdef.IntsFlag).OrError(t)
+	its.EqEqPtr(ptr(100)).Match(flagdef.IntpFlag).OrError(t)
+}
+
+func TestParser_reentrantCallbacks(t *testing.T) {
+	forEachConstructor(t, internal.NewKindsParser, func(t *testing.T, newParser constructor[internal.Kinds]) {
+		given := []string{}
+		flagdef := &internal.Kinds{
+			Callback: func(s string) error {
+				given = append(given, s)
+				return nil
+			},
+			Counter: &internal.Counter{N: 1},
+		}
+		testee, err := newParser(flagdef, []params.ArgDef{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		first, _, _, err := testee.Parse([]string{"--counter", "x", "--counter", "x", "--callback", "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		its.EqEq(3).Match(first.Counter.N).OrError(t)
+
+		second, _, _, err := testee.Parse([]string{"--callback", "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the value is copied from flagdef for each Parse.
+		its.EqEq(1).Match(second.Counter.N).OrError(t)
+		its.EqEq(false).Match(first.Counter == second.Counter).OrError(t)
+
+		// results of the first run are not affected by the second one.
+		its.EqEq(3).Match(first.Counter.N).OrError(t)
+
+		// flagdef is not modified.
+		its.EqEq(1).Match(flagdef.Counter.N).OrError(t)
+
+		// callbacks are called with values, as they are.
+		its.Slice(its.EqEq("a"), its.EqEq("b")).Match(given).OrError(t)
 	})
-	if err != nil {
-		t.Fatal(err)
-	}
-	its.Slice(
-		its.EqEqPtr(ptr(1)),
-		its.EqEqPtr(ptr(2)),
-		its.EqEqPtr(ptr(3)),
-	).Match(flag.IntpsFlag).OrError(t)
-	its.EqEq(0).Match(len(posarg)).OrError(t)
-	its.EqEq(0).Match(len(rem)).OrError(t)
 }
 
-func TestParser_ptrOfSliceFlag(t *testing.T) {
+func TestParser_concurrent(t *testing.T) {
 	type T struct {
-		IntspFlag *[]int
+		IntFlag  int
+		IntsFlag []int
 	}
 
-	testee, err := parser.New(&T{IntspFlag: &[]int{}}, []params.ArgDef{})
-	if err != nil {
-		t.Fatal(err)
-	}
-	flag, posarg, rem, err := testee.Parse([]string{
-		"--intsp-flag", "1",
-		"--intsp-flag", "2",
-		"--intsp-flag", "3",
+	testee, err := parser.New(&T{IntFlag: -1}, []params.ArgDef{
+		{Name: "ARG", Required: true},
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	its.Slice(
-		its.EqEq(1),
-		its.EqEq(2),
-		its.EqEq(3),
-	).Match(*flag.IntspFlag).OrError(t)
-	its.EqEq(0).Match(len(posarg)).OrError(t)
-	its.EqEq(0).Match(len(rem)).OrError(t)
+
+	const n = 32
+	wg := new(sync.WaitGroup)
+	for i := 0; i < n; i += 1 {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := fmt.Sprint(i)
+			flag, posarg, _, err := testee.Parse([]string{
+				"--int-flag", s, "--ints-flag", s, "--ints-flag", s, s,
+			})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			its.EqEq(i).Match(flag.IntFlag).OrError(t)
+			its.Slice(its.EqEq(i), its.EqEq(i)).Match(flag.IntsFlag).OrError(t)
+			its.Map(its.MapSpec[string, []string]{
+				"ARG": its.Slice(its.EqEq(s)),
+			}).Match(posarg).OrError(t)
+		}(i)
+	}
+	wg.Wait()
 }
 
-func ptr[T any](v T) *T {
-	return &v
+func TestParser_shortFlagCluster(t *testing.T) {
+	forEachConstructor(t, internal.NewClusterParser, func(t *testing.T, newParser constructor[internal.Cluster]) {
+		type Flag = internal.Cluster
+
+		type Then struct {
+			Flags   its.Matcher[*Flag]
+			PosArgs its.Matcher[map[string][]string]
+			Err     its.Matcher[error]
+		}
+
+		theory := func(argv []string, then Then, options ...parser.ParseOption) func(*testing.T) {
+			return func(t *testing.T) {
+				testee, err := newParser(&Flag{O: "default"}, []params.ArgDef{
+					{Name: "args", Repeatable: true},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				flags, posargs, _, err := testee.Parse(argv, options...)
+				then.Flags.Match(flags).OrError(t)
+				then.PosArgs.Match(posargs).OrError(t)
+				then.Err.Match(err).OrError(t)
+			}
+		}
+
+		t.Run("bool flags", theory(
+			[]string{"-ab", "x"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{A: true, B: true, O: "default"})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice(its.EqEq("x")),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("bool flags and attached value", theory(
+			[]string{"-abn5", "x"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{A: true, B: true, N: 5, O: "default"})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice(its.EqEq("x")),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("bool flags and value in next token", theory(
+			[]string{"-abn", "5", "x"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{A: true, B: true, N: 5, O: "default"})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice(its.EqEq("x")),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("attached value", theory(
+			[]string{"-ofile.txt", "x"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{O: "file.txt"})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice(its.EqEq("x")),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("attached value looks like flags", theory(
+			[]string{"-aoab"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{A: true, O: "ab"})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice[string](),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("attached value with =", theory(
+			[]string{"-ao=file.txt"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{A: true, O: "file.txt"})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice[string](),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("starting with unknown flag", theory(
+			[]string{"-xa"},
+			Then{
+				Flags:   its.Nil[*Flag](),
+				PosArgs: its.Nil[map[string][]string](),
+				Err:     its.Error(parser.ErrUnknownFlag),
+			},
+		))
+
+		t.Run("negative number is not a flag", theory(
+			[]string{"-12"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{O: "default"})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice(its.EqEq("-12")),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("unknown flag in the middle", theory(
+			[]string{"-axb"},
+			Then{
+				Flags:   its.Nil[*Flag](),
+				PosArgs: its.Nil[map[string][]string](),
+				Err:     its.Error(parser.ErrUnknownShortFlag),
+			},
+		))
+
+		t.Run("flag taking value in the middle", theory(
+			[]string{"-nab"},
+			Then{
+				Flags:   its.Nil[*Flag](),
+				PosArgs: its.Nil[map[string][]string](),
+				Err:     its.Error(params.ErrParse),
+			},
+		))
+
+		t.Run("value is missing", theory(
+			[]string{"-an"},
+			Then{
+				Flags:   its.Nil[*Flag](),
+				PosArgs: its.Nil[map[string][]string](),
+				Err:     its.Error(params.ErrValueRequired),
+			},
+		))
+
+		t.Run("unknown flag in the middle, allowing unknown flags", theory(
+			[]string{"-axb", "-b"},
+			Then{
+				// none of flags in the cluster are set.
+				Flags: its.Pointer(its.EqEq(Flag{B: true, O: "default"})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice(its.EqEq("-axb")),
+				}),
+				Err: its.Nil[error](),
+			},
+			parser.AllowUnknownFlags(),
+		))
+
+		t.Run("attached value is not flags, allowing unknown flags", theory(
+			[]string{"-aoxyz"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{A: true, O: "xyz"})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice[string](),
+				}),
+				Err: its.Nil[error](),
+			},
+			parser.AllowUnknownFlags(),
+		))
+
+		t.Run("lone hyphen is a positional arg", theory(
+			[]string{"-", "-a"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{A: true, O: "default"})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice(its.EqEq("-")),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("hyphen with value is an unknown flag", theory(
+			[]string{"-=x"},
+			Then{
+				Flags:   its.Nil[*Flag](),
+				PosArgs: its.Nil[map[string][]string](),
+				Err:     its.Error(parser.ErrUnknownFlag),
+			},
+		))
+
+		t.Run("hyphen with value, allowing unknown flags", theory(
+			[]string{"-=x"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{O: "default"})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice(its.EqEq("-=x")),
+				}),
+				Err: its.Nil[error](),
+			},
+			parser.AllowUnknownFlags(),
+		))
+
+		t.Run("three hyphens are not a flag", theory(
+			[]string{"---x"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{O: "default"})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice(its.EqEq("---x")),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+	})
+}
+
+func TestParser_negation(t *testing.T) {
+	forEachConstructor(t, internal.NewNegationParser, func(t *testing.T, newParser constructor[internal.Negation]) {
+		type Flag = internal.Negation
+
+		type Then struct {
+			Flags   its.Matcher[*Flag]
+			PosArgs its.Matcher[map[string][]string]
+			Err     its.Matcher[error]
+		}
+
+		theory := func(argv []string, then Then) func(*testing.T) {
+			return func(t *testing.T) {
+				testee, err := newParser(&Flag{Color: true, Force: true}, []params.ArgDef{
+					{Name: "args", Repeatable: true},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				flags, posargs, _, err := testee.Parse(argv)
+				then.Flags.Match(flags).OrError(t)
+				then.PosArgs.Match(posargs).OrError(t)
+				then.Err.Match(err).OrError(t)
+			}
+		}
+
+		t.Run("not given", theory(
+			[]string{},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{Color: true, Force: true})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice[string](),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("negate bool", theory(
+			[]string{"--no-color", "x"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{Color: false, Force: true})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice(its.EqEq("x")),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("negate bool with alias", theory(
+			[]string{"--no-colour", "x"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{Color: false, Force: true})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice(its.EqEq("x")),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("later one wins", theory(
+			[]string{"--no-color", "--color"},
+			Then{
+				Flags: its.Pointer(its.EqEq(Flag{Color: true, Force: true})),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice[string](),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("negate pointer to bool", theory(
+			[]string{"--no-verbose"},
+			Then{
+				Flags: its.Pointer(its.Property(
+					".Verbose", func(f Flag) *bool { return f.Verbose },
+					its.EqEqPtr(ptr(false)),
+				)),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice[string](),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("pointer to bool is nil unless given", theory(
+			[]string{},
+			Then{
+				Flags: its.Pointer(its.Property(
+					".Verbose", func(f Flag) *bool { return f.Verbose },
+					its.Nil[*bool](),
+				)),
+				PosArgs: its.Map(its.MapSpec[string, []string]{
+					"args": its.Slice[string](),
+				}),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("single letter alias is not negatable", theory(
+			[]string{"--no-c"},
+			Then{
+				Flags:   its.Nil[*Flag](),
+				PosArgs: its.Nil[map[string][]string](),
+				Err:     its.Error(parser.ErrUnknownFlag),
+			},
+		))
+
+		t.Run("opted out flag is not negatable", theory(
+			[]string{"--no-force"},
+			Then{
+				Flags:   its.Nil[*Flag](),
+				PosArgs: its.Nil[map[string][]string](),
+				Err:     its.Error(parser.ErrUnknownFlag),
+			},
+		))
+
+		t.Run("negated flag with value", theory(
+			[]string{"--no-color=true"},
+			Then{
+				Flags:   its.Nil[*Flag](),
+				PosArgs: its.Nil[map[string][]string](),
+				Err:     its.Error(flarcerror.ErrUsage),
+			},
+		))
+	})
+}
+
+func TestParser_env(t *testing.T) {
+	forEachConstructor(t, internal.NewEnvParser, func(t *testing.T, newParser constructor[internal.Env]) {
+		type Flag = internal.Env
+
+		lookup := func(env map[string]string) func(string) (string, bool) {
+			return func(name string) (string, bool) {
+				v, ok := env[name]
+				return v, ok
+			}
+		}
+
+		type When struct {
+			options []parser.Option
+			env     map[string]string
+			argv    []string
+		}
+
+		type Then struct {
+			Flags its.Matcher[*Flag]
+			Err   its.Matcher[error]
+		}
+
+		theory := func(when When, then Then) func(*testing.T) {
+			return func(t *testing.T) {
+				testee, err := newParser(
+					&Flag{Timeout: time.Second, Names: []string{"default"}, Secret: "s"},
+					[]params.ArgDef{},
+					when.options...,
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				flags, _, _, err := testee.Parse(when.argv, parser.WithLookupEnv(lookup(when.env)))
+				then.Flags.Match(flags).OrError(t)
+				then.Err.Match(err).OrError(t)
+			}
+		}
+
+		t.Run("env tag", theory(
+			When{
+				env:  map[string]string{"TIMEOUT": "3s", "APP_NAMES": "x", "APP_SECRET": "x"},
+				argv: []string{},
+			},
+			Then{
+				Flags: its.Pointer(ItsDeepEqual(Flag{
+					Timeout: 3 * time.Second, Names: []string{"default"}, Secret: "s",
+				})),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("env prefix", theory(
+			When{
+				options: []parser.Option{parser.WithEnvPrefix("APP")},
+				env: map[string]string{
+					"TIMEOUT": "3s", "APP_NAMES": "x", "APP_DRY_RUN": "true", "APP_SECRET": "x",
+				},
+				argv: []string{},
+			},
+			Then{
+				Flags: its.Pointer(ItsDeepEqual(Flag{
+					Timeout: 3 * time.Second, Names: []string{"x"}, DryRun: true, Secret: "s",
+				})),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("commandline overrides env", theory(
+			When{
+				options: []parser.Option{parser.WithEnvPrefix("APP_")},
+				env: map[string]string{
+					"TIMEOUT": "3s", "APP_NAMES": "x", "APP_DRY_RUN": "true",
+				},
+				argv: []string{"--timeout", "5s", "--names", "y", "--names", "z", "--no-dry-run"},
+			},
+			Then{
+				Flags: its.Pointer(ItsDeepEqual(Flag{
+					Timeout: 5 * time.Second, Names: []string{"y", "z"}, DryRun: false, Secret: "s",
+				})),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("invalid value in env", theory(
+			When{
+				env:  map[string]string{"TIMEOUT": "not duration"},
+				argv: []string{},
+			},
+			Then{
+				Flags: its.Nil[*Flag](),
+				Err:   its.Error(params.ErrParse),
+			},
+		))
+	})
+}
+
+func TestParser_config(t *testing.T) {
+	forEachConstructor(t, internal.NewConfigParser, func(t *testing.T, newParser constructor[internal.Config]) {
+		type Flag = internal.Config
+
+		type When struct {
+			config parser.Config
+			env    map[string]string
+			argv   []string
+		}
+
+		type Then struct {
+			Flags its.Matcher[*Flag]
+			Err   its.Matcher[error]
+		}
+
+		theory := func(when When, then Then) func(*testing.T) {
+			return func(t *testing.T) {
+				testee, err := newParser(&Flag{Count: 1, Name: "default"}, []params.ArgDef{})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				flags, _, _, err := testee.Parse(
+					when.argv,
+					parser.WithConfig(when.config),
+					parser.WithLookupEnv(func(s string) (string, bool) {
+						v, ok := when.env[s]
+						return v, ok
+					}),
+				)
+				then.Flags.Match(flags).OrError(t)
+				then.Err.Match(err).OrError(t)
+			}
+		}
+
+		t.Run("values from config", theory(
+			When{
+				config: parser.Config{Values: map[string]any{
+					"count":   json.Number("3"),
+					"timeout": "1m",
+					"names":   []any{"a", "b"},
+					"verbose": true,
+					"name":    "config",
+				}},
+			},
+			Then{
+				Flags: its.Pointer(ItsDeepEqual(Flag{
+					Count: 3, Timeout: time.Minute, Names: []string{"a", "b"},
+					Verbose: true, Name: "config",
+				})),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("null is ignored", theory(
+			When{
+				config: parser.Config{Values: map[string]any{"count": nil}},
+			},
+			Then{
+				Flags: its.Pointer(ItsDeepEqual(Flag{Count: 1, Name: "default"})),
+				Err:   its.Nil[error](),
+			},
+		))
+
+		t.Run("env and commandline override config", theory(
+			When{
+				config: parser.Config{Values: map[string]any{
+					"count": json.Number("3"),
+					"names": []any{"a", "b"},
+					"name":  "config",
+				}},
+				env:  map[string]string{"NAME": "env"},
+				argv: []string{"--names", "c"},
+			},
+			Then{
+				Flags: its.Pointer(ItsDeepEqual(Flag{
+					Count: 3, Names: []string{"c"}, Name: "env",
+				})),
+				Err: its.Nil[error](),
+			},
+		))
+
+		t.Run("unknown key", theory(
+			When{
+				config: parser.Config{
+					Key:    []string{"sub"},
+					Values: map[string]any{"unknown": "x"},
+				},
+			},
+			Then{
+				Flags: its.Nil[*Flag](),
+				Err: its.All(
+					its.Error(parser.ErrConfig),
+					its.Property(
+						".Error()", error.Error,
+						its.StringHavingSuffix("unknown key: sub.unknown"),
+					),
+				),
+			},
+		))
+
+		t.Run("type mismatch", theory(
+			When{
+				config: parser.Config{
+					Key:    []string{"sub"},
+					Values: map[string]any{"count": "3"},
+				},
+			},
+			Then{
+				Flags: its.Nil[*Flag](),
+				Err: its.All(
+					its.Error(parser.ErrConfig),
+					its.Property(
+						".Error()", error.Error,
+						its.StringHavingSuffix(": sub.count"),
+					),
+				),
+			},
+		))
+
+		t.Run("array for non repeatable flag", theory(
+			When{
+				config: parser.Config{Values: map[string]any{"name": []any{"a"}}},
+			},
+			Then{
+				Flags: its.Nil[*Flag](),
+				Err:   its.Error(parser.ErrConfig),
+			},
+		))
+
+		t.Run("invalid value", theory(
+			When{
+				config: parser.Config{Values: map[string]any{"timeout": "not duration"}},
+			},
+			Then{
+				Flags: its.Nil[*Flag](),
+				Err: its.All(
+					its.Error(params.ErrParse),
+					its.Property(
+						".Error()", error.Error,
+						its.StringHavingSuffix("config timeout"),
+					),
+				),
+			},
+		))
+	})
+}
+
+func TestParser_sources(t *testing.T) {
+	forEachConstructor(t, internal.NewSourcesParser, func(t *testing.T, newParser constructor[internal.Sources]) {
+		type Flag = internal.Sources
+
+		testee, err := newParser(&Flag{Color: true}, []params.ArgDef{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sources := parser.Sources{}
+		_, _, _, err = testee.Parse(
+			[]string{"-c", "0", "-v", "--no-color"},
+			parser.WithConfig(parser.Config{Values: map[string]any{
+				"names": []any{"a"}, "name": "config",
+			}}),
+			parser.WithLookupEnv(func(s string) (string, bool) {
+				if s == "NAME" {
+					return "env", true
+				}
+				return "", false
+			}),
+			parser.RecordSources(sources),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		its.Map(its.MapSpec[string, parser.Source]{
+			"count":   its.EqEq(parser.SourceCommandline),
+			"names":   its.EqEq(parser.SourceConfig),
+			"verbose": its.EqEq(parser.SourceCommandline),
+			"color":   its.EqEq(parser.SourceCommandline),
+			"name":    its.EqEq(parser.SourceEnv),
+		}).Match(map[string]parser.Source(sources)).OrError(t)
+	})
+}
+
+func TestParser_constraints(t *testing.T) {
+	forEachConstructor(t, internal.NewConstraintsParser, func(t *testing.T, newParser constructor[internal.Constraints]) {
+		type Flag = internal.Constraints
+
+		testee, err := newParser(
+			&Flag{}, []params.ArgDef{},
+			parser.WithConstraints(
+				parser.MutuallyExclusive("json", "--yaml"),
+				parser.AtLeastOneOf("token", "-p"),
+			),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		theory := func(args []string, want its.Matcher[error]) func(*testing.T) {
+			return func(t *testing.T) {
+				_, _, _, err := testee.Parse(args)
+				want.Match(err).OrError(t)
+			}
+		}
+
+		t.Run("all constraints are satisfied", theory(
+			[]string{"--name", "n", "--key", "k", "--cert", "c", "--json", "-p", "pass"},
+			its.Nil[error](),
+		))
+
+		t.Run("required flag is missing", theory(
+			[]string{"--token", "t"},
+			its.All(
+				its.Error(parser.ErrRequiredFlag),
+				its.Error(flarcerror.ErrUsage),
+			),
+		))
+
+		t.Run("required flag given via env", func(t *testing.T) {
+			_, _, _, err := testee.Parse(
+				[]string{"--token", "t"},
+				parser.WithLookupEnv(func(s string) (string, bool) {
+					return "n", s == "NAME"
+				}),
+			)
+			its.Nil[error]().Match(err).OrError(t)
+		})
+
+		t.Run("required dependency is missing", theory(
+			[]string{"--name", "n", "--token", "t", "--key", "k"},
+			its.All(
+				its.Error(parser.ErrConstraint),
+				its.Error(flarcerror.ErrUsage),
+			),
+		))
+
+		t.Run("mutually exclusive flags are given", theory(
+			[]string{"--name", "n", "--token", "t", "--json", "--yaml"},
+			its.Error(parser.ErrConstraint),
+		))
+
+		t.Run("none of at-least-one-of flags is given", theory(
+			[]string{"--name", "n"},
+			its.Error(parser.ErrConstraint),
+		))
+
+		t.Run("all violations are reported", func(t *testing.T) {
+			_, _, _, err := testee.Parse([]string{"--key", "k", "--json", "--yaml"})
+			its.All(
+				its.Error(parser.ErrRequiredFlag),
+				its.Error(parser.ErrConstraint),
+			).Match(err).OrError(t)
+
+			its.EqEq(4).Match(len(err.(interface{ Unwrap() []error }).Unwrap())).OrError(t)
+		})
+
+		t.Run("violations are not checked with SkipValidation", func(t *testing.T) {
+			flags, _, _, err := testee.Parse(
+				[]string{"--key", "k", "--json", "--yaml"}, parser.SkipValidation(),
+			)
+			its.Nil[error]().Match(err).OrError(t)
+			its.EqEq("k").Match(flags.Key).OrError(t)
+		})
+
+		t.Run("invalid values are reported with SkipValidation", func(t *testing.T) {
+			_, _, _, err := testee.Parse([]string{"--json=x"}, parser.SkipValidation())
+			its.All(
+				its.Error(params.ErrParse),
+				its.Not(its.Error(parser.ErrRequiredFlag)),
+			).Match(err).OrError(t)
+		})
+
+		t.Run("constraints are listed", func(t *testing.T) {
+			cs := testee.Constraints()
+			got := make([]string, len(cs))
+			for i := range cs {
+				got[i] = cs[i].String()
+			}
+			its.Slice(
+				its.EqEq("--json, --yaml are mutually exclusive"),
+				its.EqEq("at least one of --token, --pass is required"),
+				its.EqEq("--key requires --cert"),
+			).Match(got).OrError(t)
+		})
+
+		t.Run("constraint with unknown flag is an error", func(t *testing.T) {
+			_, err := newParser(
+				&Flag{}, []params.ArgDef{},
+				parser.WithConstraints(parser.MutuallyExclusive("json", "xml")),
+			)
+			its.Not(its.Nil[error]()).Match(err).OrError(t)
+		})
+	})
+}
+
+func TestParser_unknownFlags(t *testing.T) {
+	forEachConstructor(t, internal.NewUnknownParser, func(t *testing.T, newParser constructor[internal.Unknown]) {
+		type Flag = internal.Unknown
+
+		testee, err := newParser(&Flag{}, []params.ArgDef{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run("mistyped flag", func(t *testing.T) {
+			_, _, _, err := testee.Parse([]string{"--fromat=json", "x"})
+
+			its.Error(flarcerror.ErrUsage).Match(err).OrError(t)
+			ufe := new(parser.UnknownFlagError)
+			if !errors.As(err, &ufe) {
+				t.Fatalf("not UnknownFlagError: %v", err)
+			}
+			its.EqEq("--fromat").Match(ufe.Flag).OrError(t)
+			its.Slice(its.EqEq("--format")).Match(ufe.Suggestions).OrError(t)
+			its.EqEq("usage error: unknown flag: --fromat (did you mean `--format`?)").
+				Match(err.Error()).OrError(t)
+		})
+
+		t.Run("prefix of flags", func(t *testing.T) {
+			_, _, _, err := testee.Parse([]string{"--fo"})
+			ufe := new(parser.UnknownFlagError)
+			if !errors.As(err, &ufe) {
+				t.Fatalf("not UnknownFlagError: %v", err)
+			}
+			its.Slice(its.EqEq("--force"), its.EqEq("--format")).Match(ufe.Suggestions).OrError(t)
+		})
+
+		t.Run("mistyped flag is allowed", func(t *testing.T) {
+			_, _, rem, err := testee.Parse([]string{"--fromat=json", "x"}, parser.AllowUnknownFlags())
+			its.Nil[error]().Match(err).OrError(t)
+			its.Slice(its.EqEq("--fromat=json"), its.EqEq("x")).Match(rem).OrError(t)
+		})
+
+		t.Run("unknown flag without similar ones", func(t *testing.T) {
+			_, _, _, err := testee.Parse([]string{"--verbose"})
+			its.Error(parser.ErrUnknownFlag).Match(err).OrError(t)
+			its.Error(flarcerror.ErrUsage).Match(err).OrError(t)
+			its.EqEq("usage error: unknown flag: --verbose").Match(err.Error()).OrError(t)
+		})
+
+		t.Run("unknown short flag", func(t *testing.T) {
+			_, _, _, err := testee.Parse([]string{"-x"})
+			its.Error(parser.ErrUnknownFlag).Match(err).OrError(t)
+			its.EqEq("usage error: unknown flag: -x").Match(err.Error()).OrError(t)
+		})
+
+		t.Run("negative number is an arg", func(t *testing.T) {
+			_, _, rem, err := testee.Parse([]string{"-1", "-.5"})
+			its.Nil[error]().Match(err).OrError(t)
+			its.Slice(its.EqEq("-1"), its.EqEq("-.5")).Match(rem).OrError(t)
+		})
+
+		t.Run("flags after -- are not checked", func(t *testing.T) {
+			_, _, rem, err := testee.Parse([]string{"--", "--fromat"})
+			its.Nil[error]().Match(err).OrError(t)
+			its.Slice(its.EqEq("--fromat")).Match(rem).OrError(t)
+		})
+
+		t.Run("flags of others are suggested with ranking", func(t *testing.T) {
+			type Other struct {
+				Formats []string
+				Verbose bool `alias:"v"`
+			}
+			other, err := parser.New(&Other{}, []params.ArgDef{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, _, _, err = testee.Parse(
+				[]string{"--formts"}, parser.SuggestingFlags(other.Flags()...),
+			)
+			ufe := new(parser.UnknownFlagError)
+			if !errors.As(err, &ufe) {
+				t.Fatalf("not UnknownFlagError: %v", err)
+			}
+			its.Slice(its.EqEq("--formats"), its.EqEq("--format")).Match(ufe.Suggestions).OrError(t)
+		})
+
+		t.Run("short flag of others is suggested with its long name", func(t *testing.T) {
+			type Other struct {
+				Verbose bool `alias:"v"`
+			}
+			other, err := parser.New(&Other{}, []params.ArgDef{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, _, _, err = testee.Parse([]string{"-v"}, parser.SuggestingFlags(other.Flags()...))
+			its.EqEq("usage error: unknown flag: -v (did you mean `--verbose`?)").Match(err.Error()).OrError(t)
+		})
+	})
+}
+
+func TestParser_parseError(t *testing.T) {
+	forEachConstructor(t, internal.NewLocatedParser, func(t *testing.T, newParser constructor[internal.Located]) {
+		type Flag = internal.Located
+
+		testee, err := newParser(&Flag{}, []params.ArgDef{
+			{Name: "args", Repeatable: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		type Then struct {
+			Index int
+			Token string
+			Given string
+			Err   error
+		}
+
+		theory := func(argv []string, then Then) func(*testing.T) {
+			return func(t *testing.T) {
+				_, _, _, err := testee.Parse(argv)
+				its.Error(flarcerror.ErrUsage).Match(err).OrError(t)
+				its.Error(then.Err).Match(err).OrError(t)
+
+				pe := new(parser.ParseError)
+				if !errors.As(err, &pe) {
+					t.Fatalf("not ParseError: %v", err)
+				}
+				its.EqEq(then.Index).Match(pe.Index).OrError(t)
+				its.EqEq(then.Token).Match(pe.Token).OrError(t)
+				its.EqEq(then.Given).Match(pe.Given).OrError(t)
+				its.EqEq("--count").Match(pe.Flag.Name()).OrError(t)
+				its.EqEq(reflect.TypeOf(0)).Match(pe.Type).OrError(t)
+				its.Nil[params.Arg]().Match(pe.Arg).OrError(t)
+			}
+		}
+
+		t.Run("value with =", theory(
+			[]string{"a", "--count=x"},
+			Then{Index: 1, Token: "--count=x", Given: "--count", Err: params.ErrParse},
+		))
+		t.Run("value as the next token", theory(
+			[]string{"a", "-c", "x"},
+			Then{Index: 2, Token: "x", Given: "-c", Err: params.ErrParse},
+		))
+		t.Run("value in clustered short flags", theory(
+			[]string{"-fcx", "a"},
+			Then{Index: 0, Token: "-fcx", Given: "-fcx", Err: params.ErrParse},
+		))
+		t.Run("value after clustered short flags", theory(
+			[]string{"-fc", "x"},
+			Then{Index: 1, Token: "x", Given: "-fc", Err: params.ErrParse},
+		))
+		t.Run("value missing", theory(
+			[]string{"a", "--count"},
+			Then{Index: 1, Token: "--count", Given: "--count", Err: params.ErrValueRequired},
+		))
+
+		t.Run("message", func(t *testing.T) {
+			_, _, _, err := testee.Parse([]string{"--count", "x"})
+			its.EqEq("usage error: parse error: x is not int: --count").Match(err.Error()).OrError(t)
+		})
+
+		t.Run("indices of rest", func(t *testing.T) {
+			g, err := newParser(&Flag{}, []params.ArgDef{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			indices := []int{}
+			_, _, rem, err := g.Parse(
+				[]string{"-c", "1", "a", "--unknown", "-f", "b", "--", "c"},
+				parser.AllowUnknownFlags(), parser.RecordRestIndices(&indices),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			its.Slice(its.EqEq("a"), its.EqEq("--unknown"), its.EqEq("b"), its.EqEq("c")).
+				Match(rem).OrError(t)
+			its.Slice(its.EqEq(2), its.EqEq(3), its.EqEq(5), its.EqEq(7)).
+				Match(indices).OrError(t)
+		})
+
+		t.Run("indices in the commandline", func(t *testing.T) {
+			g, err := newParser(&Flag{}, []params.ArgDef{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			indices := []int{}
+			_, _, _, err = g.Parse(
+				[]string{"a", "-c", "x"},
+				parser.WithArgIndices([]int{3, 5, 6}),
+			)
+			pe := new(parser.ParseError)
+			if !errors.As(err, &pe) {
+				t.Fatalf("not ParseError: %v", err)
+			}
+			its.EqEq(6).Match(pe.Index).OrError(t)
+			its.EqEq("x").Match(pe.Token).OrError(t)
+
+			_, _, rem, err := g.Parse(
+				[]string{"a", "-c", "1", "b"},
+				parser.WithArgIndices([]int{3, 5, 6, 8}), parser.RecordRestIndices(&indices),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			its.Slice(its.EqEq("a"), its.EqEq("b")).Match(rem).OrError(t)
+			its.Slice(its.EqEq(3), its.EqEq(8)).Match(indices).OrError(t)
+		})
+	})
+}
+
+func TestParser_collectErrors(t *testing.T) {
+	forEachConstructor(t, internal.NewCollectedParser, func(t *testing.T, newParser constructor[internal.Collected]) {
+		type Flag = internal.Collected
+
+		testee, err := newParser(
+			&Flag{},
+			[]params.ArgDef{{Name: "src", Required: true}, {Name: "dest", Required: true}},
+			parser.WithConstraints(parser.MutuallyExclusive("json", "yaml")),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run("all errors are reported", func(t *testing.T) {
+			_, _, _, err := testee.Parse([]string{"--count=x", "--verbose", "-jy", "a"})
+
+			joined, ok := err.(interface{ Unwrap() []error })
+			if !ok {
+				t.Fatalf("not joined: %v", err)
+			}
+			its.Slice(
+				its.Error(params.ErrParse),
+				its.Error(parser.ErrUnknownFlag),
+				its.Error(parser.ErrRequiredFlag),
+				its.Error(parser.ErrConstraint),
+				its.Error(parser.ErrNotEnoughArgs),
+			).Match(joined.Unwrap()).OrError(t)
+			its.Error(flarcerror.ErrUsage).Match(err).OrError(t)
+		})
+
+		t.Run("invalid value of required flag is not reported as missing", func(t *testing.T) {
+			_, _, _, err := testee.Parse([]string{"--limit", "x", "a", "b"})
+
+			joined, ok := err.(interface{ Unwrap() []error })
+			if !ok {
+				t.Fatalf("not joined: %v", err)
+			}
+			its.Slice(its.Error(params.ErrParse)).Match(joined.Unwrap()).OrError(t)
+		})
+
+		t.Run("invalid value is not an arg", func(t *testing.T) {
+			_, _, _, err := testee.Parse([]string{"--limit", "1", "-c", "x", "a"})
+
+			joined, ok := err.(interface{ Unwrap() []error })
+			if !ok {
+				t.Fatalf("not joined: %v", err)
+			}
+			its.Slice(its.Error(params.ErrParse), its.Error(parser.ErrNotEnoughArgs)).
+				Match(joined.Unwrap()).OrError(t)
+		})
+
+		t.Run("too much args are reported with RejectRestArgs", func(t *testing.T) {
+			_, _, _, err := testee.Parse(
+				[]string{"--limit", "x", "a", "b", "c"}, parser.RejectRestArgs(),
+			)
+
+			joined, ok := err.(interface{ Unwrap() []error })
+			if !ok {
+				t.Fatalf("not joined: %v", err)
+			}
+			its.Slice(its.Error(params.ErrParse), its.Error(parser.ErrTooMuchArgs)).
+				Match(joined.Unwrap()).OrError(t)
+		})
+
+		t.Run("rest of args are returned without RejectRestArgs", func(t *testing.T) {
+			_, _, rem, err := testee.Parse([]string{"--limit", "1", "a", "b", "c"})
+			its.Nil[error]().Match(err).OrError(t)
+			its.Slice(its.EqEq("c")).Match(rem).OrError(t)
+		})
+	})
+}
+
+func TestTypedArgs(t *testing.T) {
+	type Args struct {
+		Dest    string        `arg:"DEST" required:"true"`
+		Timeout time.Duration `help:"wait at most"`
+		Sources []string      `arg:"SOURCE" help:"input files"`
+		Ignored int           `arg:"-"`
+	}
+
+	ta, err := parser.NewTypedArgs(&Args{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("declares args", func(t *testing.T) {
+		its.Slice(
+			ItsDeepEqual(params.ArgDef{Name: "DEST", Required: true}),
+			ItsDeepEqual(params.ArgDef{Name: "TIMEOUT", Help: "wait at most"}),
+			ItsDeepEqual(params.ArgDef{Name: "SOURCE", Repeatable: true, Help: "input files"}),
+		).Match(ta.Defs()).OrError(t)
+	})
+
+	testee, err := parser.New(&struct{}{}, ta.Defs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("converts args", func(t *testing.T) {
+		_, args, _, err := testee.Parse([]string{"c", "3s", "a", "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ta.Bind(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ItsDeepEqual(Args{
+			Sources: []string{"a", "b"}, Dest: "c", Timeout: 3 * time.Second,
+		}).Match(*got).OrError(t)
+	})
+
+	t.Run("args not given keep defaults", func(t *testing.T) {
+		_, args, _, err := testee.Parse([]string{"c"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ta.Bind(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ItsDeepEqual(Args{
+			Dest: "c", Timeout: time.Second,
+		}).Match(*got).OrError(t)
+	})
+
+	t.Run("invalid value is a usage error", func(t *testing.T) {
+		_, args, _, err := testee.Parse([]string{"c", "soon"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ta.Bind(args)
+		its.All(
+			its.Error(params.ErrParse),
+			its.Error(flarcerror.ErrUsage),
+		).Match(err).OrError(t)
+
+		pe := new(parser.ParseError)
+		if !errors.As(err, &pe) {
+			t.Fatalf("not ParseError: %v", err)
+		}
+		its.EqEq(-1).Match(pe.Index).OrError(t)
+		its.EqEq("soon").Match(pe.Token).OrError(t)
+		its.EqEq("TIMEOUT").Match(pe.Given).OrError(t)
+		its.EqEq("TIMEOUT").Match(pe.Arg.Name()).OrError(t)
+		its.EqEq(reflect.TypeOf(time.Duration(0))).Match(pe.Type).OrError(t)
+	})
+
+	t.Run("BindArgs converts args in Parse", func(t *testing.T) {
+		var got *Args
+		_, _, _, err := testee.Parse([]string{"c", "3s", "a"}, parser.BindArgs(ta, &got))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ItsDeepEqual(Args{
+			Sources: []string{"a"}, Dest: "c", Timeout: 3 * time.Second,
+		}).Match(*got).OrError(t)
+	})
+
+	t.Run("BindArgs reports all invalid values with indices and other errors", func(t *testing.T) {
+		type Counts struct {
+			Counts []int `arg:"COUNT"`
+		}
+		cta, err := parser.NewTypedArgs(&Counts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		type Flag struct {
+			Level int
+		}
+		testee, err := parser.New(&Flag{}, cta.Defs())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got *Counts
+		_, _, _, err = testee.Parse(
+			[]string{"one", "--level=high", "2", "three"},
+			parser.BindArgs(cta, &got),
+		)
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			t.Fatalf("not joined: %v", err)
+		}
+
+		indexOf := func(err error) int {
+			pe := new(parser.ParseError)
+			if !errors.As(err, &pe) {
+				t.Fatalf("not ParseError: %v", err)
+			}
+			return pe.Index
+		}
+		indices := []int{}
+		for _, e := range joined.Unwrap() {
+			its.Error(flarcerror.ErrUsage).Match(e).OrError(t)
+			indices = append(indices, indexOf(e))
+		}
+		its.Slice(its.EqEq(1), its.EqEq(0), its.EqEq(3)).Match(indices).OrError(t)
+		its.Nil[*Counts]().Match(got).OrError(t)
+	})
+
+	t.Run("non-struct is rejected", func(t *testing.T) {
+		_, err := parser.NewTypedArgs(new(string))
+		its.Not(its.Nil[error]()).Match(err).OrError(t)
+	})
+}
+
+func TestParser_declarationsAreCopied(t *testing.T) {
+	type Flag struct {
+		JSON bool `flag:"json"`
+		YAML bool `flag:"yaml"`
+	}
+	testee, err := parser.New(
+		&Flag{}, []params.ArgDef{{Name: "SOURCE"}},
+		parser.WithConstraints(parser.MutuallyExclusive("json", "yaml")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testee.Flags()[0] = nil
+	testee.Args()[0] = nil
+	testee.Constraints()[0] = parser.AtLeastOneOf("json")
+
+	its.EqEq("--json").Match(testee.Flags()[0].Name()).OrError(t)
+	its.EqEq("SOURCE").Match(testee.Args()[0].Name()).OrError(t)
+	its.EqEq(parser.MutuallyExclusive("--json", "--yaml").String()).
+		Match(testee.Constraints()[0].String()).OrError(t)
+}
+
+func TestNew_conflicts(t *testing.T) {
+	t.Run("name and alias", func(t *testing.T) {
+		type Flag struct {
+			Format string `alias:"f"`
+			F      bool
+		}
+		_, err := parser.New(&Flag{}, []params.ArgDef{})
+		its.Error(parser.ErrFlagConflict).Match(err).OrError(t)
+		its.EqEq("flag name conflicts: -f is declared by field Format and field F").
+			Match(err.Error()).OrError(t)
+	})
+
+	t.Run("aliases", func(t *testing.T) {
+		type Flag struct {
+			Format string `alias:"fmt,f"`
+			Force  bool   `alias:"f"`
+		}
+		_, err := parser.New(&Flag{}, []params.ArgDef{})
+		its.EqEq("flag name conflicts: -f is declared by field Format and field Force").
+			Match(err.Error()).OrError(t)
+	})
+
+	t.Run("negation", func(t *testing.T) {
+		type Flag struct {
+			Color   bool
+			NoColor bool
+		}
+		_, err := parser.New(&Flag{}, []params.ArgDef{})
+		its.EqEq("flag name conflicts: --no-color is declared by negation of field Color and field NoColor").
+			Match(err.Error()).OrError(t)
+	})
+
+	t.Run("not negatable", func(t *testing.T) {
+		type Flag struct {
+			Color   bool `negatable:"false"`
+			NoColor bool
+		}
+		_, err := parser.New(&Flag{}, []params.ArgDef{})
+		its.Nil[error]().Match(err).OrError(t)
+	})
+}
+
+func TestCheckFlagConflicts(t *testing.T) {
+	type Outer struct {
+		Region string `alias:"r"`
+	}
+	type Inner struct {
+		Recursive bool `alias:"r"`
+	}
+	type Other struct {
+		Regions []string
+	}
+
+	outer, err := parser.New(&Outer{}, []params.ArgDef{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner, err := parser.New(&Inner{}, []params.ArgDef{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := parser.New(&Other{}, []params.ArgDef{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("conflicting", func(t *testing.T) {
+		err := parser.CheckFlagConflicts(outer.Flags(), inner.Flags())
+		its.Error(parser.ErrFlagConflict).Match(err).OrError(t)
+		its.EqEq("flag name conflicts: -r is declared by field Region and field Recursive").
+			Match(err.Error()).OrError(t)
+	})
+
+	t.Run("not conflicting", func(t *testing.T) {
+		err := parser.CheckFlagConflicts(outer.Flags(), other.Flags())
+		its.Nil[error]().Match(err).OrError(t)
+	})
 }
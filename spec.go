@@ -0,0 +1,179 @@
+package flarc
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/youta-t/flarc/params"
+)
+
+// CommandSpec is a machine readable description of a command and its subcommands.
+//
+// It is encoded to JSON as it is, and its format is kept compatible across releases:
+// fields may be added, but not removed nor changed.
+type CommandSpec struct {
+	// Name is the name of the command. For the root, it is the name of the executable.
+	Name string `json:"name"`
+
+	// Path is names of commands from the root to this command, including the both.
+	Path []string `json:"path"`
+
+	ShortDescription string `json:"shortDescription"`
+
+	// Description is the description of the command, rendered for this command.
+	Description string `json:"description"`
+
+	// Flags are flags of this command, excluding ones of its command groups.
+	Flags []FlagSpec `json:"flags"`
+
+	// Constraints are descriptions of constraints among flags.
+	Constraints []string `json:"constraints"`
+
+	Args []ArgSpec `json:"args"`
+
+	// Subcommands of this command, sorted by name.
+	Subcommands []CommandSpec `json:"subcommands"`
+}
+
+// FlagSpec is a machine readable description of a flag.
+type FlagSpec struct {
+	// Name is the name of the flag, with leading hyphens, like "--name".
+	Name string `json:"name"`
+
+	// Aliases are other names of the flag, with leading hyphens.
+	Aliases []string `json:"aliases"`
+
+	// Type is the Go type of the field declaring the flag, like "int" or "[]string".
+	Type string `json:"type"`
+
+	// Default is the default value in text. If no default value is set, it is "".
+	Default string `json:"default"`
+
+	// Metavar is the value shown in usage, like "--name=METAVAR".
+	Metavar string `json:"metavar"`
+
+	Help string `json:"help"`
+
+	// Env is the name of environment variable bound to the flag, or "".
+	Env string `json:"env"`
+
+	Required bool `json:"required"`
+
+	// Negatable is true if the flag can be negated with "--no-" prefix.
+	Negatable bool `json:"negatable"`
+
+	// Choices are values the flag accepts. If it accepts any values, it is empty.
+	Choices []string `json:"choices"`
+}
+
+// ArgSpec is a machine readable description of a positional argument.
+type ArgSpec struct {
+	Name       string `json:"name"`
+	Help       string `json:"help"`
+	Required   bool   `json:"required"`
+	Repeatable bool   `json:"repeatable"`
+}
+
+// Spec describes cmd and its subcommands.
+//
+// name is the name of the executable, as WithName for Run.
+// The flag "--help" is included in flags of the root, as Run provides it by default.
+func Spec(name string, cmd Command) (CommandSpec, error) {
+	hp, err := helpParser()
+	if err != nil {
+		return CommandSpec{}, err
+	}
+
+	spec, err := commandSpec([]string{name}, cmd)
+	if err != nil {
+		return CommandSpec{}, err
+	}
+	for _, f := range hp.Flags() {
+		spec.Flags = append(spec.Flags, flagSpec(f))
+	}
+	return spec, nil
+}
+
+// WriteSpec writes the spec of cmd and its subcommands as JSON.
+//
+// name is the name of the executable, as WithName for Run.
+func WriteSpec(w io.Writer, name string, cmd Command) error {
+	spec, err := Spec(name, cmd)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(spec)
+}
+
+func commandSpec(path []string, cmd Command) (CommandSpec, error) {
+	fullname := strings.Join(path, " ")
+
+	description := ""
+	if tpl := cmd.descriptionTemplate(); tpl != nil {
+		sb := new(strings.Builder)
+		if err := tpl.Execute(sb, struct{ Command string }{Command: fullname}); err != nil {
+			return CommandSpec{}, err
+		}
+		description = sb.String()
+	}
+
+	spec := CommandSpec{
+		Name:             path[len(path)-1],
+		Path:             path,
+		ShortDescription: cmd.ShortDescription(),
+		Description:      description,
+		Flags:            []FlagSpec{},
+		Constraints:      constraintDescriptions(cmd.listConstraints()),
+		Args:             []ArgSpec{},
+		Subcommands:      []CommandSpec{},
+	}
+	for _, f := range cmd.Flags() {
+		spec.Flags = append(spec.Flags, flagSpec(f))
+	}
+	for _, a := range cmd.Args() {
+		spec.Args = append(spec.Args, ArgSpec{
+			Name:       a.Name(),
+			Help:       a.Help(),
+			Required:   a.Required(),
+			Repeatable: a.Repeatable(),
+		})
+	}
+
+	subs := cmd.Subcommands()
+	names := make([]string, 0, len(subs))
+	for n := range subs {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	for _, n := range names {
+		s, err := commandSpec(append(path[:len(path):len(path)], n), subs[n])
+		if err != nil {
+			return CommandSpec{}, err
+		}
+		spec.Subcommands = append(spec.Subcommands, s)
+	}
+	return spec, nil
+}
+
+func flagSpec(f params.Flag) FlagSpec {
+	metavar := ""
+	if _, m, ok := strings.Cut(f.Usage(), "="); ok {
+		metavar = m
+	}
+	return FlagSpec{
+		Name:      f.Name(),
+		Aliases:   append([]string{}, f.Alias()...),
+		Type:      f.Type().String(),
+		Default:   f.Default(),
+		Metavar:   metavar,
+		Help:      f.Help(),
+		Env:       f.Env(),
+		Required:  f.Required(),
+		Negatable: f.Negatable(),
+		Choices:   append([]string{}, f.Choices()...),
+	}
+}
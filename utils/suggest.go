@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Suggest returns candidates similar to given, most similar first.
+//
+// A candidate is similar when it has given as its prefix,
+// or its edit distance from given is small enough for its length.
+func Suggest(given string, candidates []string) []string {
+	if given == "" {
+		return []string{}
+	}
+
+	type scored struct {
+		name     string
+		distance int
+	}
+	found := []scored{}
+	for _, c := range candidates {
+		if c == given || slices.ContainsFunc(found, func(s scored) bool { return s.name == c }) {
+			continue
+		}
+		d := editDistance(given, c)
+		if d <= max(1, len(given)/3) || strings.HasPrefix(c, given) {
+			found = append(found, scored{name: c, distance: d})
+		}
+	}
+	slices.SortStableFunc(found, func(a, b scored) int {
+		if d := cmp.Compare(a.distance, b.distance); d != 0 {
+			return d
+		}
+		return cmp.Compare(a.name, b.name)
+	})
+
+	ret := make([]string, len(found))
+	for i := range found {
+		ret[i] = found[i].name
+	}
+	return ret
+}
+
+// DidYouMean formats suggestions like "did you mean `a` or `b`?".
+//
+// If there are no suggestions, it returns "".
+func DidYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(suggestions))
+	for i := range suggestions {
+		quoted[i] = "`" + suggestions[i] + "`"
+	}
+	return "did you mean " + strings.Join(quoted, " or ") + "?"
+}
+
+// editDistance returns the edit distance between a and b,
+// counting insertions, deletions, substitutions and transpositions of adjacent characters.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// d[i][j] is the distance between ra[:i] and rb[:j]
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i += 1 {
+		for j := 1; j <= len(rb); j += 1 {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if 1 < i && 1 < j && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
@@ -0,0 +1,44 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/youta-t/flarc/utils"
+	"github.com/youta-t/its"
+)
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"status", "stash", "start", "list", "format", "force"}
+
+	theory := func(given string, then []string) func(*testing.T) {
+		return func(t *testing.T) {
+			got := utils.Suggest(given, candidates)
+
+			matchers := []its.Matcher[string]{}
+			for _, w := range then {
+				matchers = append(matchers, its.EqEq(w))
+			}
+			its.Slice(matchers...).Match(got).OrError(t)
+		}
+	}
+
+	t.Run("transposed", theory("statsu", []string{"status", "stash"}))
+	t.Run("missing a character", theory("lst", []string{"list"}))
+	t.Run("transposed and prefix", theory("fromat", []string{"format"}))
+	t.Run("prefix", theory("sta", []string{"start", "stash", "status"}))
+	t.Run("nothing similar", theory("delete", []string{}))
+	t.Run("exact", theory("list", []string{}))
+	t.Run("empty", theory("", []string{}))
+}
+
+func TestDidYouMean(t *testing.T) {
+	theory := func(given []string, then string) func(*testing.T) {
+		return func(t *testing.T) {
+			its.EqEq(then).Match(utils.DidYouMean(given)).OrError(t)
+		}
+	}
+
+	t.Run("one", theory([]string{"status"}, "did you mean `status`?"))
+	t.Run("many", theory([]string{"status", "stash"}, "did you mean `status` or `stash`?"))
+	t.Run("none", theory([]string{}, ""))
+}
@@ -0,0 +1,46 @@
+package flarc
+
+import (
+	"errors"
+	"slices"
+)
+
+// SkipSubcommands is used as a return value from functions for Walk,
+// to skip subcommands of the command.
+var SkipSubcommands = errors.New("skip subcommands")
+
+// Walk calls fn for cmd and its subcommands recursively, depth first.
+//
+// path is names of subcommands from cmd to c. For cmd itself, path is empty.
+// Subcommands are visited in order of their names.
+//
+// If fn returns SkipSubcommands, subcommands of c are skipped.
+// If fn returns other errors, Walk stops and returns it.
+func Walk(cmd Command, fn func(path []string, c Command) error) error {
+	err := walk([]string{}, cmd, fn)
+	if errors.Is(err, SkipSubcommands) {
+		return nil
+	}
+	return err
+}
+
+func walk(path []string, cmd Command, fn func(path []string, c Command) error) error {
+	if err := fn(path, cmd); err != nil {
+		return err
+	}
+
+	subs := cmd.Subcommands()
+	names := make([]string, 0, len(subs))
+	for n := range subs {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+
+	for _, n := range names {
+		err := walk(append(path[:len(path):len(path)], n), subs[n], fn)
+		if err != nil && !errors.Is(err, SkipSubcommands) {
+			return err
+		}
+	}
+	return nil
+}